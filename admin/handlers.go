@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"awsx-api/appstate"
+	"awsx-api/cache"
+	"awsx-api/handlers"
+	"awsx-api/log"
+	"awsx-api/logsinsights"
+	"awsx-api/status"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+)
+
+type logLevel struct {
+	Level    string `json:"level"`
+	Previous string `json:"previous,omitempty"`
+}
+
+func getLogLevel(w http.ResponseWriter, r *http.Request) {
+	handlers.RespondWithJSON(w, http.StatusOK, logLevel{Level: log.GetLevel()})
+}
+
+// setLogLevel takes the level from the body ({"level": "debug"}) or the level query parameter.
+func setLogLevel(w http.ResponseWriter, r *http.Request) {
+	requested := logLevel{Level: r.URL.Query().Get("level")}
+	if requested.Level == "" {
+		if err := json.NewDecoder(r.Body).Decode(&requested); err != nil {
+			respondWithError(w, http.StatusBadRequest, "body must be {\"level\": \"<level>\"}: "+err.Error())
+			return
+		}
+	}
+	previous := log.GetLevel()
+	if err := log.SetLevel(requested.Level); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	audit(r, "set-log-level").Str("previous", previous).Str("level", log.GetLevel()).Send()
+	log.FromContext(r.Context()).Infof("Log level changed from [%s] to [%s]", previous, log.GetLevel())
+	handlers.RespondWithJSON(w, http.StatusOK, logLevel{Level: log.GetLevel(), Previous: previous})
+}
+
+func listCaches(w http.ResponseWriter, r *http.Request) {
+	handlers.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"sizes":   cache.Sizes(),
+		"entries": cache.Entries(),
+	})
+}
+
+// evictCache evicts the entry given by the key query parameter, or the whole cache without it.
+func evictCache(w http.ResponseWriter, r *http.Request) {
+	cacheName := mux.Vars(r)["cache"]
+	key := r.URL.Query().Get("key")
+	evicted, err := cache.Evict(cacheName, key)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	audit(r, "evict-cache").Str("cache", cacheName).Str("key", key).Int("evicted", evicted).Send()
+	log.FromContext(r.Context()).Infof("Evicted %d entries from the [%s] cache", evicted, cacheName)
+	handlers.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"cache":   cacheName,
+		"key":     key,
+		"evicted": evicted,
+	})
+}
+
+func listInFlightRequests(w http.ResponseWriter, r *http.Request) {
+	handlers.RespondWithJSON(w, http.StatusOK, appstate.InFlightRequests())
+}
+
+func listLogsInsightsQueries(w http.ResponseWriter, r *http.Request) {
+	handlers.RespondWithJSON(w, http.StatusOK, logsinsights.Running())
+}
+
+// dependency is the state of a dependency as the health report shows it, with its url and the
+// error of its last failed check.
+type dependency struct {
+	status.Dependency
+	Url   string `json:"url"`
+	Error string `json:"error,omitempty"`
+}
+
+func listDependencies(w http.ResponseWriter, r *http.Request) {
+	dependencies := []dependency{}
+	for _, dep := range status.Dependencies() {
+		dependencies = append(dependencies, dependency{Dependency: dep, Url: dep.Url, Error: dep.Error})
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, dependencies)
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	handlers.RespondWithJSON(w, code, map[string]string{"error": message})
+}
+
+// audit starts an audit record of an admin operation that changes state.
+func audit(r *http.Request, operation string) *zerolog.Event {
+	return log.Audit().
+		Str("route", "admin").
+		Str("operation", operation).
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Str("remoteAddr", r.RemoteAddr)
+}
@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"awsx-api/config"
+	"awsx-api/log"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const tokenEnvVar = "AWSX_API_ADMIN_TOKEN"
+
+// Server is the management listener. It is bound to its own address so it can be kept off the
+// network that serves the API.
+type Server struct {
+	httpServer *http.Server
+}
+
+// NewServer returns the management server, or nil when it is disabled or has no token.
+func NewServer() *Server {
+	conf := config.Get().Server.Admin
+	if !conf.Enabled {
+		return nil
+	}
+	token := conf.Token
+	if envToken := os.Getenv(tokenEnvVar); envToken != "" {
+		token = envToken
+	}
+	if token == "" {
+		log.Warningf("Admin endpoint is enabled but no token is set (server.admin.token or %s), it will not start", tokenEnvVar)
+		return nil
+	}
+
+	return &Server{
+		httpServer: &http.Server{
+			Addr:         fmt.Sprintf("%v:%v", conf.Address, conf.Port),
+			Handler:      requireToken(token, newRouter()),
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 120 * time.Second, // long enough for a cpu profile
+		},
+	}
+}
+
+func newRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/log-level", getLogLevel).Methods(http.MethodGet)
+	router.HandleFunc("/admin/log-level", setLogLevel).Methods(http.MethodPut)
+	router.HandleFunc("/admin/caches", listCaches).Methods(http.MethodGet)
+	router.HandleFunc("/admin/caches/{cache}", evictCache).Methods(http.MethodDelete)
+	router.HandleFunc("/admin/dependencies", listDependencies).Methods(http.MethodGet)
+	router.HandleFunc("/admin/requests", listInFlightRequests).Methods(http.MethodGet)
+	router.HandleFunc("/admin/logs-insights/queries", listLogsInsightsQueries).Methods(http.MethodGet)
+
+	router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	router.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	router.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	router.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+	return router
+}
+
+// requireToken accepts the token as bearer token or in the X-Admin-Token header.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := r.Header.Get("X-Admin-Token")
+		if bearer := r.Header.Get("Authorization"); strings.HasPrefix(bearer, "Bearer ") {
+			provided = strings.TrimPrefix(bearer, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			log.Warningf("Rejected admin request [%s %s] from [%s]: invalid token", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="awsx-api admin"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Start the management server asynchronously.
+func (s *Server) Start() {
+	log.Infof("Admin endpoint will start at [%v]", s.httpServer.Addr)
+	go func() {
+		if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("Admin endpoint failed: %v", err)
+		}
+	}()
+}
+
+// Stop the management server, waiting for running requests until ctx is done.
+func (s *Server) Stop(ctx context.Context) {
+	log.Infof("Admin endpoint will stop at [%v]", s.httpServer.Addr)
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.httpServer.Close()
+	}
+}
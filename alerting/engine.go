@@ -0,0 +1,348 @@
+package alerting
+
+import (
+	"awsx-api/config"
+	"awsx-api/handlers"
+	"awsx-api/log"
+	"awsx-api/panel"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	StatePending  = "pending"
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// Alert is the state of a rule for one element and series. It is pending while the condition
+// holds for less than the For of its rule, firing after that and resolved once the condition
+// is false again.
+type Alert struct {
+	Rule        string            `json:"rule"`
+	State       string            `json:"state"`
+	Severity    string            `json:"severity"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Value       float64           `json:"value"`
+	ActiveAt    time.Time         `json:"activeAt"`
+	FiredAt     *time.Time        `json:"firedAt,omitempty"`
+	ResolvedAt  *time.Time        `json:"resolvedAt,omitempty"`
+	// Flapping tells that the alert started firing flap_threshold times within flap_window.
+	// A flapping alert only resolves after its condition was false for a whole flap window.
+	Flapping bool `json:"flapping,omitempty"`
+
+	falseSince time.Time
+}
+
+// RuleHealth is the outcome of the last evaluation of a rule.
+type RuleHealth struct {
+	LastEvaluation *time.Time `json:"lastEvaluation,omitempty"`
+	DurationMs     int64      `json:"durationMs"`
+	LastError      string     `json:"lastError,omitempty"`
+}
+
+// Listener is told the alerts after every evaluation, e.g. to send notifications about them.
+type Listener func(now time.Time, alerts []Alert)
+
+// queryFunc returns the series of the panel of a rule for one element.
+type queryFunc func(ctx context.Context, rule Rule, element map[string]string, now time.Time) ([]panel.Series, error)
+
+// Engine evaluates the rules and keeps the state of their alerts.
+type Engine struct {
+	rules     []Rule
+	settings  config.Alerting
+	query     queryFunc
+	listeners []Listener
+
+	mu     sync.RWMutex
+	alerts map[string]*Alert
+	// starts are the times alerts started firing within the flap window, by alert key. They
+	// outlive the alerts, so that an alert that resolves and fires again is seen flapping.
+	starts map[string][]time.Time
+	health map[string]*RuleHealth
+}
+
+var (
+	current     *Engine
+	currentLock sync.RWMutex
+)
+
+// NewEngine creates an engine of the rules that queries the panels of getQueryOutput.
+func NewEngine(rules []Rule, settings config.Alerting) *Engine {
+	return &Engine{
+		rules:    rules,
+		settings: settings,
+		query:    runPanel,
+		alerts:   map[string]*Alert{},
+		starts:   map[string][]time.Time{},
+		health:   map[string]*RuleHealth{},
+	}
+}
+
+// Start loads the rules and evaluates them right away and then every evaluation_interval
+// seconds until ctx is done, telling the listeners the alerts after every evaluation. The
+// engine serves /awsx-api/alerts.
+func Start(ctx context.Context, settings config.Alerting, listeners ...Listener) error {
+	rules, err := LoadRules(settings.RuleFiles)
+	if err != nil {
+		return err
+	}
+	engine := NewEngine(rules, settings)
+	engine.listeners = listeners
+	currentLock.Lock()
+	current = engine
+	currentLock.Unlock()
+	log.Infof("Alerting started with %d rules, evaluated every %ds", len(rules), settings.EvaluationInterval)
+
+	interval := time.Duration(settings.EvaluationInterval) * time.Second
+	go func() {
+		engine.Evaluate(ctx, time.Now())
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				engine.Evaluate(ctx, now)
+			}
+		}
+	}()
+	return nil
+}
+
+// Current is the engine Start started, nil when alerting is not enabled.
+func Current() *Engine {
+	currentLock.RLock()
+	defer currentLock.RUnlock()
+	return current
+}
+
+// Evaluate evaluates every rule at now and tells the listeners the alerts.
+func (engine *Engine) Evaluate(ctx context.Context, now time.Time) {
+	for _, rule := range engine.rules {
+		if ctx.Err() != nil {
+			return
+		}
+		engine.evaluateRule(ctx, rule, now)
+	}
+	engine.prune(now)
+	if len(engine.listeners) > 0 {
+		alerts := engine.Alerts()
+		for _, listener := range engine.listeners {
+			listener(now, alerts)
+		}
+	}
+}
+
+// prune forgets the alerts resolved longer than the retention ago and the firing starts
+// before the flap window.
+func (engine *Engine) prune(now time.Time) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	retention := time.Duration(engine.settings.ResolvedRetention) * time.Second
+	for key, alert := range engine.alerts {
+		if alert.State == StateResolved && now.Sub(*alert.ResolvedAt) >= retention {
+			delete(engine.alerts, key)
+		}
+	}
+	for key := range engine.starts {
+		engine.pruneStarts(key, now)
+	}
+}
+
+func (engine *Engine) evaluateRule(ctx context.Context, rule Rule, now time.Time) {
+	type sample struct {
+		labels map[string]string
+		value  float64
+	}
+	start := time.Now()
+	samples := map[string]sample{}
+	evaluated := map[string]bool{}
+	var errs []string
+	for _, element := range rule.Elements {
+		series, err := engine.query(ctx, rule, element, now)
+		if err != nil {
+			log.Warningf("Rule [%s] failed for element [%s]: %v", rule.Name, elementOf(element), err)
+			errs = append(errs, fmt.Sprintf("element [%s]: %v", elementOf(element), err))
+			continue
+		}
+		evaluated[elementOf(element)] = true
+		for _, s := range series {
+			if (rule.Series != "" && s.Name != rule.Series) || len(s.Points) == 0 {
+				continue
+			}
+			labels := map[string]string{}
+			for k, v := range rule.Labels {
+				labels[k] = v
+			}
+			labels["alertname"] = rule.Name
+			labels["severity"] = rule.Severity
+			labels["elementType"] = rule.Panel.ElementType
+			labels["query"] = rule.Panel.Query
+			labels["element"] = elementOf(element)
+			labels["series"] = s.Name
+			samples[alertKey(rule.Name, labels["element"], s.Name)] = sample{labels, latest(s.Points).Value}
+		}
+	}
+
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	compare := comparators[rule.Comparator]
+	for key, sample := range samples {
+		engine.transition(rule, key, sample.labels, sample.value, compare(sample.value, rule.Threshold), now)
+	}
+	// the series that are gone from an element that answered no longer meet the condition
+	for key, alert := range engine.alerts {
+		if _, ok := samples[key]; !ok && alert.Rule == rule.Name && evaluated[alert.Labels["element"]] {
+			engine.transition(rule, key, alert.Labels, alert.Value, false, now)
+		}
+	}
+
+	health := &RuleHealth{LastEvaluation: &now, DurationMs: time.Since(start).Milliseconds()}
+	if len(errs) > 0 {
+		health.LastError = fmt.Sprint(errs)
+	}
+	engine.health[rule.Name] = health
+}
+
+// transition moves an alert on by the outcome of an evaluation.
+func (engine *Engine) transition(rule Rule, key string, labels map[string]string, value float64, active bool, now time.Time) {
+	alert, ok := engine.alerts[key]
+	if !active {
+		if !ok {
+			return
+		}
+		alert.Value = value
+		switch alert.State {
+		case StatePending:
+			delete(engine.alerts, key)
+		case StateFiring:
+			if alert.falseSince.IsZero() {
+				alert.falseSince = now
+			}
+			hold := time.Duration(rule.KeepFiringFor)
+			if flapWindow := time.Duration(engine.settings.FlapWindow) * time.Second; alert.Flapping && flapWindow > hold {
+				hold = flapWindow
+			}
+			if now.Sub(alert.falseSince) >= hold {
+				alert.State = StateResolved
+				alert.ResolvedAt = &now
+			}
+		}
+		return
+	}
+
+	if !ok || alert.State == StateResolved {
+		alert = &Alert{
+			Rule:        rule.Name,
+			State:       StatePending,
+			Severity:    rule.Severity,
+			Labels:      labels,
+			Annotations: rule.Annotations,
+			ActiveAt:    now,
+		}
+		engine.alerts[key] = alert
+	}
+	alert.Value = value
+	alert.falseSince = time.Time{}
+	if alert.State == StatePending && now.Sub(alert.ActiveAt) >= time.Duration(rule.For) {
+		alert.State = StateFiring
+		alert.FiredAt = &now
+		engine.starts[key] = append(engine.starts[key], now)
+		engine.pruneStarts(key, now)
+	}
+}
+
+// pruneStarts forgets the firing starts before the flap window and updates whether the alert
+// is flapping.
+func (engine *Engine) pruneStarts(key string, now time.Time) {
+	window := time.Duration(engine.settings.FlapWindow) * time.Second
+	starts := engine.starts[key]
+	for len(starts) > 0 && now.Sub(starts[0]) > window {
+		starts = starts[1:]
+	}
+	if len(starts) == 0 {
+		delete(engine.starts, key)
+	} else {
+		engine.starts[key] = starts
+	}
+	if alert, ok := engine.alerts[key]; ok {
+		alert.Flapping = engine.settings.FlapThreshold > 0 && len(starts) >= engine.settings.FlapThreshold
+	}
+}
+
+// Alerts lists the alerts ordered by rule and labels.
+func (engine *Engine) Alerts() []Alert {
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+	alerts := make([]Alert, 0, len(engine.alerts))
+	keys := make([]string, 0, len(engine.alerts))
+	for key := range engine.alerts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		alerts = append(alerts, *engine.alerts[key])
+	}
+	return alerts
+}
+
+// Rules lists the rules with the health of their last evaluation.
+func (engine *Engine) Rules() ([]Rule, map[string]RuleHealth) {
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+	health := map[string]RuleHealth{}
+	for name, h := range engine.health {
+		health[name] = *h
+	}
+	return engine.rules, health
+}
+
+// latest is the newest point of a series.
+func latest(points []panel.Point) panel.Point {
+	newest := points[0]
+	for _, p := range points[1:] {
+		if p.Time.After(newest.Time) {
+			newest = p
+		}
+	}
+	return newest
+}
+
+func alertKey(rule, element, series string) string {
+	return rule + "\x00" + element + "\x00" + series
+}
+
+// runPanel runs the panel of a rule for an element over the lookback of the rule.
+func runPanel(ctx context.Context, rule Rule, element map[string]string, now time.Time) ([]panel.Series, error) {
+	definition, ok := handlers.Panels().Lookup(rule.Panel.ElementType, rule.Panel.Query)
+	if !ok {
+		return nil, fmt.Errorf("unknown panel [%s] of elementType [%s]", rule.Panel.Query, rule.Panel.ElementType)
+	}
+	params := url.Values{}
+	for k, v := range element {
+		params.Set(k, v)
+	}
+	if rule.Stat != "" {
+		params.Set("stat", rule.Stat)
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/awsx-api/getQueryOutput", nil)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := panel.Run(r, definition, params, now.Add(-time.Duration(rule.Lookback)), now)
+	if err != nil {
+		return nil, err
+	}
+	return panel.Normalize(payload, now), nil
+}
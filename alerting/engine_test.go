@@ -0,0 +1,154 @@
+package alerting
+
+import (
+	"awsx-api/config"
+	"awsx-api/panel"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// step is one evaluation: the value the panel answers with at the offset, or no series, or an
+// error, and the state of the alert afterwards, "" for none.
+type step struct {
+	at       time.Duration
+	value    float64
+	missing  bool
+	fail     bool
+	want     string
+	flapping bool
+}
+
+func TestEvaluate(t *testing.T) {
+	settings := config.Alerting{ResolvedRetention: 120}
+	flapSettings := config.Alerting{FlapThreshold: 2, FlapWindow: 600}
+	tests := []struct {
+		name          string
+		settings      config.Alerting
+		forDuration   time.Duration
+		keepFiringFor time.Duration
+		steps         []step
+	}{
+		{"below threshold", settings, 0, 0, []step{
+			{at: 0, value: 50, want: ""},
+		}},
+		{"fires without for", settings, 0, 0, []step{
+			{at: 0, value: 90, want: StateFiring},
+		}},
+		{"pending for the duration of for", settings, 3 * time.Minute, 0, []step{
+			{at: 0, value: 90, want: StatePending},
+			{at: 2 * time.Minute, value: 95, want: StatePending},
+			{at: 3 * time.Minute, value: 90, want: StateFiring},
+		}},
+		{"pending alert dropped when false", settings, 3 * time.Minute, 0, []step{
+			{at: 0, value: 90, want: StatePending},
+			{at: time.Minute, value: 50, want: ""},
+			{at: 2 * time.Minute, value: 90, want: StatePending},
+			{at: 4 * time.Minute, value: 90, want: StatePending},
+			{at: 5 * time.Minute, value: 90, want: StateFiring},
+		}},
+		{"resolved alert kept for the retention", settings, 0, 0, []step{
+			{at: 0, value: 90, want: StateFiring},
+			{at: time.Minute, value: 50, want: StateResolved},
+			{at: 2 * time.Minute, value: 50, want: StateResolved},
+			{at: 3 * time.Minute, value: 50, want: ""},
+		}},
+		{"resolved alert fires again", settings, time.Minute, 0, []step{
+			{at: 0, value: 90, want: StatePending},
+			{at: time.Minute, value: 90, want: StateFiring},
+			{at: 2 * time.Minute, value: 50, want: StateResolved},
+			{at: 3 * time.Minute, value: 90, want: StatePending},
+			{at: 4 * time.Minute, value: 90, want: StateFiring},
+		}},
+		{"keep firing for", settings, 0, 2 * time.Minute, []step{
+			{at: 0, value: 90, want: StateFiring},
+			{at: time.Minute, value: 50, want: StateFiring},
+			{at: 2 * time.Minute, value: 90, want: StateFiring},
+			{at: 3 * time.Minute, value: 50, want: StateFiring},
+			{at: 4 * time.Minute, value: 50, want: StateFiring},
+			{at: 5 * time.Minute, value: 50, want: StateResolved},
+		}},
+		{"series gone from an element that answered", settings, 0, 0, []step{
+			{at: 0, value: 90, want: StateFiring},
+			{at: time.Minute, missing: true, want: StateResolved},
+		}},
+		{"failed query keeps the state", settings, 0, 0, []step{
+			{at: 0, value: 90, want: StateFiring},
+			{at: time.Minute, fail: true, want: StateFiring},
+			{at: 2 * time.Minute, value: 50, want: StateResolved},
+		}},
+		{"flapping alert holds for the flap window", flapSettings, 0, 0, []step{
+			{at: 0, value: 90, want: StateFiring},
+			{at: time.Minute, value: 50, want: ""},
+			{at: 2 * time.Minute, value: 90, want: StateFiring, flapping: true},
+			{at: 3 * time.Minute, value: 50, want: StateFiring, flapping: true},
+			{at: 10 * time.Minute, value: 50, want: StateFiring, flapping: true},
+			{at: 12 * time.Minute, value: 50, want: StateFiring},
+			{at: 13 * time.Minute, value: 50, want: ""},
+		}},
+		{"firing starts leave the flap window", flapSettings, 0, 0, []step{
+			{at: 0, value: 90, want: StateFiring},
+			{at: time.Minute, value: 50, want: ""},
+			{at: 2 * time.Minute, value: 90, want: StateFiring, flapping: true},
+			{at: 11 * time.Minute, value: 90, want: StateFiring},
+		}},
+	}
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule := Rule{
+				Name:          "high-cpu",
+				Panel:         Panel{ElementType: "EC2", Query: "cpu_utilization_panel"},
+				Elements:      []map[string]string{{"elementId": "1"}},
+				Comparator:    ">",
+				Threshold:     80,
+				For:           Duration(test.forDuration),
+				KeepFiringFor: Duration(test.keepFiringFor),
+				Severity:      SeverityWarning,
+			}
+			engine := NewEngine([]Rule{rule}, test.settings)
+			var current step
+			engine.query = func(ctx context.Context, rule Rule, element map[string]string, now time.Time) ([]panel.Series, error) {
+				switch {
+				case current.fail:
+					return nil, errors.New("throttled")
+				case current.missing:
+					return nil, nil
+				}
+				return []panel.Series{{Name: "cpu", Points: []panel.Point{{Time: now, Value: current.value}}}}, nil
+			}
+			for _, current = range test.steps {
+				engine.Evaluate(context.Background(), start.Add(current.at))
+				alerts := engine.Alerts()
+				if current.want == "" {
+					if len(alerts) != 0 {
+						t.Fatalf("at %v: got %s alert, want none", current.at, alerts[0].State)
+					}
+					continue
+				}
+				if len(alerts) != 1 {
+					t.Fatalf("at %v: got %d alerts, want one %s", current.at, len(alerts), current.want)
+				}
+				if alerts[0].State != current.want || alerts[0].Flapping != current.flapping {
+					t.Fatalf("at %v: got %s alert (flapping %v), want %s (flapping %v)",
+						current.at, alerts[0].State, alerts[0].Flapping, current.want, current.flapping)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateHealth(t *testing.T) {
+	rule := Rule{Name: "high-cpu", Elements: []map[string]string{{"elementId": "1"}}, Comparator: ">", Threshold: 80}
+	engine := NewEngine([]Rule{rule}, config.Alerting{})
+	engine.query = func(ctx context.Context, rule Rule, element map[string]string, now time.Time) ([]panel.Series, error) {
+		return nil, errors.New("throttled")
+	}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	engine.Evaluate(context.Background(), now)
+	_, health := engine.Rules()
+	if h := health["high-cpu"]; h.LastEvaluation == nil || !h.LastEvaluation.Equal(now) || h.LastError == "" {
+		t.Errorf("health = %+v, want the evaluation at %v with its error", h, now)
+	}
+}
@@ -0,0 +1,67 @@
+package alerting
+
+import (
+	"awsx-api/handlers"
+	"awsx-api/log"
+	"net/http"
+)
+
+// secretParams are the element parameters GetRules does not show.
+var secretParams = []string{"crossAccountRoleArn", "externalId"}
+
+// ruleStatus is a rule with the health of its last evaluation.
+type ruleStatus struct {
+	Rule
+	RuleHealth
+}
+
+// GetAlerts lists the pending, firing and recently resolved alerts, optionally only those of a
+// state, severity or rule.
+func GetAlerts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	alerts := []Alert{}
+	if engine := Current(); engine != nil {
+		for _, alert := range engine.Alerts() {
+			if matches(query.Get("state"), alert.State) && matches(query.Get("severity"), alert.Severity) && matches(query.Get("rule"), alert.Rule) {
+				alerts = append(alerts, alert)
+			}
+		}
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"alerts": alerts})
+}
+
+// GetRules lists the rules with the time, duration and error of their last evaluation. The
+// role arns and external ids of the elements are redacted.
+func GetRules(w http.ResponseWriter, r *http.Request) {
+	rules := []ruleStatus{}
+	if engine := Current(); engine != nil {
+		definitions, health := engine.Rules()
+		for _, rule := range definitions {
+			rule.Elements = redactElements(rule.Elements)
+			rules = append(rules, ruleStatus{Rule: rule, RuleHealth: health[rule.Name]})
+		}
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"rules": rules})
+}
+
+// redactElements copies the elements with their secret parameters redacted.
+func redactElements(elements []map[string]string) []map[string]string {
+	redacted := make([]map[string]string, 0, len(elements))
+	for _, element := range elements {
+		shown := make(map[string]string, len(element))
+		for k, v := range element {
+			shown[k] = v
+		}
+		for _, param := range secretParams {
+			if shown[param] != "" {
+				shown[param] = log.Redacted
+			}
+		}
+		redacted = append(redacted, shown)
+	}
+	return redacted
+}
+
+func matches(filter, value string) bool {
+	return filter == "" || filter == value
+}
@@ -0,0 +1,187 @@
+package alerting
+
+import (
+	"awsx-api/handlers"
+	"awsx-api/metricquery"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+
+	// defaultLookback is how far back a rule looks for the latest value of its panel.
+	// CloudWatch publishes most metrics every five minutes and with some delay.
+	defaultLookback = 15 * time.Minute
+)
+
+var comparators = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// Duration is a duration in a rule file, e.g. 10m or 1h30m.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration [%s]", node.Line, node.Value)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + time.Duration(d).String() + `"`), nil
+}
+
+// Panel selects the panel of getQueryOutput a rule evaluates.
+type Panel struct {
+	ElementType string `yaml:"elementType" json:"elementType"`
+	Query       string `yaml:"query" json:"query"`
+}
+
+// Rule fires when the latest value of a panel compares to the threshold for the duration of
+// For. Every element, given by the panel parameters that select it (elementId, or instanceId
+// with zone, crossAccountRoleArn and externalId), and every series of the panel, or only the
+// one named by Series, is an alert of its own.
+type Rule struct {
+	Name       string              `yaml:"name" json:"name"`
+	Panel      Panel               `yaml:"panel" json:"panel"`
+	Elements   []map[string]string `yaml:"elements" json:"elements"`
+	Series     string              `yaml:"series,omitempty" json:"series,omitempty"`
+	Stat       string              `yaml:"stat,omitempty" json:"stat,omitempty"`
+	Comparator string              `yaml:"comparator" json:"comparator"`
+	Threshold  float64             `yaml:"threshold" json:"threshold"`
+	For        Duration            `yaml:"for,omitempty" json:"for"`
+	// KeepFiringFor keeps a firing alert firing until the condition was false this long.
+	KeepFiringFor Duration          `yaml:"keep_firing_for,omitempty" json:"keepFiringFor,omitempty"`
+	Lookback      Duration          `yaml:"lookback,omitempty" json:"lookback"`
+	Severity      string            `yaml:"severity,omitempty" json:"severity"`
+	Labels        map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Annotations   map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+type ruleFile struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// LoadRules reads the rules of the files matching the glob patterns. Rule names must be
+// unique across all files.
+func LoadRules(patterns []string) ([]Rule, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule file pattern [%s]: %v", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	var rules []Rule
+	names := map[string]string{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rule file [%s]: %v", file, err)
+		}
+		fileRules, err := ParseRules(content)
+		if err != nil {
+			return nil, fmt.Errorf("rule file [%s]: %v", file, err)
+		}
+		for _, rule := range fileRules {
+			if other, ok := names[rule.Name]; ok {
+				return nil, fmt.Errorf("rule file [%s]: rule [%s] is already defined in [%s]", file, rule.Name, other)
+			}
+			names[rule.Name] = file
+		}
+		rules = append(rules, fileRules...)
+	}
+	return rules, nil
+}
+
+// ParseRules reads and validates the rules of a rule file and fills in their defaults.
+func ParseRules(content []byte) ([]Rule, error) {
+	var file ruleFile
+	decoder := yaml.NewDecoder(strings.NewReader(string(content)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse yaml: %v", err)
+	}
+	for i := range file.Rules {
+		if err := file.Rules[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	return file.Rules, nil
+}
+
+func (rule *Rule) validate() error {
+	if rule.Name == "" {
+		return fmt.Errorf("rule without name")
+	}
+	if _, ok := handlers.Panels().Lookup(rule.Panel.ElementType, rule.Panel.Query); !ok {
+		return fmt.Errorf("rule [%s]: unknown panel [%s] of elementType [%s]", rule.Name, rule.Panel.Query, rule.Panel.ElementType)
+	}
+	if len(rule.Elements) == 0 {
+		return fmt.Errorf("rule [%s]: no elements", rule.Name)
+	}
+	for _, element := range rule.Elements {
+		if element["elementId"] == "" && element["instanceId"] == "" {
+			return fmt.Errorf("rule [%s]: every element needs an elementId or instanceId", rule.Name)
+		}
+	}
+	if rule.Stat != "" {
+		stat, err := metricquery.ParseStat(rule.Stat)
+		if err != nil {
+			return fmt.Errorf("rule [%s]: %v", rule.Name, err)
+		}
+		spec, ok := metricquery.SpecOf(rule.Panel.ElementType, rule.Panel.Query)
+		if !ok {
+			return fmt.Errorf("rule [%s]: panel [%s] of elementType [%s] is not a CloudWatch metric panel, stat is not supported", rule.Name, rule.Panel.Query, rule.Panel.ElementType)
+		}
+		if spec.Counter && metricquery.IsExtended(stat) {
+			return fmt.Errorf("rule [%s]: panel [%s] counts events, stat [%s] is not supported", rule.Name, rule.Panel.Query, stat)
+		}
+		rule.Stat = stat
+	}
+	if _, ok := comparators[rule.Comparator]; !ok {
+		return fmt.Errorf("rule [%s]: invalid comparator [%s], expected >, >=, <, <=, == or !=", rule.Name, rule.Comparator)
+	}
+	switch rule.Severity {
+	case "":
+		rule.Severity = SeverityWarning
+	case SeverityCritical, SeverityWarning, SeverityInfo:
+	default:
+		return fmt.Errorf("rule [%s]: invalid severity [%s], expected critical, warning or info", rule.Name, rule.Severity)
+	}
+	if rule.For < 0 || rule.KeepFiringFor < 0 || rule.Lookback < 0 {
+		return fmt.Errorf("rule [%s]: durations must not be negative", rule.Name)
+	}
+	if rule.Lookback == 0 {
+		rule.Lookback = Duration(defaultLookback)
+	}
+	return nil
+}
+
+// elementOf names the element of a rule in alerts.
+func elementOf(element map[string]string) string {
+	if element["elementId"] != "" {
+		return element["elementId"]
+	}
+	return element["instanceId"]
+}
@@ -0,0 +1,260 @@
+package anomaly
+
+import (
+	"awsx-api/panel"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// maxScore caps the score of a point that differs from an expectation without any spread,
+// e.g. the first non-zero value of a metric that was flat zero.
+const maxScore = 100
+
+// Evaluation is a point of a series with what the detector expected of it. Lower and Upper
+// are the band outside of which the point is anomalous, Score is the distance from Expected
+// in standard deviations, positive above it.
+type Evaluation struct {
+	Time     time.Time `json:"time"`
+	Value    float64   `json:"value"`
+	Expected float64   `json:"expected"`
+	Lower    float64   `json:"lower"`
+	Upper    float64   `json:"upper"`
+	Score    float64   `json:"score"`
+}
+
+// Interval is a run of consecutive anomalous points. Score is the score of the point
+// furthest from its expectation, Direction tells on which side of the band it lies.
+type Interval struct {
+	Start     time.Time    `json:"start"`
+	End       time.Time    `json:"end"`
+	Score     float64      `json:"score"`
+	Direction string       `json:"direction"`
+	Points    []Evaluation `json:"points"`
+}
+
+// Detector evaluates the points of a series, sorted by time, against a threshold in standard
+// deviations. Points without enough history to judge them are left out.
+type Detector func(points []panel.Point, threshold float64) ([]Evaluation, error)
+
+// ZScore compares every point with the mean and standard deviation of the window points
+// before it. Both are computed over each window from the deviations from its mean; a running
+// sum of squares cancels out on large values such as bytes of storage.
+func ZScore(window int) Detector {
+	return func(points []panel.Point, threshold float64) ([]Evaluation, error) {
+		if window < 2 {
+			return nil, fmt.Errorf("window must be at least 2 points")
+		}
+		if len(points) <= window {
+			return nil, fmt.Errorf("%d points are too few for a window of %d", len(points), window)
+		}
+		evaluations := make([]Evaluation, 0, len(points)-window)
+		for i := window; i < len(points); i++ {
+			mean, stddev := meanAndStddev(points[i-window : i])
+			evaluations = append(evaluations, evaluate(points[i], mean, stddev, threshold))
+		}
+		return evaluations, nil
+	}
+}
+
+// meanAndStddev computes the mean of the points and their population standard deviation,
+// summing the squared deviations from the mean.
+func meanAndStddev(points []panel.Point) (float64, float64) {
+	sum := 0.0
+	for _, p := range points {
+		sum += p.Value
+	}
+	mean := sum / float64(len(points))
+	squares := 0.0
+	for _, p := range points {
+		squares += (p.Value - mean) * (p.Value - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(points)))
+}
+
+// EWMA compares every point with the exponentially weighted moving average and standard
+// deviation of the points before it. A larger alpha follows the series more closely.
+func EWMA(alpha float64) Detector {
+	return func(points []panel.Point, threshold float64) ([]Evaluation, error) {
+		if !(alpha > 0 && alpha < 1) {
+			return nil, fmt.Errorf("alpha must be between 0 and 1")
+		}
+		// the average needs about 1/alpha points to settle; compared as floats, a tiny alpha
+		// overflows an int
+		if float64(len(points)) <= math.Ceil(1/alpha) {
+			return nil, fmt.Errorf("%d points are too few for alpha %g", len(points), alpha)
+		}
+		warmup := int(math.Ceil(1 / alpha))
+		evaluations := make([]Evaluation, 0, len(points)-warmup)
+		mean, variance := points[0].Value, 0.0
+		for i, p := range points[1:] {
+			if i+1 >= warmup {
+				evaluations = append(evaluations, evaluate(p, mean, math.Sqrt(variance), threshold))
+			}
+			diff := p.Value - mean
+			increment := alpha * diff
+			mean += increment
+			variance = (1 - alpha) * (variance + diff*increment)
+		}
+		return evaluations, nil
+	}
+}
+
+// Seasonal decomposes the series into a trend, the moving average over one season, and a
+// seasonal component, the median deviation from the trend at the same time of the other
+// seasons. What remains is compared with its median absolute deviation. The series has to
+// span two seasons.
+func Seasonal(season time.Duration) Detector {
+	return func(points []panel.Point, threshold float64) ([]Evaluation, error) {
+		if len(points) < 3 || points[len(points)-1].Time.Sub(points[0].Time) < 2*season {
+			return nil, fmt.Errorf("seasonality of %s needs at least two seasons of data", season)
+		}
+		step := medianStep(points)
+		if step <= 0 || season < 2*step {
+			return nil, fmt.Errorf("points are too far apart for a seasonality of %s", season)
+		}
+
+		trend := movingAverage(points, season)
+		byPhase := map[int64][]int{}
+		for i, p := range points {
+			phase := p.Time.UnixNano() % int64(season) / int64(step)
+			byPhase[phase] = append(byPhase[phase], i)
+		}
+		// a point is not part of its own seasonal component, or it would explain itself
+		expected := make([]float64, len(points))
+		others := make([]float64, 0, len(points))
+		for _, indexes := range byPhase {
+			for _, i := range indexes {
+				others = others[:0]
+				for _, j := range indexes {
+					if j != i {
+						others = append(others, points[j].Value-trend[j])
+					}
+				}
+				expected[i] = trend[i] + median(others)
+			}
+		}
+
+		residuals := make([]float64, len(points))
+		for i, p := range points {
+			residuals[i] = p.Value - expected[i]
+		}
+		center := median(residuals)
+		absolute := make([]float64, len(residuals))
+		for i, residual := range residuals {
+			absolute[i] = math.Abs(residual - center)
+		}
+		// the median absolute deviation of a normal distribution is 0.6745 standard deviations
+		stddev := 1.4826 * median(absolute)
+
+		evaluations := make([]Evaluation, len(points))
+		for i, p := range points {
+			evaluations[i] = evaluate(p, expected[i]+center, stddev, threshold)
+		}
+		return evaluations, nil
+	}
+}
+
+// Intervals joins the consecutive evaluations outside of their band.
+func Intervals(evaluations []Evaluation, threshold float64) []Interval {
+	intervals := []Interval{}
+	var current *Interval
+	for _, e := range evaluations {
+		if math.Abs(e.Score) < threshold {
+			current = nil
+			continue
+		}
+		if current == nil {
+			intervals = append(intervals, Interval{Start: e.Time})
+			current = &intervals[len(intervals)-1]
+		}
+		current.End = e.Time
+		current.Points = append(current.Points, e)
+		if math.Abs(e.Score) > math.Abs(current.Score) {
+			current.Score = e.Score
+		}
+	}
+	for i := range intervals {
+		intervals[i].Direction = "above"
+		if intervals[i].Score < 0 {
+			intervals[i].Direction = "below"
+		}
+	}
+	return intervals
+}
+
+func evaluate(p panel.Point, expected, stddev, threshold float64) Evaluation {
+	e := Evaluation{
+		Time:     p.Time,
+		Value:    p.Value,
+		Expected: expected,
+		Lower:    expected - threshold*stddev,
+		Upper:    expected + threshold*stddev,
+	}
+	diff := p.Value - expected
+	switch {
+	case stddev > 0:
+		e.Score = math.Max(math.Min(diff/stddev, maxScore), -maxScore)
+	case diff != 0:
+		e.Score = math.Copysign(maxScore, diff)
+	}
+	return e
+}
+
+// movingAverage averages the points within half a window on either side of every point.
+// Points less than half a window from either end take the nearest average over a whole window.
+func movingAverage(points []panel.Point, window time.Duration) []float64 {
+	averages := make([]float64, len(points))
+	from, to := 0, 0
+	sum := 0.0
+	first, last := -1, -1
+	for i, p := range points {
+		for to < len(points) && points[to].Time.Sub(p.Time) <= window/2 {
+			sum += points[to].Value
+			to++
+		}
+		for p.Time.Sub(points[from].Time) > window/2 {
+			sum -= points[from].Value
+			from++
+		}
+		averages[i] = sum / float64(to-from)
+		if p.Time.Sub(points[0].Time) >= window/2 && points[len(points)-1].Time.Sub(p.Time) >= window/2 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first >= 0 {
+		for i := 0; i < first; i++ {
+			averages[i] = averages[first]
+		}
+		for i := last + 1; i < len(points); i++ {
+			averages[i] = averages[last]
+		}
+	}
+	return averages
+}
+
+// medianStep is the usual time between two points.
+func medianStep(points []panel.Point) time.Duration {
+	steps := make([]float64, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		steps = append(steps, float64(points[i].Time.Sub(points[i-1].Time)))
+	}
+	return time.Duration(median(steps))
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,63 @@
+package anomaly
+
+import (
+	"awsx-api/panel"
+	"math"
+	"testing"
+	"time"
+)
+
+// storageSeries is a FreeStorageSpace like series: about 150 GB with a few KB of noise.
+func storageSeries(n int) []panel.Point {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]panel.Point, n)
+	for i := range points {
+		noise := 4096 * math.Sin(float64(i)*1.7) * math.Cos(float64(i)*0.3)
+		points[i] = panel.Point{Time: start.Add(time.Duration(i) * 5 * time.Minute), Value: 1.5e11 + noise}
+	}
+	return points
+}
+
+func TestZScoreLargeValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		spikeAt   int
+		spike     float64
+		anomalies int
+	}{
+		{"low noise is not anomalous", -1, 0, 0},
+		{"a drop of 1 MB is", 1500, -1 << 20, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := storageSeries(2000)
+			if tt.spikeAt >= 0 {
+				points[tt.spikeAt].Value += tt.spike
+			}
+			evaluations, err := ZScore(12)(points, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range evaluations {
+				if e.Upper <= e.Lower {
+					t.Fatalf("point at %s has an empty band around %g", e.Time, e.Expected)
+				}
+			}
+			intervals := Intervals(evaluations, 3)
+			if len(intervals) != tt.anomalies {
+				t.Fatalf("got %d anomalies, want %d: %+v", len(intervals), tt.anomalies, intervals)
+			}
+			if tt.anomalies > 0 && intervals[0].Start != points[tt.spikeAt].Time {
+				t.Errorf("anomaly starts at %s, want %s", intervals[0].Start, points[tt.spikeAt].Time)
+			}
+		})
+	}
+}
+
+func TestMeanAndStddev(t *testing.T) {
+	points := []panel.Point{{Value: 1e12 + 2}, {Value: 1e12 + 4}, {Value: 1e12 + 4}, {Value: 1e12 + 4}, {Value: 1e12 + 5}, {Value: 1e12 + 5}, {Value: 1e12 + 7}, {Value: 1e12 + 9}}
+	mean, stddev := meanAndStddev(points)
+	if mean != 1e12+5 || stddev != 2 {
+		t.Errorf("got mean %f and stddev %f, want 1e12+5 and 2", mean, stddev)
+	}
+}
@@ -0,0 +1,186 @@
+package anomaly
+
+import (
+	"awsx-api/handlers"
+	"awsx-api/log"
+	"awsx-api/panel"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultThreshold = 3.0
+	defaultWindow    = 12
+	defaultAlpha     = 0.3
+)
+
+// seasons are the seasonalities of the seasonal detector, with the lookback that gives it
+// enough seasons when the request has no time range. With four seasons the same time of the
+// three other seasons tells what to expect, even when one of them was anomalous itself.
+var seasons = map[string]struct {
+	season   time.Duration
+	lookback string
+}{
+	"daily":  {24 * time.Hour, "now-4d"},
+	"weekly": {7 * 24 * time.Hour, "now-4w"},
+}
+
+// ownParams are the parameters of the anomalies endpoint, all others are passed to the panel.
+var ownParams = map[string]bool{
+	"elementType": true, "query": true, "startTime": true, "endTime": true, "range": true, "tz": true,
+	"method": true, "threshold": true, "window": true, "alpha": true, "seasonality": true, "includeBands": true,
+}
+
+// Result lists the anomalies of every series of a panel.
+type Result struct {
+	ElementType string          `json:"elementType"`
+	Query       string          `json:"query"`
+	Method      string          `json:"method"`
+	Threshold   float64         `json:"threshold"`
+	TimeRange   panel.TimeRange `json:"timeRange"`
+	Series      []SeriesResult  `json:"series"`
+}
+
+// SeriesResult holds the anomalous intervals of a series. Bands has the evaluation of every
+// point with includeBands=true. Error tells why a series was not evaluated, e.g. because it
+// has too few points.
+type SeriesResult struct {
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Unit      string            `json:"unit,omitempty"`
+	Evaluated int               `json:"evaluated"`
+	Anomalies []Interval        `json:"anomalies"`
+	Bands     []Evaluation      `json:"bands,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// GetAnomalies runs a panel and looks for anomalies in its series with one of the detectors:
+// method=zscore (rolling z-score over window points), ewma (bands around an exponentially
+// weighted moving average with alpha) or seasonal (decomposition with daily or weekly
+// seasonality). A point is anomalous when it is threshold standard deviations from what was
+// expected of it.
+func GetAnomalies(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	definition, ok := handlers.Panels().Lookup(query.Get("elementType"), query.Get("query"))
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("unknown panel [%s] of elementType [%s]", query.Get("query"), query.Get("elementType")))
+		return
+	}
+	method := query.Get("method")
+	if method == "" {
+		method = "zscore"
+	}
+	detector, lookback, err := detectorOf(method, query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	threshold := defaultThreshold
+	if value := query.Get("threshold"); value != "" {
+		if threshold, err = strconv.ParseFloat(value, 64); err != nil || !finite(threshold) || threshold <= 0 {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid threshold [%s]", value))
+			return
+		}
+	}
+
+	requested := panel.RequestedRange{
+		StartTime: query.Get("startTime"),
+		EndTime:   query.Get("endTime"),
+		Range:     query.Get("range"),
+		TimeZone:  query.Get("tz"),
+	}
+	if requested.StartTime == "" && requested.Range == "" {
+		requested.StartTime = lookback
+	}
+	timeRange, err := panel.ResolveTimeRange(requested, time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := url.Values{}
+	for k, v := range query {
+		if !ownParams[k] {
+			params[k] = v
+		}
+	}
+	payload, err := panel.Run(r, definition, params, timeRange.From, timeRange.To)
+	if err != nil {
+		log.FromContext(r.Context()).Errorf("Panel [%s] for anomaly detection failed: %v", definition.Query, err)
+		respondWithError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	result := Result{
+		ElementType: definition.ElementType,
+		Query:       definition.Query,
+		Method:      method,
+		Threshold:   threshold,
+		TimeRange:   timeRange,
+		Series:      []SeriesResult{},
+	}
+	for _, s := range panel.Normalize(payload, timeRange.To) {
+		seriesResult := SeriesResult{Name: s.Name, Labels: s.Labels, Unit: s.Unit, Anomalies: []Interval{}}
+		evaluations, err := detector(s.Points, threshold)
+		if err != nil {
+			seriesResult.Error = err.Error()
+		} else {
+			seriesResult.Evaluated = len(evaluations)
+			seriesResult.Anomalies = Intervals(evaluations, threshold)
+			if query.Get("includeBands") == "true" {
+				seriesResult.Bands = evaluations
+			}
+		}
+		result.Series = append(result.Series, seriesResult)
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, result)
+}
+
+// detectorOf builds the detector of a method from its parameters and returns the lookback it
+// needs when the request has no time range.
+func detectorOf(method string, query url.Values) (Detector, string, error) {
+	switch method {
+	case "zscore":
+		window := defaultWindow
+		if value := query.Get("window"); value != "" {
+			var err error
+			if window, err = strconv.Atoi(value); err != nil || window < 2 {
+				return nil, "", fmt.Errorf("invalid window [%s], expected a number of points of at least 2", value)
+			}
+		}
+		return ZScore(window), "now-24h", nil
+	case "ewma":
+		alpha := defaultAlpha
+		if value := query.Get("alpha"); value != "" {
+			var err error
+			if alpha, err = strconv.ParseFloat(value, 64); err != nil || !finite(alpha) || alpha <= 0 || alpha >= 1 {
+				return nil, "", fmt.Errorf("invalid alpha [%s], expected a number between 0 and 1", value)
+			}
+		}
+		return EWMA(alpha), "now-24h", nil
+	case "seasonal":
+		seasonality := query.Get("seasonality")
+		if seasonality == "" {
+			seasonality = "daily"
+		}
+		s, ok := seasons[seasonality]
+		if !ok {
+			return nil, "", fmt.Errorf("invalid seasonality [%s], expected daily or weekly", seasonality)
+		}
+		return Seasonal(s.season), s.lookback, nil
+	}
+	return nil, "", fmt.Errorf("invalid method [%s], expected zscore, ewma or seasonal", method)
+}
+
+// finite rejects NaN and the infinities, which pass every comparison with a bound.
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	handlers.RespondWithJSON(w, code, map[string]string{"error": message})
+}
@@ -0,0 +1,59 @@
+package appstate
+
+import (
+	"awsx-api/log"
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ShutdownHook releases or flushes a resource while the server shuts down. It should give up
+// when ctx is done.
+type ShutdownHook func(ctx context.Context) error
+
+type namedShutdownHook struct {
+	name string
+	hook ShutdownHook
+}
+
+var (
+	draining atomic.Bool
+
+	shutdownHooks     []namedShutdownHook
+	shutdownHooksLock sync.Mutex
+)
+
+// SetDraining marks the server as shutting down. Readiness reports failure from then on so
+// that Kubernetes stops routing new requests to this pod.
+func SetDraining(value bool) {
+	draining.Store(value)
+}
+
+// IsDraining reports whether the server is shutting down.
+func IsDraining() bool {
+	return draining.Load()
+}
+
+// OnShutdown registers a hook that runs once the http server has stopped serving requests.
+// Hooks run in registration order.
+func OnShutdown(name string, hook ShutdownHook) {
+	shutdownHooksLock.Lock()
+	defer shutdownHooksLock.Unlock()
+	shutdownHooks = append(shutdownHooks, namedShutdownHook{name: name, hook: hook})
+}
+
+// RunShutdownHooks runs every registered hook. A failing hook is logged and does not stop
+// the remaining ones.
+func RunShutdownHooks(ctx context.Context) {
+	shutdownHooksLock.Lock()
+	hooks := make([]namedShutdownHook, len(shutdownHooks))
+	copy(hooks, shutdownHooks)
+	shutdownHooksLock.Unlock()
+
+	for _, h := range hooks {
+		log.Debugf("Running shutdown hook [%s]", h.name)
+		if err := h.hook(ctx); err != nil {
+			log.Errorf("Shutdown hook [%s] failed: %v", h.name, err)
+		}
+	}
+}
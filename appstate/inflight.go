@@ -0,0 +1,49 @@
+package appstate
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// InFlightRequest is a request that is being served.
+type InFlightRequest struct {
+	RequestId   string    `json:"requestId"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	ElementType string    `json:"elementType,omitempty"`
+	Query       string    `json:"query,omitempty"`
+	RemoteAddr  string    `json:"remoteAddr,omitempty"`
+	StartedAt   time.Time `json:"startedAt"`
+}
+
+var (
+	inFlight     = map[*InFlightRequest]struct{}{}
+	inFlightLock sync.Mutex
+)
+
+// TrackRequest records request as in flight; the returned function must be called once it has
+// been served.
+func TrackRequest(request InFlightRequest) func() {
+	entry := &request
+	inFlightLock.Lock()
+	inFlight[entry] = struct{}{}
+	inFlightLock.Unlock()
+	return func() {
+		inFlightLock.Lock()
+		delete(inFlight, entry)
+		inFlightLock.Unlock()
+	}
+}
+
+// InFlightRequests returns the requests being served, oldest first.
+func InFlightRequests() []InFlightRequest {
+	inFlightLock.Lock()
+	defer inFlightLock.Unlock()
+	requests := make([]InFlightRequest, 0, len(inFlight))
+	for entry := range inFlight {
+		requests = append(requests, *entry)
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].StartedAt.Before(requests[j].StartedAt) })
+	return requests
+}
@@ -1,89 +1,353 @@
 package cache
 
 import (
+	"awsx-api/internalmetrics"
 	"awsx-api/log"
+	"awsx-api/observability"
+	"context"
 	"fmt"
-	"github.com/Appkube-awsx/awsx-common/authenticate"
+	awsauth "github.com/Appkube-awsx/awsx-common/authenticate"
 	"github.com/Appkube-awsx/awsx-common/awsclient"
 	"github.com/Appkube-awsx/awsx-common/cmdb"
 	"github.com/Appkube-awsx/awsx-common/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"go.opentelemetry.io/otel/attribute"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 var (
 	credentialCache sync.Map
 	awsClientCache  sync.Map
 	cacheLock       sync.RWMutex
+
+	clientDecorators     []ClientDecorator
+	clientDecoratorsLock sync.RWMutex
 )
 
-func GetLandingZone(commandParam model.CommandParam) (*model.Landingzone, error) {
-	log.Infof("getting cloud-element data to do aws connection caching. cloudElementId: " + commandParam.CloudElementId)
+// ClientDecorator is applied to every aws service client created by NewAwsClient. It is the
+// place to attach aws-sdk-go request handlers, e.g. for tracking or instrumentation.
+type ClientDecorator func(auth model.Auth, clientType string, c *client.Client)
+
+// RegisterClientDecorator adds a decorator for all aws clients created from now on.
+func RegisterClientDecorator(decorator ClientDecorator) {
+	clientDecoratorsLock.Lock()
+	defer clientDecoratorsLock.Unlock()
+	clientDecorators = append(clientDecorators, decorator)
+}
+
+// NewAwsClient creates an aws service client like awsclient.GetClient and applies the
+// registered client decorators to it.
+func NewAwsClient(auth model.Auth, clientType string) interface{} {
+	awsClient := awsclient.GetClient(auth, clientType)
+	sdkClient := sdkClientOf(awsClient)
+	if sdkClient == nil {
+		return awsClient
+	}
+	clientDecoratorsLock.RLock()
+	defer clientDecoratorsLock.RUnlock()
+	for _, decorate := range clientDecorators {
+		decorate(auth, clientType, sdkClient)
+	}
+	return awsClient
+}
+
+// sdkClientOf returns the *client.Client embedded in every aws-sdk-go service client.
+func sdkClientOf(awsClient interface{}) *client.Client {
+	v := reflect.ValueOf(awsClient)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	field := v.Elem().FieldByName("Client")
+	if !field.IsValid() || !field.CanInterface() {
+		return nil
+	}
+	sdkClient, _ := field.Interface().(*client.Client)
+	return sdkClient
+}
+
+func GetLandingZone(ctx context.Context, commandParam model.CommandParam) (*model.Landingzone, error) {
+	log.FromContext(ctx).Infof("getting cloud-element data to do aws connection caching. cloudElementId: " + commandParam.CloudElementId)
+	_, span := observability.StartSpan(ctx, "cmdb.GetCloudElement", attribute.String("awsx.elementId", commandParam.CloudElementId))
+	start := time.Now()
 	cloudElementResp, err := cmdb.GetCloudElement(commandParam)
+	internalmetrics.ObserveCmdbRequest("cloud-element", err, time.Since(start))
+	observability.EndSpan(span, err)
 	if err != nil {
-		return nil, fmt.Errorf("cmdb api failed to get cloud-element response in local caching", err)
+		return nil, fmt.Errorf("cmdb api failed to get cloud-element response in local caching: %v", err)
 	}
-	log.Infof("getting landing-zone data to do aws connection caching. landingZoneId: " + strconv.FormatInt(cloudElementResp.LandingzoneId, 10))
-	landingZoneResp, err := cmdb.GetLandingZone(commandParam, int(cloudElementResp.LandingzoneId))
+	return getLandingZoneById(ctx, commandParam, cloudElementResp.LandingzoneId)
+}
+
+func getLandingZoneById(ctx context.Context, commandParam model.CommandParam, landingZoneId int64) (*model.Landingzone, error) {
+	log.FromContext(ctx).Infof("getting landing-zone data to do aws connection caching. landingZoneId: " + strconv.FormatInt(landingZoneId, 10))
+	_, span := observability.StartSpan(ctx, "cmdb.GetLandingZone", attribute.Int64("awsx.landingZoneId", landingZoneId))
+	start := time.Now()
+	landingZoneResp, err := cmdb.GetLandingZone(commandParam, int(landingZoneId))
+	internalmetrics.ObserveCmdbRequest("landing-zone", err, time.Since(start))
+	observability.EndSpan(span, err)
 	if err != nil {
-		return nil, fmt.Errorf("cmdb api failed to get landing-zone response in local caching", err)
+		return nil, fmt.Errorf("cmdb api failed to get landing-zone response in local caching: %v", err)
 	}
+	log.SetLandingZone(ctx, strconv.FormatInt(landingZoneId, 10))
 	return landingZoneResp, nil
 }
 
-func GetAwsCredsAndClient(commandParam model.CommandParam, clientType string) (*model.Auth, interface{}, error) {
-	landingZoneResp, err := GetLandingZone(commandParam)
+// GetAwsCredsAndClient returns the credentials and the aws client of the landing zone the
+// cloud element belongs to, from the cache when possible. The returned client is bound to ctx,
+// see WithContext.
+func GetAwsCredsAndClient(ctx context.Context, commandParam model.CommandParam, clientType string) (*model.Auth, interface{}, error) {
+	landingZoneResp, err := GetLandingZone(ctx, commandParam)
 	if err != nil {
 		return nil, nil, err
 	}
+	return credsAndClient(ctx, landingZoneResp.RoleArn, commandParam, clientType)
+}
 
+// GetLandingZoneCredsAndClient is GetAwsCredsAndClient for a landing zone given by its id,
+// for the apis that work on a whole account, such as Cost Explorer.
+func GetLandingZoneCredsAndClient(ctx context.Context, landingZoneId string, clientType string) (*model.Auth, interface{}, error) {
+	id, err := strconv.ParseInt(landingZoneId, 10, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid landingZoneId [%s]", landingZoneId)
+	}
+	commandParam := model.CommandParam{LandingZoneId: landingZoneId}
+	landingZoneResp, err := getLandingZoneById(ctx, commandParam, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return credsAndClient(ctx, landingZoneResp.RoleArn, commandParam, clientType)
+}
+
+// credsAndClient returns the cached credentials and client of the role of a landing zone,
+// authenticating and creating them on a miss.
+func credsAndClient(ctx context.Context, roleArn string, commandParam model.CommandParam, clientType string) (*model.Auth, interface{}, error) {
 	var awsCredsAuth *model.Auth
 	var awsClient interface{}
+	var err error
 
 	cacheLock.Lock()
-	if credAuth, ok := credentialCache.Load(landingZoneResp.RoleArn); ok {
-		log.Infof("client credentials found in cache")
+	if credAuth, ok := credentialCache.Load(roleArn); ok {
+		log.FromContext(ctx).Infof("client credentials found in cache")
+		internalmetrics.CacheHit("credential")
 		awsCredsAuth = credAuth.(*model.Auth)
 	} else {
-		log.Infof("storing new aws credential reference in cache")
-		_, awsCredsAuth, err = authenticate.DoAuthenticate(commandParam)
+		log.FromContext(ctx).Infof("storing new aws credential reference in cache")
+		internalmetrics.CacheMiss("credential")
+		awsCredsAuth, err = authenticate(ctx, commandParam)
 		if err != nil {
 			cacheLock.Unlock()
 			return nil, nil, err
 		}
-		credentialCache.Store(landingZoneResp.RoleArn, awsCredsAuth)
+		credentialCache.Store(roleArn, awsCredsAuth)
 	}
 
-	if awsClientAuth, ok := awsClientCache.Load(landingZoneResp.RoleArn + "$$" + clientType); ok {
-		log.Infof("aws client found in cache")
+	if awsClientAuth, ok := awsClientCache.Load(roleArn + "$$" + clientType); ok {
+		log.FromContext(ctx).Infof("aws client found in cache")
+		internalmetrics.CacheHit("client")
 		awsClient = awsClientAuth
 	} else {
-		log.Infof("storing new client connection reference in cache")
-		awsClient = awsclient.GetClient(*awsCredsAuth, clientType)
-		awsClientCache.Store(landingZoneResp.RoleArn+"$$"+clientType, awsClient)
+		log.FromContext(ctx).Infof("storing new client connection reference in cache")
+		internalmetrics.CacheMiss("client")
+		awsClient = newAwsClientWithSpan(ctx, *awsCredsAuth, clientType)
+		awsClientCache.Store(roleArn+"$$"+clientType, awsClient)
 	}
 	cacheLock.Unlock()
 
-	return awsCredsAuth, awsClient, nil
+	return awsCredsAuth, WithContext(ctx, awsClient), nil
 }
 
-func SetAwsCredsAndClientInCache(commandParam model.CommandParam, clientType string) (*model.Auth, interface{}, error) {
-	log.Infof("storing aws credentials and client of a landing-zone in cache")
+func SetAwsCredsAndClientInCache(ctx context.Context, commandParam model.CommandParam, clientType string) (*model.Auth, interface{}, error) {
+	log.FromContext(ctx).Infof("storing aws credentials and client of a landing-zone in cache")
 	cacheLock.Lock()
-	landingZoneResp, err := GetLandingZone(commandParam)
+	landingZoneResp, err := GetLandingZone(ctx, commandParam)
 	if err != nil {
 		cacheLock.Unlock()
 		return nil, nil, err
 	}
-	_, awsCredsAuth, err := authenticate.DoAuthenticate(commandParam)
+	awsCredsAuth, err := authenticate(ctx, commandParam)
 	if err != nil {
 		cacheLock.Unlock()
 		return nil, nil, err
 	}
 	credentialCache.Store(landingZoneResp.RoleArn, awsCredsAuth)
 
-	awsClient := awsclient.GetClient(*awsCredsAuth, clientType)
+	awsClient := newAwsClientWithSpan(ctx, *awsCredsAuth, clientType)
 	awsClientCache.Store(landingZoneResp.RoleArn+"$$"+clientType, awsClient)
 	cacheLock.Unlock()
-	return awsCredsAuth, awsClient, nil
+	return awsCredsAuth, WithContext(ctx, awsClient), nil
+}
+
+// authenticate resolves the aws credentials of the landing zone (vault or global secrets).
+func authenticate(ctx context.Context, commandParam model.CommandParam) (*model.Auth, error) {
+	_, span := observability.StartSpan(ctx, "credentials.Authenticate", attribute.String("awsx.elementId", commandParam.CloudElementId))
+	_, awsCredsAuth, err := awsauth.DoAuthenticate(commandParam)
+	observability.EndSpan(span, err)
+	return awsCredsAuth, err
+}
+
+// newAwsClientWithSpan creates a client, which assumes the cross account role of the landing zone.
+func newAwsClientWithSpan(ctx context.Context, auth model.Auth, clientType string) interface{} {
+	_, span := observability.StartSpan(ctx, "credentials.AssumeRole", attribute.String("awsx.clientType", clientType))
+	defer span.End()
+	return NewAwsClient(auth, clientType)
+}
+
+// WithContext returns a shallow copy of awsClient whose calls run with ctx unless the caller
+// passes a context itself. The library panel functions call the aws apis without a context,
+// this links those calls to the inbound request for tracing and cancellation.
+func WithContext(ctx context.Context, awsClient interface{}) interface{} {
+	sdkClient := sdkClientOf(awsClient)
+	if ctx == nil || sdkClient == nil {
+		return awsClient
+	}
+	clientCopy := *sdkClient
+	clientCopy.Handlers = sdkClient.Handlers.Copy()
+	clientCopy.Handlers.Validate.PushFrontNamed(request.NamedHandler{
+		Name: "awsx-api.RequestContext",
+		Fn: func(r *request.Request) {
+			if r.Context() == aws.BackgroundContext() {
+				r.SetContext(ctx)
+			}
+		},
+	})
+
+	v := reflect.ValueOf(awsClient).Elem()
+	serviceCopy := reflect.New(v.Type())
+	serviceCopy.Elem().Set(v)
+	serviceCopy.Elem().FieldByName("Client").Set(reflect.ValueOf(&clientCopy))
+	return serviceCopy.Interface()
+}
+
+const (
+	CredentialCache = "credential"
+	ClientCache     = "client"
+	ResponseCache   = "response"
+)
+
+// Entry describes a cached credential, aws client or response. Credentials themselves are
+// never exposed.
+type Entry struct {
+	Cache         string `json:"cache"`
+	Key           string `json:"key"`
+	ClientType    string `json:"clientType,omitempty"`
+	LandingZoneId string `json:"landingZoneId,omitempty"`
+	Region        string `json:"region,omitempty"`
+}
+
+// Sizes returns the number of entries of the shared and panel caches, by cache name.
+func Sizes() map[string]int {
+	sizes := map[string]int{
+		CredentialCache: countEntries(&credentialCache),
+		ClientCache:     countEntries(&awsClientCache),
+	}
+	for _, name := range []string{CredentialCache, ClientCache} {
+		for _, local := range localsOf(name) {
+			sizes[name] += countEntries(&local.entries)
+		}
+	}
+	sizes[ResponseCache] = 0
+	for _, responses := range allResponseCaches() {
+		sizes[ResponseCache] += countEntries(&responses.entries)
+	}
+	return sizes
+}
+
+func countEntries(m *sync.Map) int {
+	n := 0
+	m.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Entries lists the entries of the shared and panel caches, sorted by cache and key.
+func Entries() []Entry {
+	entries := []Entry{}
+	credentialCache.Range(func(key, value interface{}) bool {
+		entry := Entry{Cache: CredentialCache, Key: key.(string)}
+		if auth, ok := value.(*model.Auth); ok {
+			entry.LandingZoneId = auth.LandingZoneId
+			entry.Region = auth.Region
+		}
+		entries = append(entries, entry)
+		return true
+	})
+	awsClientCache.Range(func(key, _ interface{}) bool {
+		roleArn, clientType, _ := strings.Cut(key.(string), "$$")
+		entries = append(entries, Entry{Cache: ClientCache, Key: key.(string), ClientType: clientType, LandingZoneId: landingZoneOf(roleArn)})
+		return true
+	})
+	entries = append(entries, localEntries()...)
+	for _, responses := range allResponseCaches() {
+		responses.entries.Range(func(key, _ interface{}) bool {
+			entries = append(entries, Entry{Cache: ResponseCache, Key: key.(string)})
+			return true
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Cache != entries[j].Cache {
+			return entries[i].Cache < entries[j].Cache
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+func landingZoneOf(roleArn string) string {
+	if value, ok := credentialCache.Load(roleArn); ok {
+		if auth, ok := value.(*model.Auth); ok {
+			return auth.LandingZoneId
+		}
+	}
+	return ""
+}
+
+// Evict removes the entry with key from the named cache and the panel caches of its kind, or
+// all their entries when key is empty, and returns the number of removed entries. Evicting a
+// credential also evicts the clients created with it, so the next request assumes the role
+// again.
+func Evict(cacheName, key string) (int, error) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	switch cacheName {
+	case CredentialCache:
+		removed := evictMatching(&credentialCache, func(k string) bool { return key == "" || k == key })
+		evictMatching(&awsClientCache, func(k string) bool { return key == "" || strings.HasPrefix(k, key+"$$") })
+		return removed + evictLocals(CredentialCache, key), nil
+	case ClientCache:
+		removed := evictMatching(&awsClientCache, func(k string) bool { return key == "" || k == key })
+		return removed + evictLocals(ClientCache, key), nil
+	case ResponseCache:
+		removed := 0
+		for _, responses := range allResponseCaches() {
+			removed += evictMatching(&responses.entries, func(k string) bool { return key == "" || k == key })
+		}
+		return removed, nil
+	default:
+		return 0, fmt.Errorf("unknown cache [%s], use %s, %s or %s", cacheName, CredentialCache, ClientCache, ResponseCache)
+	}
+}
+
+func evictMatching(m *sync.Map, match func(key string) bool) int {
+	return evictMatchingEntries(m, func(key string, _ interface{}) bool { return match(key) })
+}
+
+func evictMatchingEntries(m *sync.Map, match func(key string, value interface{}) bool) int {
+	removed := 0
+	m.Range(func(k, v interface{}) bool {
+		if match(k.(string), v) {
+			m.Delete(k)
+			removed++
+		}
+		return true
+	})
+	return removed
 }
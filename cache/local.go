@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"awsx-api/internalmetrics"
+	"sync"
+
+	"github.com/Appkube-awsx/awsx-common/model"
+)
+
+// Local is a credential or client cache of a single panel handler. The panel handlers keep
+// their own caches, keyed by cloud element id (credentials) or cross account role arn
+// (clients), next to the shared caches of GetAwsCredsAndClient. Lookups are counted in the
+// cache metrics and Evict reaches them too.
+type Local struct {
+	name    string
+	entries sync.Map
+}
+
+var (
+	locals     []*Local
+	localsLock sync.Mutex
+)
+
+// NewLocal creates and registers a panel cache of the kind name, CredentialCache or ClientCache.
+func NewLocal(name string) *Local {
+	local := &Local{name: name}
+	localsLock.Lock()
+	locals = append(locals, local)
+	localsLock.Unlock()
+	return local
+}
+
+// Load returns the cached value of key and counts the lookup as a hit or a miss.
+func (local *Local) Load(key interface{}) (interface{}, bool) {
+	value, ok := local.entries.Load(key)
+	if ok {
+		internalmetrics.CacheHit(local.name)
+	} else {
+		internalmetrics.CacheMiss(local.name)
+	}
+	return value, ok
+}
+
+// Store caches value under key.
+func (local *Local) Store(key, value interface{}) {
+	local.entries.Store(key, value)
+}
+
+// localsOf returns the registered panel caches of the kind name.
+func localsOf(name string) []*Local {
+	localsLock.Lock()
+	defer localsLock.Unlock()
+	var matching []*Local
+	for _, local := range locals {
+		if local.name == name {
+			matching = append(matching, local)
+		}
+	}
+	return matching
+}
+
+// evictLocals removes the entries of the panel caches of the kind name whose key is key, all
+// of them when key is empty. Credentials also match by the role arn they were assumed with,
+// the key of the shared credential cache, and take the clients of that role arn with them.
+func evictLocals(name, key string) int {
+	removed := 0
+	for _, local := range localsOf(name) {
+		removed += evictMatchingEntries(&local.entries, func(k string, value interface{}) bool {
+			if key == "" || k == key {
+				return true
+			}
+			auth, ok := value.(*model.Auth)
+			return ok && auth.CrossAccountRoleArn == key
+		})
+	}
+	if name == CredentialCache {
+		evictLocals(ClientCache, key)
+	}
+	return removed
+}
+
+// localEntries lists the entries of the panel caches.
+func localEntries() []Entry {
+	entries := []Entry{}
+	localsLock.Lock()
+	defer localsLock.Unlock()
+	for _, local := range locals {
+		name := local.name
+		local.entries.Range(func(key, value interface{}) bool {
+			entry := Entry{Cache: name, Key: key.(string)}
+			if auth, ok := value.(*model.Auth); ok {
+				entry.LandingZoneId = auth.LandingZoneId
+				entry.Region = auth.Region
+			}
+			entries = append(entries, entry)
+			return true
+		})
+	}
+	return entries
+}
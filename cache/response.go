@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"awsx-api/internalmetrics"
+	"sync"
+	"time"
+)
+
+// Responses keeps the responses of an api for a short time, keyed by what the response
+// depends on. Lookups are counted as the response cache in the cache metrics and Evict
+// reaches it.
+type Responses struct {
+	ttl     time.Duration
+	entries sync.Map
+}
+
+type cachedResponse struct {
+	value   interface{}
+	expires time.Time
+}
+
+var (
+	responseCaches     []*Responses
+	responseCachesLock sync.Mutex
+)
+
+// NewResponses creates and registers a response cache whose entries expire after ttl.
+func NewResponses(ttl time.Duration) *Responses {
+	responses := &Responses{ttl: ttl}
+	responseCachesLock.Lock()
+	responseCaches = append(responseCaches, responses)
+	responseCachesLock.Unlock()
+	return responses
+}
+
+// Get returns the response cached under key unless it expired.
+func (responses *Responses) Get(key string) (interface{}, bool) {
+	if value, ok := responses.entries.Load(key); ok {
+		cached := value.(cachedResponse)
+		if time.Now().Before(cached.expires) {
+			internalmetrics.CacheHit(ResponseCache)
+			return cached.value, true
+		}
+		responses.entries.Delete(key)
+	}
+	internalmetrics.CacheMiss(ResponseCache)
+	return nil, false
+}
+
+// Set caches value under key for the ttl of the cache, dropping the entries that expired.
+func (responses *Responses) Set(key string, value interface{}) {
+	now := time.Now()
+	responses.entries.Range(func(k, v interface{}) bool {
+		if !now.Before(v.(cachedResponse).expires) {
+			responses.entries.Delete(k)
+		}
+		return true
+	})
+	responses.entries.Store(key, cachedResponse{value: value, expires: now.Add(responses.ttl)})
+}
+
+func allResponseCaches() []*Responses {
+	responseCachesLock.Lock()
+	defer responseCachesLock.Unlock()
+	return append([]*Responses(nil), responseCaches...)
+}
@@ -15,20 +15,145 @@ var rwMutex sync.RWMutex
 
 var awsClientCache = make(map[string]*model.Auth)
 
+// CORS configuration. Origins may contain a wildcard subdomain such as https://*.example.com.
+// Credentials need explicit origins, they are never combined with allowing all origins.
+type CORS struct {
+	AllowCredentials bool     `yaml:"allow_credentials,omitempty"`
+	AllowedHeaders   []string `yaml:"allowed_headers,omitempty"`
+	AllowedMethods   []string `yaml:"allowed_methods,omitempty"`
+	AllowedOrigins   []string `yaml:"allowed_origins,omitempty"`
+	ExposedHeaders   []string `yaml:"exposed_headers,omitempty"`
+	MaxAge           int      `yaml:"max_age,omitempty"` // Seconds a preflight response may be cached by the browser
+}
+
+// Admin configuration of the management listener. It is bound separately from the API and only
+// started when a token is set; the AWSX_API_ADMIN_TOKEN environment variable overrides Token.
+type Admin struct {
+	Address string `yaml:"address,omitempty"`
+	Enabled bool   `yaml:"enabled,omitempty"`
+	Port    int    `yaml:"port,omitempty"`
+	Token   string `yaml:"token,omitempty"`
+}
+
+// Alerting configuration of the rule engine. The rules are read from the YAML files matching
+// rule_files when the server starts.
+type Alerting struct {
+	Enabled            bool          `yaml:"enabled,omitempty"`
+	EvaluationInterval int           `yaml:"evaluation_interval,omitempty"` // Seconds between two evaluations of the rules
+	FlapThreshold      int           `yaml:"flap_threshold,omitempty"`      // Times an alert may start firing within flap_window before it counts as flapping
+	FlapWindow         int           `yaml:"flap_window,omitempty"`         // Seconds
+	ResolvedRetention  int           `yaml:"resolved_retention,omitempty"`  // Seconds a resolved alert is still listed
+	RuleFiles          []string      `yaml:"rule_files,omitempty"`          // Glob patterns, e.g. /etc/awsx-api/rules/*.yaml
+	Notifications      Notifications `yaml:"notifications,omitempty"`
+}
+
+// Notifications configuration of where firing and resolved alerts are sent. Alerts with the
+// same group_by labels are sent together.
+type Notifications struct {
+	GroupBy        []string   `yaml:"group_by,omitempty"`
+	GroupWait      int        `yaml:"group_wait,omitempty"`      // Seconds to wait for more alerts of a new group before its first notification
+	GroupInterval  int        `yaml:"group_interval,omitempty"`  // Seconds between two notifications about changes of a group
+	RepeatInterval int        `yaml:"repeat_interval,omitempty"` // Seconds before a notification of unchanged firing alerts is sent again
+	Retries        int        `yaml:"retries,omitempty"`         // Attempts after a failed delivery, with doubling backoff
+	RetryBackoff   int        `yaml:"retry_backoff,omitempty"`   // Seconds before the first retry
+	Receivers      []Receiver `yaml:"receivers,omitempty"`
+}
+
+// Receiver is a destination of notifications: webhook, slack, teams, email or alertmanager.
+type Receiver struct {
+	Name       string   `yaml:"name,omitempty"`
+	Type       string   `yaml:"type,omitempty"`
+	URL        string   `yaml:"url,omitempty"`        // Webhook, Slack or Teams incoming webhook url, or the Alertmanager base url
+	Secret     string   `yaml:"secret,omitempty"`     // Webhook: signs the body with HMAC-SHA256
+	Severities []string `yaml:"severities,omitempty"` // Only alerts of these severities. Empty for all
+	SMTP       SMTP     `yaml:"smtp,omitempty"`
+	To         []string `yaml:"to,omitempty"`    // Email recipients
+	Title      string   `yaml:"title,omitempty"` // Go template of the title or subject
+	Body       string   `yaml:"body,omitempty"`  // Go template of the message
+}
+
+// SMTP server of an email receiver.
+type SMTP struct {
+	Host     string `yaml:"host,omitempty"`
+	Port     int    `yaml:"port,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	From     string `yaml:"from,omitempty"`
+}
+
+// Compression configuration for response bodies. Only applies when gzip_enabled is true.
+type Compression struct {
+	BrotliEnabled bool `yaml:"brotli_enabled,omitempty"` // Offer br to clients that accept it, in preference to gzip
+	Level         int  `yaml:"level,omitempty"`          // Encoder level. 0 uses the encoder default
+	MinSize       int  `yaml:"min_size,omitempty"`       // Responses smaller than this many bytes are sent uncompressed
+}
+
+// Health configuration of the dependency checks behind readiness and the health report.
+type Health struct {
+	CheckInterval int `yaml:"check_interval,omitempty"` // Seconds between two checks of CMDB and vault
+	CheckTimeout  int `yaml:"check_timeout,omitempty"`  // Seconds a single check may take
+}
+
+// TLS configuration for the API listener. Certificates come from Identity and are reloaded
+// from disk when the files change.
+type TLS struct {
+	CipherSuites   []string `yaml:"cipher_suites,omitempty"`   // IANA names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Not configurable for TLS 1.3
+	ClientAuth     string   `yaml:"client_auth,omitempty"`     // none, request or require. Defaults to require when client_ca_file is set
+	ClientCAFile   string   `yaml:"client_ca_file,omitempty"`  // CA bundle used to verify client certificates (mTLS)
+	MinVersion     string   `yaml:"min_version,omitempty"`     // 1.2 or 1.3
+	ReloadInterval int      `yaml:"reload_interval,omitempty"` // Seconds between checks of the certificate files for changes
+}
+
+// Tracing configuration for OpenTelemetry
+type Tracing struct {
+	CollectorURL string  `yaml:"collector_url,omitempty"` // OTLP/HTTP traces endpoint, e.g. http://otel-collector:4318/v1/traces
+	Enabled      bool    `yaml:"enabled,omitempty"`
+	Exporter     string  `yaml:"exporter,omitempty"`      // otlp (http)
+	SamplingRate float64 `yaml:"sampling_rate,omitempty"` // Ratio of new traces that are sampled, 0..1. Incoming sampled traces are always kept
+}
+
+// Observability configuration
+type Observability struct {
+	Tracing Tracing `yaml:"tracing,omitempty"`
+}
+
 // Server configuration
 type Server struct {
-	Address                    string `yaml:"address,omitempty"`
-	AuditLog                   bool   `yaml:"audit_log,omitempty"` // When true, allows additional audit logging on Write operations
-	CORSAllowAll               bool   `yaml:"cors_allow_all,omitempty"`
-	GzipEnabled                bool   `yaml:"gzip_enabled,omitempty"`
-	Port                       int    `yaml:"port,omitempty"`
-	StaticContentRootDirectory string `yaml:"static_content_root_directory,omitempty"`
-	WebFQDN                    string `yaml:"web_fqdn,omitempty"`
-	WebPort                    string `yaml:"web_port,omitempty"`
-	WebRoot                    string `yaml:"web_root,omitempty"`
-	WebHistoryMode             string `yaml:"web_history_mode,omitempty"`
-	WebSchema                  string `yaml:"web_schema,omitempty"`
-	WhiteListUrls              string `yaml:"white_list_urls,omitempty"`
+	Address                    string        `yaml:"address,omitempty"`
+	Admin                      Admin         `yaml:"admin,omitempty"`
+	Alerting                   Alerting      `yaml:"alerting,omitempty"`
+	AuditLog                   bool          `yaml:"audit_log,omitempty"` // When true, allows additional audit logging on Write operations
+	Compression                Compression   `yaml:"compression,omitempty"`
+	CORS                       CORS          `yaml:"cors,omitempty"`
+	CORSAllowAll               bool          `yaml:"cors_allow_all,omitempty"`
+	GzipEnabled                bool          `yaml:"gzip_enabled,omitempty"`
+	Health                     Health        `yaml:"health,omitempty"`
+	Observability              Observability `yaml:"observability,omitempty"`
+	Port                       int           `yaml:"port,omitempty"`
+	ShutdownDrainDelay         int           `yaml:"shutdown_drain_delay,omitempty"`  // Seconds to keep serving after readiness turned failing, so load balancers can deregister the pod
+	ShutdownGracePeriod        int           `yaml:"shutdown_grace_period,omitempty"` // Seconds in-flight requests get to finish before connections are closed
+	ShutdownTimeout            int           `yaml:"shutdown_timeout,omitempty"`      // Seconds the whole shutdown may take, below the terminationGracePeriodSeconds of the pod
+	SLA                        SLA           `yaml:"sla,omitempty"`
+	StaticContentRootDirectory string        `yaml:"static_content_root_directory,omitempty"`
+	TLS                        TLS           `yaml:"tls,omitempty"`
+	WebFQDN                    string        `yaml:"web_fqdn,omitempty"`
+	WebPort                    string        `yaml:"web_port,omitempty"`
+	WebRoot                    string        `yaml:"web_root,omitempty"`
+	WebHistoryMode             string        `yaml:"web_history_mode,omitempty"`
+	WebSchema                  string        `yaml:"web_schema,omitempty"`
+	WhiteListUrls              string        `yaml:"white_list_urls,omitempty"` // Comma separated list of allowed origins, merged into cors.allowed_origins
+}
+
+// SLA configuration of the service level objectives of /awsx-api/getSlaDetails, read from the
+// YAML files matching slo_files when the server starts.
+type SLA struct {
+	SLOFiles []string `yaml:"slo_files,omitempty"` // Glob patterns, e.g. /etc/awsx-api/slos/*.yaml
+}
+
+// Identity is the certificate and private key the server presents when serving https
+type Identity struct {
+	CertFile       string `yaml:"cert_file,omitempty"`
+	PrivateKeyFile string `yaml:"private_key_file,omitempty"`
 }
 
 // Vault configuration
@@ -42,6 +167,7 @@ type CloudElement struct {
 }
 
 type Config struct {
+	Identity     Identity     `yaml:",omitempty"`
 	Server       Server       `yaml:",omitempty"`
 	Vault        Vault        `yaml:",omitempty"`
 	CloudElement CloudElement `yaml:",omitempty"`
@@ -74,15 +200,64 @@ func Unmarshal(yamlString string) (conf *Config, err error) {
 func NewConfig() (c *Config) {
 	c = &Config{
 		Server: Server{
-			AuditLog:                   true,
-			GzipEnabled:                true,
+			Admin: Admin{
+				Address: "localhost",
+				Enabled: false,
+				Port:    7001,
+			},
+			Alerting: Alerting{
+				EvaluationInterval: 60,
+				FlapThreshold:      4,
+				FlapWindow:         3600,
+				ResolvedRetention:  900,
+				Notifications: Notifications{
+					GroupBy:        []string{"alertname"},
+					GroupWait:      30,
+					GroupInterval:  300,
+					RepeatInterval: 14400,
+					Retries:        3,
+					RetryBackoff:   1,
+				},
+			},
+			AuditLog: true,
+			Compression: Compression{
+				MinSize: 1024,
+			},
+			CORS: CORS{
+				AllowCredentials: false,
+				AllowedHeaders:   []string{"Origin", "X-Requested-With", "Content-Type", "Accept", "Authorization", "X-Request-ID"},
+				AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+				ExposedHeaders:   []string{"X-Request-ID"},
+				MaxAge:           600,
+			},
+			GzipEnabled: true,
+			Health: Health{
+				CheckInterval: 15,
+				CheckTimeout:  3,
+			},
+			Observability: Observability{
+				Tracing: Tracing{
+					CollectorURL: "http://localhost:4318/v1/traces",
+					Enabled:      false,
+					Exporter:     "otlp",
+					SamplingRate: 1,
+				},
+			},
 			Port:                       7000,
+			ShutdownDrainDelay:         5,
+			ShutdownGracePeriod:        15,
+			ShutdownTimeout:            28,
 			StaticContentRootDirectory: "/opt/awsx-api/console",
-			WebFQDN:                    "",
-			WebRoot:                    "/",
-			WebHistoryMode:             "browser",
-			WebSchema:                  "",
+			TLS: TLS{
+				MinVersion:     "1.2",
+				ReloadInterval: 30,
+			},
+			WebFQDN:        "",
+			WebRoot:        "/",
+			WebHistoryMode: "browser",
+			WebSchema:      "",
 		},
+		Identity:     Identity{},
 		Vault:        Vault{},
 		CloudElement: CloudElement{},
 	}
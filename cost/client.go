@@ -0,0 +1,133 @@
+package cost
+
+import (
+	"awsx-api/cache"
+	"awsx-api/handlers/getLandingZoneDetails"
+	"awsx-api/panel"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Appkube-awsx/awsx-common/awsclient"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+)
+
+// Client is the part of the Cost Explorer api the cost details use, which
+// *costexplorer.CostExplorer implements.
+type Client interface {
+	GetCostAndUsageWithContext(ctx aws.Context, input *costexplorer.GetCostAndUsageInput, opts ...request.Option) (*costexplorer.GetCostAndUsageOutput, error)
+	GetCostAndUsageWithResourcesWithContext(ctx aws.Context, input *costexplorer.GetCostAndUsageWithResourcesInput, opts ...request.Option) (*costexplorer.GetCostAndUsageWithResourcesOutput, error)
+}
+
+// ClientFunc returns the Cost Explorer client of a landing zone.
+type ClientFunc func(ctx context.Context, landingZoneId string) (Client, error)
+
+// InventoryFunc returns the resources of a landing zone that costs are matched to.
+type InventoryFunc func(r *http.Request, landingZoneId string) ([]Resource, error)
+
+// Resource is a resource of the landing zone inventory.
+type Resource struct {
+	ElementType string
+	Id          string
+	Name        string
+}
+
+// inventoryQueries are the getLandingZoneDetails queries of the inventory, by element type.
+var inventoryQueries = map[string]string{
+	"EC2":        "getEc2List",
+	"RDS":        "getRdsList",
+	"LAMBDA":     "getLambdaList",
+	"EKS":        "getEksList",
+	"ECS":        "getEcsList",
+	"APIGATEWAY": "getApiGwList",
+	"DYNAMODB":   "getDynamoDbList",
+	"LB":         "getLbList",
+	"S3":         "getS3List",
+	"KINESIS":    "getKinesisList",
+	"CDN":        "getCdnList",
+}
+
+var (
+	clientOf    ClientFunc    = cachedClient
+	inventoryOf InventoryFunc = landingZoneInventory
+	sourcesLock sync.RWMutex
+)
+
+// SetClientFunc replaces how the Cost Explorer clients are made, e.g. with clients of a local
+// Cost Explorer stand-in.
+func SetClientFunc(f ClientFunc) {
+	sourcesLock.Lock()
+	defer sourcesLock.Unlock()
+	clientOf = f
+}
+
+// SetInventoryFunc replaces how the inventory of a landing zone is read.
+func SetInventoryFunc(f InventoryFunc) {
+	sourcesLock.Lock()
+	defer sourcesLock.Unlock()
+	inventoryOf = f
+}
+
+func sources() (ClientFunc, InventoryFunc) {
+	sourcesLock.RLock()
+	defer sourcesLock.RUnlock()
+	return clientOf, inventoryOf
+}
+
+// cachedClient is the Cost Explorer client of the landing zone from the credential and client
+// cache. Cost Explorer has a single endpoint in us-east-1, whatever the region of the zone.
+func cachedClient(ctx context.Context, landingZoneId string) (Client, error) {
+	_, client, err := cache.GetLandingZoneCredsAndClient(ctx, landingZoneId, awsclient.COST_EXPLORER)
+	if err != nil {
+		return nil, err
+	}
+	costExplorer, ok := client.(*costexplorer.CostExplorer)
+	if !ok {
+		return nil, fmt.Errorf("no cost explorer client for landing zone [%s]", landingZoneId)
+	}
+	return costExplorer, nil
+}
+
+// landingZoneInventory lists the resources of the landing zone with the getLandingZoneDetails
+// queries. A query that fails leaves its element type out.
+func landingZoneInventory(r *http.Request, landingZoneId string) ([]Resource, error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var resources []Resource
+	var errs []string
+	for elementType, query := range inventoryQueries {
+		wg.Add(1)
+		go func(elementType, query string) {
+			defer wg.Done()
+			params := url.Values{"query": {query}, "landingZoneId": {landingZoneId}}
+			rec := panel.Record(getLandingZoneDetails.ExecuteLandingzoneQueries, panel.NewRequest(r, params))
+			var found []Resource
+			var err error
+			if !rec.Succeeded() {
+				err = fmt.Errorf("%s: %s", query, strings.TrimSpace(string(rec.Body())))
+			} else if inventory, decodeErr := rec.Decode(); decodeErr != nil {
+				err = fmt.Errorf("%s: %v", query, decodeErr)
+			} else {
+				for _, resource := range getLandingZoneDetails.Resources(inventory) {
+					found = append(found, Resource{ElementType: elementType, Id: resource.Id, Name: resource.Name})
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			resources = append(resources, found...)
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+		}(elementType, query)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return resources, fmt.Errorf("inventory incomplete: %s", strings.Join(errs, "; "))
+	}
+	return resources, nil
+}
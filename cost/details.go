@@ -0,0 +1,513 @@
+package cost
+
+import (
+	"awsx-api/panel"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+)
+
+const (
+	GranularityDaily   = "daily"
+	GranularityMonthly = "monthly"
+
+	GroupByService = "service"
+	GroupByTag     = "tag"
+	GroupByElement = "element"
+
+	dateLayout = "2006-01-02"
+	// resourceDays is how far back Cost Explorer has costs by resource.
+	resourceDays = 14
+)
+
+// metrics are the cost metrics of Cost Explorer.
+var metrics = []string{"AmortizedCost", "BlendedCost", "NetAmortizedCost", "NetUnblendedCost", "UnblendedCost"}
+
+// defaultStarts are the startTime of each granularity when the request gives no time range.
+var defaultStarts = map[string]string{
+	GranularityDaily:   "now-30d/d",
+	GranularityMonthly: "now-6M/M",
+}
+
+// Query is what cost details to fetch. From and To are midnights UTC, the days Cost Explorer
+// accounts in; To is exclusive.
+type Query struct {
+	LandingZoneId string
+	From, To      time.Time
+	Granularity   string
+	Metric        string
+	GroupBy       map[string]bool
+	TagKey        string
+}
+
+// Details are the costs of a landing zone over a time range, and how they changed from the
+// previous period of the same length.
+type Details struct {
+	LandingZoneId     string          `json:"landingZoneId"`
+	TimeRange         panel.TimeRange `json:"timeRange"`
+	PreviousTimeRange panel.TimeRange `json:"previousTimeRange"`
+	Granularity       string          `json:"granularity"`
+	Metric            string          `json:"metric"`
+	Unit              string          `json:"unit"`
+	Total             Cost            `json:"total"`
+	ByService         []Cost          `json:"byService,omitempty"`
+	ByTag             []Cost          `json:"byTag,omitempty"`
+	ByElement         []ElementCost   `json:"byElement,omitempty"`
+	// Unmatched are the costs by resource of resources not in the inventory.
+	Unmatched []Cost   `json:"unmatched,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// Cost is the amount of a service, tag value or resource over the time range. Previous and
+// Change are left out when the previous period has no data, ChangePercent also when the
+// previous amount is 0.
+type Cost struct {
+	Key           string   `json:"key"`
+	Amount        float64  `json:"amount"`
+	Previous      *float64 `json:"previous,omitempty"`
+	Change        *float64 `json:"change,omitempty"`
+	ChangePercent *float64 `json:"changePercent,omitempty"`
+	Periods       []Period `json:"periods"`
+}
+
+// Period is the amount of a day or month. Estimated is set while Cost Explorer has not
+// finalized it.
+type Period struct {
+	Start     string  `json:"start"`
+	End       string  `json:"end"`
+	Amount    float64 `json:"amount"`
+	Estimated bool    `json:"estimated,omitempty"`
+}
+
+// ElementCost is the cost of a resource of the landing zone inventory.
+type ElementCost struct {
+	Cost
+	ElementType string `json:"elementType"`
+	Name        string `json:"name,omitempty"`
+}
+
+// QueryOf reads a query from the request parameters. Without a time range, daily costs cover
+// the last 30 days and monthly costs the last 6 months.
+func QueryOf(params url.Values, now time.Time) (Query, error) {
+	query := Query{
+		LandingZoneId: params.Get("landingZoneId"),
+		Granularity:   strings.ToLower(params.Get("granularity")),
+		Metric:        params.Get("metric"),
+		GroupBy:       map[string]bool{},
+		TagKey:        params.Get("tagKey"),
+	}
+	if query.LandingZoneId == "" {
+		return query, fmt.Errorf("landingZoneId is required")
+	}
+	if _, err := strconv.ParseInt(query.LandingZoneId, 10, 64); err != nil {
+		return query, fmt.Errorf("invalid landingZoneId [%s]", query.LandingZoneId)
+	}
+	if query.Granularity == "" {
+		query.Granularity = GranularityDaily
+	}
+	if _, ok := defaultStarts[query.Granularity]; !ok {
+		return query, fmt.Errorf("unknown granularity [%s], expected daily or monthly", query.Granularity)
+	}
+	if query.Metric == "" {
+		query.Metric = "UnblendedCost"
+	}
+	if !contains(metrics, query.Metric) {
+		return query, fmt.Errorf("unknown metric [%s], expected one of %s", query.Metric, strings.Join(metrics, ", "))
+	}
+	groupBy := params.Get("groupBy")
+	if groupBy == "" {
+		groupBy = GroupByService
+	}
+	for _, g := range strings.Split(groupBy, ",") {
+		g = strings.TrimSpace(g)
+		if g != GroupByService && g != GroupByTag && g != GroupByElement {
+			return query, fmt.Errorf("unknown groupBy [%s], expected service, tag or element", g)
+		}
+		query.GroupBy[g] = true
+	}
+	if query.GroupBy[GroupByTag] && query.TagKey == "" {
+		return query, fmt.Errorf("groupBy tag needs a tagKey")
+	}
+
+	requested := panel.RequestedRange{
+		StartTime: params.Get("startTime"),
+		EndTime:   params.Get("endTime"),
+		Range:     params.Get("range"),
+		TimeZone:  params.Get("tz"),
+	}
+	if requested.StartTime == "" && requested.EndTime == "" && requested.Range == "" {
+		requested.StartTime = defaultStarts[query.Granularity]
+	}
+	tr, err := panel.ResolveTimeRange(requested, now)
+	if err != nil {
+		return query, err
+	}
+	query.From, query.To = days(tr, query.Granularity, now)
+	if !query.From.Before(query.To) {
+		return query, fmt.Errorf("the time range from %s to %s has no costs yet", tr.From.Format(time.RFC3339), tr.To.Format(time.RFC3339))
+	}
+	return query, nil
+}
+
+// key identifies the costs of the query in the response cache.
+func (query Query) key() string {
+	groupBy := make([]string, 0, len(query.GroupBy))
+	for g := range query.GroupBy {
+		groupBy = append(groupBy, g)
+	}
+	sort.Strings(groupBy)
+	return strings.Join([]string{query.LandingZoneId, query.From.Format(dateLayout), query.To.Format(dateLayout),
+		query.Granularity, query.Metric, strings.Join(groupBy, ","), query.TagKey}, "|")
+}
+
+// days widens a time range to whole UTC days, or whole months for monthly costs, ending by
+// tomorrow at the latest.
+func days(tr panel.TimeRange, granularity string, now time.Time) (time.Time, time.Time) {
+	from := midnight(tr.From)
+	to := midnight(tr.To)
+	if to.Before(tr.To) {
+		to = to.AddDate(0, 0, 1)
+	}
+	if granularity == GranularityMonthly {
+		from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if to.Day() != 1 {
+			to = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+		}
+	}
+	if tomorrow := midnight(now).AddDate(0, 0, 1); to.After(tomorrow) {
+		to = tomorrow
+	}
+	return from, to
+}
+
+// previous is the start of the period before the query of as many days, or months.
+func (query Query) previous() time.Time {
+	if query.Granularity == GranularityMonthly {
+		months := 0
+		for t := query.From; t.Before(query.To); t = t.AddDate(0, 1, 0) {
+			months++
+		}
+		return query.From.AddDate(0, -months, 0)
+	}
+	return query.From.AddDate(0, 0, -int(query.To.Sub(query.From).Hours()/24))
+}
+
+// periods are the days or calendar months of a time range, the first and last cut off at its
+// start and end.
+func (query Query) periods(from time.Time) []Period {
+	var periods []Period
+	for start := from; start.Before(query.To); {
+		end := start.AddDate(0, 0, 1)
+		if query.Granularity == GranularityMonthly {
+			end = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+		}
+		if end.After(query.To) {
+			end = query.To
+		}
+		periods = append(periods, Period{Start: start.Format(dateLayout), End: end.Format(dateLayout)})
+		start = end
+	}
+	return periods
+}
+
+// Fetch fetches the costs of a query. The costs by service are always fetched, they make up
+// the total and select the services of the costs by resource. Failing to fetch the costs by
+// tag or by element, or the inventory, leaves them out and adds the error.
+func Fetch(r *http.Request, client Client, inventory InventoryFunc, query Query) (Details, error) {
+	previousFrom := query.previous()
+	details := Details{
+		LandingZoneId:     query.LandingZoneId,
+		TimeRange:         panel.TimeRange{From: query.From, To: query.To},
+		PreviousTimeRange: panel.TimeRange{From: previousFrom, To: query.From},
+		Granularity:       query.Granularity,
+		Metric:            query.Metric,
+	}
+	ctx := r.Context()
+
+	var byService, byTag *table
+	var serviceErr, tagErr, inventoryErr error
+	var resources []Resource
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		byService, serviceErr = fetchCosts(ctx, client, query, previousFrom, &costexplorer.GroupDefinition{Type: aws.String("DIMENSION"), Key: aws.String("SERVICE")})
+	}()
+	if query.GroupBy[GroupByTag] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			byTag, tagErr = fetchCosts(ctx, client, query, previousFrom, &costexplorer.GroupDefinition{Type: aws.String("TAG"), Key: aws.String(query.TagKey)})
+		}()
+	}
+	if query.GroupBy[GroupByElement] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resources, inventoryErr = inventory(r, query.LandingZoneId)
+		}()
+	}
+	wg.Wait()
+	if serviceErr != nil {
+		return details, serviceErr
+	}
+
+	details.Unit = byService.unit
+	details.Total = byService.total(query, previousFrom)
+	if query.GroupBy[GroupByService] {
+		details.ByService = byService.costs(query, previousFrom)
+	}
+	if tagErr != nil {
+		details.Errors = append(details.Errors, fmt.Sprintf("costs by tag: %v", tagErr))
+	} else if byTag != nil {
+		details.ByTag = byTag.costs(query, previousFrom)
+		prefix := query.TagKey + "$"
+		for i := range details.ByTag {
+			details.ByTag[i].Key = strings.TrimPrefix(details.ByTag[i].Key, prefix)
+		}
+	}
+	if query.GroupBy[GroupByElement] {
+		if inventoryErr != nil {
+			details.Errors = append(details.Errors, inventoryErr.Error())
+		}
+		details.ByElement, details.Unmatched = elementCosts(ctx, client, query, previousFrom, byService.keys(), resources, &details.Errors)
+	}
+	return details, nil
+}
+
+// elementCosts fetches the costs by resource of the services and matches the resources to the
+// inventory. Cost Explorer only has them for the last 14 days, the time range is cut to those
+// and the previous period is left out when it does not fit in them.
+func elementCosts(ctx aws.Context, client Client, query Query, previousFrom time.Time, services []string, resources []Resource, errs *[]string) ([]ElementCost, []Cost) {
+	if len(services) == 0 {
+		return nil, nil
+	}
+	limit := midnight(time.Now()).AddDate(0, 0, -resourceDays)
+	elementQuery := query
+	if elementQuery.From.Before(limit) {
+		elementQuery.From = limit
+		*errs = append(*errs, fmt.Sprintf("costs by element are only available from %s", limit.Format(dateLayout)))
+	}
+	if !elementQuery.From.Before(elementQuery.To) {
+		return nil, nil
+	}
+	comparable := !previousFrom.Before(limit)
+	from := elementQuery.From
+	if comparable {
+		from = previousFrom
+	}
+
+	byResource := newTable()
+	input := &costexplorer.GetCostAndUsageWithResourcesInput{
+		TimePeriod:  &costexplorer.DateInterval{Start: aws.String(from.Format(dateLayout)), End: aws.String(query.To.Format(dateLayout))},
+		Granularity: aws.String(strings.ToUpper(query.Granularity)),
+		Metrics:     []*string{aws.String(query.Metric)},
+		GroupBy:     []*costexplorer.GroupDefinition{{Type: aws.String("DIMENSION"), Key: aws.String("RESOURCE_ID")}},
+		Filter:      &costexplorer.Expression{Dimensions: &costexplorer.DimensionValues{Key: aws.String("SERVICE"), Values: aws.StringSlice(services)}},
+	}
+	for {
+		output, err := client.GetCostAndUsageWithResourcesWithContext(ctx, input)
+		if err != nil {
+			*errs = append(*errs, fmt.Sprintf("costs by element: %v", err))
+			return nil, nil
+		}
+		byResource.add(output.ResultsByTime, query.Metric)
+		if aws.StringValue(output.NextPageToken) == "" {
+			break
+		}
+		input.NextPageToken = output.NextPageToken
+	}
+
+	costs := byResource.costs(elementQuery, from)
+	if !comparable {
+		for i := range costs {
+			costs[i].Previous, costs[i].Change, costs[i].ChangePercent = nil, nil, nil
+		}
+	}
+	index := map[string]Resource{}
+	for _, resource := range resources {
+		index[resource.Id] = resource
+		if _, ok := index[suffix(resource.Id)]; !ok {
+			index[suffix(resource.Id)] = resource
+		}
+	}
+	elements := []ElementCost{}
+	var unmatched []Cost
+	for _, cost := range costs {
+		resource, ok := index[cost.Key]
+		if !ok {
+			resource, ok = index[suffix(cost.Key)]
+		}
+		if !ok {
+			unmatched = append(unmatched, cost)
+			continue
+		}
+		elements = append(elements, ElementCost{Cost: cost, ElementType: resource.ElementType, Name: resource.Name})
+	}
+	return elements, unmatched
+}
+
+// fetchCosts fetches the costs of the query and the previous period with one grouping, page
+// by page.
+func fetchCosts(ctx aws.Context, client Client, query Query, previousFrom time.Time, groupBy *costexplorer.GroupDefinition) (*table, error) {
+	t := newTable()
+	input := &costexplorer.GetCostAndUsageInput{
+		TimePeriod:  &costexplorer.DateInterval{Start: aws.String(previousFrom.Format(dateLayout)), End: aws.String(query.To.Format(dateLayout))},
+		Granularity: aws.String(strings.ToUpper(query.Granularity)),
+		Metrics:     []*string{aws.String(query.Metric)},
+		GroupBy:     []*costexplorer.GroupDefinition{groupBy},
+	}
+	for {
+		output, err := client.GetCostAndUsageWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		t.add(output.ResultsByTime, query.Metric)
+		if aws.StringValue(output.NextPageToken) == "" {
+			return t, nil
+		}
+		input.NextPageToken = output.NextPageToken
+	}
+}
+
+// table is the amounts of a grouping by key and period start. A grouping can span pages, so
+// the amounts of a period add up.
+type table struct {
+	unit      string
+	amounts   map[string]map[string]float64
+	estimated map[string]bool
+}
+
+func newTable() *table {
+	return &table{amounts: map[string]map[string]float64{}, estimated: map[string]bool{}}
+}
+
+func (t *table) add(results []*costexplorer.ResultByTime, metric string) {
+	for _, result := range results {
+		if result.TimePeriod == nil {
+			continue
+		}
+		start := aws.StringValue(result.TimePeriod.Start)
+		t.estimated[start] = t.estimated[start] || aws.BoolValue(result.Estimated)
+		for _, group := range result.Groups {
+			value, ok := group.Metrics[metric]
+			if !ok || len(group.Keys) == 0 {
+				continue
+			}
+			amount, err := strconv.ParseFloat(aws.StringValue(value.Amount), 64)
+			if err != nil {
+				continue
+			}
+			if t.unit == "" {
+				t.unit = aws.StringValue(value.Unit)
+			}
+			key := aws.StringValue(group.Keys[0])
+			if t.amounts[key] == nil {
+				t.amounts[key] = map[string]float64{}
+			}
+			t.amounts[key][start] += amount
+		}
+	}
+}
+
+// keys are the keys with an amount other than 0.
+func (t *table) keys() []string {
+	var keys []string
+	for key, amounts := range t.amounts {
+		for _, amount := range amounts {
+			if amount != 0 {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// costs are the costs of the keys with an amount in either period, the highest first.
+func (t *table) costs(query Query, previousFrom time.Time) []Cost {
+	costs := []Cost{}
+	for _, key := range t.keys() {
+		costs = append(costs, t.cost(key, t.amounts[key], query, previousFrom))
+	}
+	sort.SliceStable(costs, func(i, j int) bool { return costs[i].Amount > costs[j].Amount })
+	return costs
+}
+
+// total is the cost of all keys together.
+func (t *table) total(query Query, previousFrom time.Time) Cost {
+	sum := map[string]float64{}
+	for _, amounts := range t.amounts {
+		for start, amount := range amounts {
+			sum[start] += amount
+		}
+	}
+	return t.cost("total", sum, query, previousFrom)
+}
+
+// cost splits the amounts of a key into the periods of the query and the previous period.
+func (t *table) cost(key string, amounts map[string]float64, query Query, previousFrom time.Time) Cost {
+	cost := Cost{Key: key, Periods: query.periods(query.From)}
+	amount := 0.0
+	for i := range cost.Periods {
+		p := &cost.Periods[i]
+		p.Amount = round(amounts[p.Start], 4)
+		p.Estimated = t.estimated[p.Start]
+		amount += amounts[p.Start]
+	}
+	previous := 0.0
+	hasPrevious := false
+	for start, a := range amounts {
+		if start >= previousFrom.Format(dateLayout) && start < query.From.Format(dateLayout) {
+			previous += a
+			hasPrevious = true
+		}
+	}
+	cost.Amount = round(amount, 4)
+	if !hasPrevious {
+		return cost
+	}
+	change := round(amount-previous, 4)
+	previous = round(previous, 4)
+	cost.Previous, cost.Change = &previous, &change
+	if previous != 0 {
+		percent := round((amount-previous)/previous*100, 2)
+		cost.ChangePercent = &percent
+	}
+	return cost
+}
+
+// suffix is the last part of an ARN or path, e.g. the name of a Lambda function or the id of
+// an instance.
+func suffix(id string) string {
+	return id[strings.LastIndexAny(id, ":/")+1:]
+}
+
+func midnight(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func round(v float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,101 @@
+package cost
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+)
+
+// fakeCostExplorer answers GetCostAndUsage with the daily costs of two services: EC2 in the
+// previous and the current period, Lambda only in the current one.
+func fakeCostExplorer(t *testing.T, calls *int32) *httptest.Server {
+	daily := map[string]map[string]string{
+		"2024-03-02": {"Amazon Elastic Compute Cloud - Compute": "10"},
+		"2024-03-03": {"Amazon Elastic Compute Cloud - Compute": "10"},
+		"2024-03-04": {"Amazon Elastic Compute Cloud - Compute": "15", "AWS Lambda": "1"},
+		"2024-03-05": {"Amazon Elastic Compute Cloud - Compute": "15", "AWS Lambda": "1"},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "AWSInsightsIndexService.GetCostAndUsage" {
+			t.Errorf("unexpected call %s", target)
+			http.Error(w, "unexpected call", http.StatusBadRequest)
+			return
+		}
+		atomic.AddInt32(calls, 1)
+		output := costexplorer.GetCostAndUsageOutput{}
+		for _, start := range []string{"2024-03-02", "2024-03-03", "2024-03-04", "2024-03-05"} {
+			result := &costexplorer.ResultByTime{TimePeriod: &costexplorer.DateInterval{Start: aws.String(start)}}
+			for service, amount := range daily[start] {
+				result.Groups = append(result.Groups, &costexplorer.Group{
+					Keys:    []*string{aws.String(service)},
+					Metrics: map[string]*costexplorer.MetricValue{"UnblendedCost": {Amount: aws.String(amount), Unit: aws.String("USD")}},
+				})
+			}
+			output.ResultsByTime = append(output.ResultsByTime, result)
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		json.NewEncoder(w).Encode(output)
+	}))
+}
+
+func getCostDetails(t *testing.T, landingZoneId string) Details {
+	r := httptest.NewRequest(http.MethodGet, "/awsx-api/getCostDetails?landingZoneId="+landingZoneId+
+		"&startTime=2024-03-04T00:00:00Z&endTime=2024-03-06T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	GetCostDetails(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var details Details
+	if err := json.Unmarshal(w.Body.Bytes(), &details); err != nil {
+		t.Fatal(err)
+	}
+	return details
+}
+
+func TestGetCostDetails(t *testing.T) {
+	var calls int32
+	server := fakeCostExplorer(t, &calls)
+	defer server.Close()
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	SetClientFunc(func(ctx context.Context, landingZoneId string) (Client, error) {
+		return costexplorer.New(sess), nil
+	})
+	defer SetClientFunc(cachedClient)
+
+	details := getCostDetails(t, "1")
+	if details.Unit != "USD" || details.Total.Amount != 32 || details.Total.Previous == nil || *details.Total.Previous != 20 {
+		t.Errorf("total = %+v %s, want 32 USD after 20", details.Total, details.Unit)
+	}
+	if len(details.ByService) != 2 {
+		t.Fatalf("got %d services, want 2", len(details.ByService))
+	}
+	ec2, lambda := details.ByService[0], details.ByService[1]
+	if ec2.Amount != 30 || ec2.Change == nil || *ec2.Change != 10 || ec2.ChangePercent == nil || *ec2.ChangePercent != 50 {
+		t.Errorf("ec2 = %+v, want 30 changed by 10 (50%%)", ec2)
+	}
+	if lambda.Amount != 2 || lambda.Previous != nil || lambda.Change != nil || lambda.ChangePercent != nil {
+		t.Errorf("lambda = %+v, want 2 without previous and change", lambda)
+	}
+
+	getCostDetails(t, "1")
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("cost explorer was called %d times, want the second request from the cache", calls)
+	}
+	getCostDetails(t, "2")
+	if calls := atomic.LoadInt32(&calls); calls != 2 {
+		t.Errorf("cost explorer was called %d times, want another landing zone queried again", calls)
+	}
+}
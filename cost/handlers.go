@@ -0,0 +1,57 @@
+package cost
+
+import (
+	"awsx-api/cache"
+	"awsx-api/handlers"
+	"awsx-api/log"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// responseTTL is how long the costs of a query are reused. Cost Explorer updates them a few
+// times a day and charges every request.
+const responseTTL = 5 * time.Minute
+
+var responses = cache.NewResponses(responseTTL)
+
+// GetCostDetails reports the costs of a landing zone by service, tag value and element, and
+// their change from the previous period. Complete responses are cached by landing zone and
+// query for responseTTL.
+func GetCostDetails(w http.ResponseWriter, r *http.Request) {
+	query, err := QueryOf(r.URL.Query(), time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if details, ok := responses.Get(query.key()); ok {
+		handlers.RespondWithJSON(w, http.StatusOK, details)
+		return
+	}
+	clientOf, inventoryOf := sources()
+	client, err := clientOf(r.Context(), query.LandingZoneId)
+	if err != nil {
+		log.FromContext(r.Context()).Errorf("No cost explorer client for landing zone [%s]: %v", query.LandingZoneId, err)
+		respondWithError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	details, err := Fetch(r, client, inventoryOf, query)
+	if err != nil {
+		code := http.StatusBadGateway
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "ValidationException" {
+			code = http.StatusBadRequest
+		}
+		log.FromContext(r.Context()).Errorf("Failed to get the costs of landing zone [%s]: %v", query.LandingZoneId, err)
+		respondWithError(w, code, err.Error())
+		return
+	}
+	if len(details.Errors) == 0 {
+		responses.Set(query.key(), details)
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, details)
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	handlers.RespondWithJSON(w, code, map[string]string{"error": message})
+}
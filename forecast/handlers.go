@@ -0,0 +1,295 @@
+package forecast
+
+import (
+	"awsx-api/handlers"
+	"awsx-api/log"
+	"awsx-api/panel"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLookback   = "now-14d"
+	defaultHorizon    = 7 * 24 * time.Hour
+	maxHorizon        = 365 * 24 * time.Hour
+	defaultConfidence = 0.95
+
+	modelLinear      = "linear"
+	modelHoltWinters = "holtwinters"
+)
+
+var seasonalities = map[string]time.Duration{
+	"none":   0,
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// ownParams are the parameters of the forecast endpoint, all others are passed to the panel.
+var ownParams = map[string]bool{
+	"elementType": true, "query": true, "startTime": true, "endTime": true, "range": true, "tz": true,
+	"horizon": true, "model": true, "seasonality": true, "confidence": true, "threshold": true,
+}
+
+// Result holds the forecasts of every series of a panel.
+type Result struct {
+	ElementType string          `json:"elementType"`
+	Query       string          `json:"query"`
+	TimeRange   panel.TimeRange `json:"timeRange"`
+	Horizon     string          `json:"horizon"`
+	Confidence  float64         `json:"confidence"`
+	Threshold   *float64        `json:"threshold,omitempty"`
+	Series      []SeriesResult  `json:"series"`
+}
+
+// SeriesResult holds the forecast of a series by every model. Error tells why a series was
+// not forecast, e.g. because it has too few points.
+type SeriesResult struct {
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Unit      string            `json:"unit,omitempty"`
+	Forecasts []ModelForecast   `json:"forecasts"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// ModelForecast is the projection of a model. DaysUntilThreshold is when the forecast reaches
+// the threshold, EarliestDaysUntilThreshold when the confidence interval does; both are left
+// out when they do not within the horizon, except for the line, which is followed beyond it.
+type ModelForecast struct {
+	Model                      string      `json:"model"`
+	Fit                        interface{} `json:"fit,omitempty"`
+	Projection                 []Projected `json:"projection"`
+	DaysUntilThreshold         *float64    `json:"daysUntilThreshold,omitempty"`
+	EarliestDaysUntilThreshold *float64    `json:"earliestDaysUntilThreshold,omitempty"`
+	Error                      string      `json:"error,omitempty"`
+}
+
+// GetForecast runs a panel over a lookback, 14 days unless startTime or range says otherwise,
+// and projects its series over the horizon with a linear and a Holt-Winters model. With a
+// threshold it estimates when the series will reach it.
+func GetForecast(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	definition, ok := handlers.Panels().Lookup(query.Get("elementType"), query.Get("query"))
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("unknown panel [%s] of elementType [%s]", query.Get("query"), query.Get("elementType")))
+		return
+	}
+	settings, err := parseSettings(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	requested := panel.RequestedRange{
+		StartTime: query.Get("startTime"),
+		EndTime:   query.Get("endTime"),
+		Range:     query.Get("range"),
+		TimeZone:  query.Get("tz"),
+	}
+	if requested.StartTime == "" && requested.Range == "" {
+		requested.StartTime = defaultLookback
+	}
+	timeRange, err := panel.ResolveTimeRange(requested, time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := url.Values{}
+	for k, v := range query {
+		if !ownParams[k] {
+			params[k] = v
+		}
+	}
+	payload, err := panel.Run(r, definition, params, timeRange.From, timeRange.To)
+	if err != nil {
+		log.FromContext(r.Context()).Errorf("Panel [%s] for forecast failed: %v", definition.Query, err)
+		respondWithError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	result := Result{
+		ElementType: definition.ElementType,
+		Query:       definition.Query,
+		TimeRange:   timeRange,
+		Horizon:     settings.horizon.String(),
+		Confidence:  settings.confidence,
+		Threshold:   settings.threshold,
+		Series:      []SeriesResult{},
+	}
+	for _, s := range panel.Normalize(payload, timeRange.To) {
+		result.Series = append(result.Series, forecastSeries(s, settings, timeRange.To))
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, result)
+}
+
+type settings struct {
+	models      []string
+	horizon     time.Duration
+	seasonality time.Duration
+	confidence  float64
+	threshold   *float64
+}
+
+func parseSettings(query url.Values) (settings, error) {
+	s := settings{
+		models:      []string{modelLinear, modelHoltWinters},
+		horizon:     defaultHorizon,
+		seasonality: seasonalities["daily"],
+		confidence:  defaultConfidence,
+	}
+	if value := query.Get("model"); value != "" {
+		s.models = strings.Split(value, ",")
+		for _, model := range s.models {
+			if model != modelLinear && model != modelHoltWinters {
+				return s, fmt.Errorf("invalid model [%s], expected linear or holtwinters", model)
+			}
+		}
+	}
+	if value := query.Get("horizon"); value != "" {
+		var err error
+		if s.horizon, err = parseHorizon(value); err != nil {
+			return s, err
+		}
+	}
+	if value := query.Get("seasonality"); value != "" {
+		var ok bool
+		if s.seasonality, ok = seasonalities[value]; !ok {
+			return s, fmt.Errorf("invalid seasonality [%s], expected none, daily or weekly", value)
+		}
+	}
+	if value := query.Get("confidence"); value != "" {
+		var err error
+		if s.confidence, err = strconv.ParseFloat(value, 64); err != nil || !(s.confidence > 0 && s.confidence < 1) {
+			return s, fmt.Errorf("invalid confidence [%s], expected a number between 0 and 1, e.g. 0.95", value)
+		}
+	}
+	if value := query.Get("threshold"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(threshold) || math.IsInf(threshold, 0) {
+			return s, fmt.Errorf("invalid threshold [%s]", value)
+		}
+		s.threshold = &threshold
+	}
+	return s, nil
+}
+
+// parseHorizon reads a number of hours, days or weeks (48h, 21d, 3w) or a Go duration, of
+// at most maxHorizon.
+func parseHorizon(value string) (time.Duration, error) {
+	tooLong := fmt.Errorf("horizon [%s] is longer than the maximum of 365d", value)
+	units := map[byte]time.Duration{'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if unit, ok := units[value[len(value)-1]]; ok {
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil && n > 0 {
+			// compared before multiplying, a large n overflows the duration
+			if n > int(maxHorizon/unit) {
+				return 0, tooLong
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		if d > maxHorizon {
+			return 0, tooLong
+		}
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid horizon [%s], expected e.g. 48h, 21d or 3w", value)
+}
+
+func forecastSeries(s panel.Series, settings settings, now time.Time) SeriesResult {
+	result := SeriesResult{Name: s.Name, Labels: s.Labels, Unit: s.Unit, Forecasts: []ModelForecast{}}
+	values, step, err := Regular(s.Points)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if settings.horizon < step {
+		result.Error = fmt.Sprintf("horizon %s is shorter than the %s between points", settings.horizon, step)
+		return result
+	}
+	last := s.Points[0].Time.Add(time.Duration(len(values)-1) * step)
+	z := math.Sqrt2 * math.Erfinv(settings.confidence)
+
+	for _, name := range settings.models {
+		forecast := ModelForecast{Model: name, Projection: []Projected{}}
+		var model Model
+		switch name {
+		case modelLinear:
+			var linear *Linear
+			if linear, err = FitLinear(values); err == nil {
+				model, forecast.Fit = linear, linear
+			}
+		case modelHoltWinters:
+			season := int(settings.seasonality / step)
+			if season < 2 || len(values) < 2*season {
+				// not enough points for the season, or not enough seasons
+				season = 0
+			}
+			var holtWinters *HoltWinters
+			if holtWinters, err = FitHoltWinters(values, season); err == nil {
+				model, forecast.Fit = holtWinters, holtWinters
+			}
+		}
+		if err != nil {
+			forecast.Error = err.Error()
+			result.Forecasts = append(result.Forecasts, forecast)
+			continue
+		}
+		forecast.Projection = Projection(model, last, step, settings.horizon, z)
+		if settings.threshold != nil {
+			rising := *settings.threshold > values[len(values)-1]
+			forecast.DaysUntilThreshold = daysUntil(forecast.Projection, *settings.threshold, rising, now, func(p Projected) float64 { return p.Value })
+			forecast.EarliestDaysUntilThreshold = daysUntil(forecast.Projection, *settings.threshold, rising, now, func(p Projected) float64 {
+				if rising {
+					return p.Upper
+				}
+				return p.Lower
+			})
+			if linear, ok := model.(*Linear); ok && forecast.DaysUntilThreshold == nil {
+				forecast.DaysUntilThreshold = linear.daysUntil(*settings.threshold, rising, last, step, now)
+			}
+		}
+		result.Forecasts = append(result.Forecasts, forecast)
+	}
+	return result
+}
+
+// daysUntil finds the first projected point that reaches the threshold.
+func daysUntil(projection []Projected, threshold float64, rising bool, now time.Time, value func(Projected) float64) *float64 {
+	for _, p := range projection {
+		if (rising && value(p) >= threshold) || (!rising && value(p) <= threshold) {
+			return days(p.Time, now)
+		}
+	}
+	return nil
+}
+
+// daysUntil follows the line beyond the horizon to where it reaches the threshold.
+func (model *Linear) daysUntil(threshold float64, rising bool, last time.Time, step time.Duration, now time.Time) *float64 {
+	if (rising && model.Slope <= 0) || (!rising && model.Slope >= 0) {
+		return nil
+	}
+	h := (threshold - model.at(model.n-1)) / model.Slope
+	if h < 0 {
+		h = 0
+	}
+	// beyond 290 years a time.Duration overflows, and nobody plans that far
+	if h*step.Hours() > 100*365*24 {
+		return nil
+	}
+	return days(last.Add(time.Duration(h*float64(step))), now)
+}
+
+func days(t, now time.Time) *float64 {
+	d := math.Max(t.Sub(now).Hours()/24, 0)
+	d = math.Round(d*100) / 100
+	return &d
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	handlers.RespondWithJSON(w, code, map[string]string{"error": message})
+}
@@ -0,0 +1,253 @@
+package forecast
+
+import (
+	"awsx-api/panel"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// maxProjectedPoints limits the points of a projection, longer horizons are sampled coarser.
+const maxProjectedPoints = 1000
+
+// Projected is a forecast value with its confidence interval.
+type Projected struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+	Lower float64   `json:"lower"`
+	Upper float64   `json:"upper"`
+}
+
+// Model is a model fitted to a series that projects it into the future. Project returns the
+// forecast h steps after the last point and its standard error.
+type Model interface {
+	Project(h int) (value, stderr float64)
+}
+
+// Linear is a least squares line through the points, x in steps since the first point.
+type Linear struct {
+	Intercept float64 `json:"intercept"`
+	// Slope is the change per step.
+	Slope float64 `json:"slope"`
+	RMSE  float64 `json:"rmse"`
+
+	n, meanX, sxx float64
+}
+
+// FitLinear fits a line to evenly spaced values.
+func FitLinear(values []float64) (*Linear, error) {
+	n := float64(len(values))
+	if len(values) < 3 {
+		return nil, fmt.Errorf("%d points are too few for a linear fit", len(values))
+	}
+	var meanX, meanY float64
+	for i, v := range values {
+		meanX += float64(i)
+		meanY += v
+	}
+	meanX, meanY = meanX/n, meanY/n
+	var sxx, sxy float64
+	for i, v := range values {
+		dx := float64(i) - meanX
+		sxx += dx * dx
+		sxy += dx * (v - meanY)
+	}
+	model := &Linear{Slope: sxy / sxx, n: n, meanX: meanX, sxx: sxx}
+	model.Intercept = meanY - model.Slope*meanX
+	var sse float64
+	for i, v := range values {
+		residual := v - model.at(float64(i))
+		sse += residual * residual
+	}
+	model.RMSE = math.Sqrt(sse / (n - 2))
+	return model, nil
+}
+
+func (model *Linear) at(x float64) float64 {
+	return model.Intercept + model.Slope*x
+}
+
+// Project returns the line h steps after the last point, with the standard error of a
+// prediction there, which grows with the distance from the fitted points.
+func (model *Linear) Project(h int) (float64, float64) {
+	x := model.n - 1 + float64(h)
+	dx := x - model.meanX
+	return model.at(x), model.RMSE * math.Sqrt(1+1/model.n+dx*dx/model.sxx)
+}
+
+// HoltWinters is additive triple exponential smoothing: a level, a trend and, with a season
+// of more than one point, a seasonal component. The smoothing factors are the ones with the
+// least squared one-step error.
+type HoltWinters struct {
+	Alpha float64 `json:"alpha"`
+	Beta  float64 `json:"beta"`
+	Gamma float64 `json:"gamma,omitempty"`
+	// Season is the length of a season in points, 0 without seasonality.
+	Season int     `json:"season,omitempty"`
+	RMSE   float64 `json:"rmse"`
+
+	level, trend float64
+	seasonal     []float64
+	n            int
+	// variance is the variance factor of Project up to varianceSteps, projections ask for
+	// growing h and only add the steps after it
+	variance      float64
+	varianceSteps int
+}
+
+var (
+	alphas = []float64{0.1, 0.2, 0.4, 0.6, 0.8}
+	betas  = []float64{0.01, 0.05, 0.1, 0.3}
+	gammas = []float64{0.05, 0.1, 0.3}
+)
+
+// FitHoltWinters fits the smoothing factors to evenly spaced values. season is the number
+// of points of a season, 0 for a series without seasonality; it takes two seasons of values.
+func FitHoltWinters(values []float64, season int) (*HoltWinters, error) {
+	if season > 0 && len(values) < 2*season {
+		return nil, fmt.Errorf("%d points are too few for a season of %d points", len(values), season)
+	}
+	if len(values) < 3 {
+		return nil, fmt.Errorf("%d points are too few for Holt-Winters", len(values))
+	}
+	seasonGammas := gammas
+	if season == 0 {
+		seasonGammas = []float64{0}
+	}
+	var best *HoltWinters
+	for _, alpha := range alphas {
+		for _, beta := range betas {
+			for _, gamma := range seasonGammas {
+				model := &HoltWinters{Alpha: alpha, Beta: beta, Gamma: gamma, Season: season}
+				model.smooth(values)
+				if best == nil || model.RMSE < best.RMSE {
+					best = model
+				}
+			}
+		}
+	}
+	return best, nil
+}
+
+// smooth runs the model over the values, the first season, or the first point, sets the
+// initial state.
+func (model *HoltWinters) smooth(values []float64) {
+	model.n = len(values)
+	start := 1
+	model.level, model.trend = values[0], values[1]-values[0]
+	if m := model.Season; m > 0 {
+		// the first season without its trend is the seasonal component, its trend line ends
+		// in the level
+		first, second := mean(values[:m]), mean(values[m:2*m])
+		model.trend = (second - first) / float64(m)
+		middle := float64(m-1) / 2
+		model.level = first + middle*model.trend
+		model.seasonal = make([]float64, m)
+		for i := 0; i < m; i++ {
+			model.seasonal[i] = values[i] - (first + (float64(i)-middle)*model.trend)
+		}
+		start = m
+	}
+	var sse float64
+	for t := start; t < len(values); t++ {
+		season := model.seasonalAt(t)
+		residual := values[t] - (model.level + model.trend + season)
+		sse += residual * residual
+		level := model.Alpha*(values[t]-season) + (1-model.Alpha)*(model.level+model.trend)
+		model.trend = model.Beta*(level-model.level) + (1-model.Beta)*model.trend
+		if model.Season > 0 {
+			model.seasonal[t%model.Season] = model.Gamma*(values[t]-level) + (1-model.Gamma)*season
+		}
+		model.level = level
+	}
+	model.RMSE = math.Sqrt(sse / float64(len(values)-start))
+}
+
+func (model *HoltWinters) seasonalAt(t int) float64 {
+	if model.Season == 0 {
+		return 0
+	}
+	return model.seasonal[t%model.Season]
+}
+
+// Project returns the forecast h steps after the last point. The standard error is that of
+// additive exponential smoothing, every step adds the error the state picks up from it.
+func (model *HoltWinters) Project(h int) (float64, float64) {
+	value := model.level + float64(h)*model.trend + model.seasonalAt(model.n-1+h)
+	if model.varianceSteps == 0 || h < model.varianceSteps {
+		model.variance, model.varianceSteps = 1, 1
+	}
+	for j := model.varianceSteps; j < h; j++ {
+		c := model.Alpha * (1 + float64(j)*model.Beta)
+		if model.Season > 0 && j%model.Season == 0 {
+			c += model.Gamma
+		}
+		model.variance += c * c
+	}
+	if h > model.varianceSteps {
+		model.varianceSteps = h
+	}
+	return value, model.RMSE * math.Sqrt(model.variance)
+}
+
+// Projection samples a model from the step after the last point to the horizon. z is the
+// number of standard errors of the confidence interval.
+func Projection(model Model, last time.Time, step, horizon time.Duration, z float64) []Projected {
+	steps := int(horizon / step)
+	stride := 1
+	if steps > maxProjectedPoints {
+		stride = (steps + maxProjectedPoints - 1) / maxProjectedPoints
+	}
+	projection := make([]Projected, 0, steps/stride+1)
+	for h := stride; h <= steps; h += stride {
+		value, stderr := model.Project(h)
+		projection = append(projection, Projected{
+			Time:  last.Add(time.Duration(h) * step),
+			Value: value,
+			Lower: value - z*stderr,
+			Upper: value + z*stderr,
+		})
+	}
+	return projection
+}
+
+// Regular resamples points onto an even grid from the first to the last point, with the
+// usual time between two points as step. Missing points are interpolated.
+func Regular(points []panel.Point) ([]float64, time.Duration, error) {
+	if len(points) < 3 {
+		return nil, 0, fmt.Errorf("%d points are too few for a forecast", len(points))
+	}
+	steps := make([]float64, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		steps = append(steps, float64(points[i].Time.Sub(points[i-1].Time)))
+	}
+	sort.Float64s(steps)
+	step := time.Duration(steps[len(steps)/2])
+	if step <= 0 {
+		return nil, 0, fmt.Errorf("points have no distinct timestamps")
+	}
+	first, last := points[0].Time, points[len(points)-1].Time
+	values := make([]float64, 0, int(last.Sub(first)/step)+1)
+	j := 0
+	for t := first; !t.After(last); t = t.Add(step) {
+		for j < len(points)-2 && !points[j+1].Time.After(t) {
+			j++
+		}
+		a, b := points[j], points[j+1]
+		ratio := 0.0
+		if span := b.Time.Sub(a.Time); span > 0 {
+			ratio = math.Min(math.Max(float64(t.Sub(a.Time))/float64(span), 0), 1)
+		}
+		values = append(values, a.Value+ratio*(b.Value-a.Value))
+	}
+	return values, step, nil
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,221 @@
+package grafana
+
+import (
+	"awsx-api/handlers"
+	"awsx-api/handlers/getLandingZoneDetails"
+	"awsx-api/log"
+	"awsx-api/panel"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// TestConnection answers the "Save & test" of the datasource.
+func TestConnection(w http.ResponseWriter, r *http.Request) {
+	handlers.RespondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Search lists the registered panels as query targets, filtered by the search text.
+func Search(w http.ResponseWriter, r *http.Request) {
+	var request searchRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	filter := strings.ToLower(request.Target)
+	options := []option{}
+	for _, definition := range handlers.Panels().Definitions() {
+		target := Target(definition)
+		if filter == "" || strings.Contains(strings.ToLower(target), filter) {
+			options = append(options, option{Text: definition.ElementType + " " + definition.Query, Value: target})
+		}
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, options)
+}
+
+// Query runs the panel of every target over the time range of the dashboard. Metric panels
+// answer with time series, the others with a table. A target that names no panel, or whose
+// panel rejects its parameters, is a bad request; a panel that fails otherwise, e.g. because
+// CloudWatch did, is a bad gateway. The maxDataPoints of the dashboard is not
+// passed on to the panels: every target is queried as getQueryOutput queries it and its series
+// are downsampled here, so a target gives the same data whichever panel width asks for it.
+func Query(w http.ResponseWriter, r *http.Request) {
+	var request queryRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	results := []interface{}{}
+	for _, target := range request.Targets {
+		if target.Hide || target.Target == "" {
+			continue
+		}
+		payload, err := runTarget(r, target.Target, target.Payload, request.Range)
+		if err != nil {
+			respondWithError(w, statusOf(err), fmt.Sprintf("target [%s]: %v", target.Target, err))
+			return
+		}
+		series, _ := panel.DownsampleSeries(panel.Normalize(payload, request.Range.To), int(request.MaxDataPoints))
+		if len(series) == 0 || target.Type == "table" {
+			results = append(results, newTable(payload, series))
+			continue
+		}
+		for _, s := range series {
+			name := s.Name
+			if len(series) == 1 {
+				name = target.Target
+			}
+			datapoints := make([][2]float64, 0, len(s.Points))
+			for _, p := range s.Points {
+				datapoints = append(datapoints, [2]float64{p.Value, float64(p.Time.UnixMilli())})
+			}
+			results = append(results, timeSeries{Target: name, Datapoints: datapoints})
+		}
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, results)
+}
+
+// Annotations turns the records of a log panel into annotations. The annotation query is a
+// target with the panel parameters as query string, e.g.
+// RDS/recent_event_log_panel?elementId=900000&logGroupName=/aws/rds/instance/db/error
+func Annotations(w http.ResponseWriter, r *http.Request) {
+	var request annotationRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	target, params, err := parseAnnotationQuery(request.Annotation.Query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	payload, err := runTarget(r, target, params, request.Range)
+	if err != nil {
+		respondWithError(w, statusOf(err), fmt.Sprintf("annotation [%s]: %v", request.Annotation.Name, err))
+		return
+	}
+	annotations := []annotation{}
+	rows, _ := panel.Rows(payload)
+	lines, _ := panel.LogLines(rows)
+	for _, line := range lines {
+		if !request.Range.To.IsZero() && (line.Time.Before(request.Range.From) || line.Time.After(request.Range.To)) {
+			continue
+		}
+		tags := make([]string, 0, len(line.Labels)+1)
+		if line.Severity != "" {
+			tags = append(tags, line.Severity)
+		}
+		for k, v := range line.Labels {
+			tags = append(tags, k+"="+v)
+		}
+		sort.Strings(tags)
+		annotations = append(annotations, annotation{
+			Time:  line.Time.UnixMilli(),
+			Title: request.Annotation.Name,
+			Text:  line.Body,
+			Tags:  tags,
+		})
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, annotations)
+}
+
+// Variable feeds template variables. The target is a landing zone inventory query
+// (getEc2List, getRdsList, ...) with payload.landingZoneId, or elementTypes for the element
+// types of the registered panels.
+func Variable(w http.ResponseWriter, r *http.Request) {
+	var request variableRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	target := request.Payload.stringValue("target")
+	if target == "" {
+		target = request.Target
+	}
+	values := []variableValue{}
+	if target == "elementTypes" {
+		seen := map[string]bool{}
+		for _, definition := range handlers.Panels().Definitions() {
+			if !seen[definition.ElementType] {
+				seen[definition.ElementType] = true
+				values = append(values, variableValue{Text: definition.ElementType, Value: definition.ElementType})
+			}
+		}
+		handlers.RespondWithJSON(w, http.StatusOK, values)
+		return
+	}
+	if !strings.HasPrefix(target, "get") || !strings.HasSuffix(target, "List") {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("unknown variable target [%s], use elementTypes or a landing zone list query such as getEc2List", target))
+		return
+	}
+	params := map[string]interface{}{"query": target, "landingZoneId": request.Payload.stringValue("landingZoneId")}
+	rec := panel.Record(getLandingZoneDetails.ExecuteLandingzoneQueries, panel.NewRequest(r, toValues(params)))
+	if !rec.Succeeded() {
+		respondWithError(w, http.StatusBadGateway, strings.TrimSpace(string(rec.Body())))
+		return
+	}
+	inventory, err := rec.Decode()
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "landing zone inventory is not json: "+err.Error())
+		return
+	}
+	for _, resource := range getLandingZoneDetails.Resources(inventory) {
+		values = append(values, variableValue{Text: resource.Name, Value: resource.Id})
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, values)
+}
+
+// runTarget calls the panel of a target with the target parameters.
+func runTarget(r *http.Request, target string, params map[string]interface{}, timeRange dashboardRange) (interface{}, error) {
+	elementType, query, ok := SplitTarget(target)
+	if !ok {
+		return nil, invalidTarget("target must be <elementType>/<query>")
+	}
+	definition, ok := handlers.Panels().Lookup(elementType, query)
+	if !ok {
+		return nil, invalidTarget(fmt.Sprintf("no panel registered for elementType [%s] and query [%s]", elementType, query))
+	}
+	payload, err := panel.Run(r, definition, toValues(params), timeRange.From, timeRange.To)
+	if err != nil {
+		log.FromContext(r.Context()).Warningf("Panel %s failed: %v", target, err)
+	}
+	return payload, err
+}
+
+// invalidTarget is a target that names no registered panel.
+type invalidTarget string
+
+func (e invalidTarget) Error() string {
+	return string(e)
+}
+
+// statusOf is the status of a failed target: 400 when the target or its parameters are
+// wrong, 502 when the panel failed to get its data.
+func statusOf(err error) int {
+	var invalid invalidTarget
+	var panelErr *panel.Error
+	switch {
+	case errors.As(err, &invalid):
+		return http.StatusBadRequest
+	case errors.As(err, &panelErr) && panelErr.Status < http.StatusInternalServerError:
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// decodeRequest reads the JSON body of a request. An empty body leaves the request as it is.
+func decodeRequest(w http.ResponseWriter, r *http.Request, request interface{}) bool {
+	if r.Body == nil {
+		return true
+	}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	handlers.RespondWithJSON(w, code, map[string]string{"error": message})
+}
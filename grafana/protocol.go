@@ -0,0 +1,178 @@
+package grafana
+
+import (
+	"awsx-api/panel"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The request and response bodies of the Grafana JSON datasource protocol, see
+// https://github.com/simPod/GrafanaJsonDatasource.
+
+type dashboardRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// payload holds the free form parameters of a target, e.g. elementId or landingZoneId.
+type payload map[string]interface{}
+
+func (p payload) stringValue(key string) string {
+	if v, ok := p[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+type searchRequest struct {
+	Target string `json:"target"`
+}
+
+type option struct {
+	Text  string `json:"text"`
+	Value string `json:"value"`
+}
+
+type queryTarget struct {
+	Target  string  `json:"target"`
+	RefID   string  `json:"refId"`
+	Type    string  `json:"type"`
+	Hide    bool    `json:"hide"`
+	Payload payload `json:"payload"`
+}
+
+type queryRequest struct {
+	Range         dashboardRange `json:"range"`
+	IntervalMs    int64          `json:"intervalMs"`
+	MaxDataPoints int64          `json:"maxDataPoints"`
+	Targets       []queryTarget  `json:"targets"`
+}
+
+type timeSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+type tableColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+type table struct {
+	Type    string          `json:"type"`
+	Columns []tableColumn   `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+type annotationRequest struct {
+	Range      dashboardRange `json:"range"`
+	Annotation struct {
+		Name  string `json:"name"`
+		Query string `json:"query"`
+	} `json:"annotation"`
+}
+
+type annotation struct {
+	Time  int64    `json:"time"`
+	Title string   `json:"title"`
+	Text  string   `json:"text"`
+	Tags  []string `json:"tags"`
+}
+
+type variableRequest struct {
+	Target  string         `json:"target"`
+	Payload payload        `json:"payload"`
+	Range   dashboardRange `json:"range"`
+}
+
+type variableValue struct {
+	Text  string `json:"__text"`
+	Value string `json:"__value"`
+}
+
+// Target is the name of a panel in Grafana, <elementType>/<query>.
+func Target(definition panel.Definition) string {
+	return definition.ElementType + "/" + definition.Query
+}
+
+// SplitTarget splits a target into elementType and query. Element types may hold a slash
+// themselves (AWS/NetworkELB), queries never do.
+func SplitTarget(target string) (string, string, bool) {
+	i := strings.LastIndex(target, "/")
+	if i <= 0 || i == len(target)-1 {
+		return "", "", false
+	}
+	return target[:i], target[i+1:], true
+}
+
+// parseAnnotationQuery splits <elementType>/<query>?<panel parameters>.
+func parseAnnotationQuery(query string) (string, map[string]interface{}, error) {
+	target, rawParams, _ := strings.Cut(strings.TrimSpace(query), "?")
+	values, err := url.ParseQuery(rawParams)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid annotation query parameters: %v", err)
+	}
+	params := make(map[string]interface{}, len(values))
+	for k := range values {
+		params[k] = values.Get(k)
+	}
+	return target, params, nil
+}
+
+func toValues(params map[string]interface{}) url.Values {
+	values := url.Values{}
+	for k, v := range params {
+		if v != nil {
+			values.Set(k, fmt.Sprint(v))
+		}
+	}
+	return values
+}
+
+// newTable answers a table target: the points of the series, or the records of a panel that
+// has no series.
+func newTable(payload interface{}, series []panel.Series) table {
+	if len(series) > 0 {
+		t := table{
+			Type:    "table",
+			Columns: []tableColumn{{Text: "Time", Type: "time"}, {Text: "Series", Type: "string"}, {Text: "Value", Type: "number"}},
+			Rows:    [][]interface{}{},
+		}
+		for _, s := range series {
+			for _, p := range s.Points {
+				t.Rows = append(t.Rows, []interface{}{p.Time.UnixMilli(), s.Name, p.Value})
+			}
+		}
+		return t
+	}
+	rows, ok := panel.Rows(payload)
+	if !ok {
+		rows = []panel.Row{{"value": payload}}
+	}
+	columns := panel.Columns(rows)
+	t := table{Type: "table", Columns: make([]tableColumn, 0, len(columns)), Rows: make([][]interface{}, 0, len(rows))}
+	for _, column := range columns {
+		columnType := "number"
+		for _, row := range rows {
+			if _, isNumber := row[column].(float64); row[column] != nil && !isNumber {
+				columnType = "string"
+				break
+			}
+		}
+		t.Columns = append(t.Columns, tableColumn{Text: column, Type: columnType})
+	}
+	for _, row := range rows {
+		cells := make([]interface{}, len(columns))
+		for i, column := range columns {
+			if t.Columns[i].Type == "string" && row[column] != nil {
+				cells[i] = fmt.Sprint(row[column])
+			} else {
+				cells[i] = row[column]
+			}
+		}
+		t.Rows = append(t.Rows, cells)
+	}
+	return t
+}
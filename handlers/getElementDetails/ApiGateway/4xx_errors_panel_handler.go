@@ -1,7 +1,9 @@
 package ApiGateway
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -23,8 +25,8 @@ type Api4xxResult struct {
 }
 
 var (
-	authCache4XX       sync.Map
-	clientCache4XX     sync.Map
+	authCache4XX       = cache.NewLocal(cache.CredentialCache)
+	clientCache4XX     = cache.NewLocal(cache.ClientCache)
 	authCacheLock4XX   sync.RWMutex
 	clientCacheLock4XX sync.RWMutex
 )
@@ -62,7 +64,7 @@ func Get4XXErrorsPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCache4XX(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCache4XX(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -115,7 +117,7 @@ func authenticateAndCache4XX(commandParam model.CommandParam) (*model.Auth, erro
 	return clientAuth, nil
 }
 
-func cloudwatchClientCache4XX(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCache4XX(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLock4XX.Lock()
@@ -123,13 +125,13 @@ func cloudwatchClientCache4XX(clientAuth model.Auth) (*cloudwatch.CloudWatch, er
 
 	if client, ok := clientCache4XX.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCache4XX.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
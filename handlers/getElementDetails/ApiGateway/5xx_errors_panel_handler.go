@@ -1,7 +1,9 @@
 package ApiGateway
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -24,8 +26,8 @@ type Api5xxResult struct {
 }
 
 var (
-	authCache5xx       sync.Map
-	clientCache5xx     sync.Map
+	authCache5xx       = cache.NewLocal(cache.CredentialCache)
+	clientCache5xx     = cache.NewLocal(cache.ClientCache)
 	authCacheLock5xx   sync.RWMutex
 	clientCacheLock5xx sync.RWMutex
 )
@@ -59,15 +61,15 @@ func GetApi5xxErrorsPanel(w http.ResponseWriter, r *http.Request) {
 
 	clientAuth, err := authenticateAndCache5xx(commandParam)
 	if err != nil {
-		log.Errorf("Authentication failed: %v", err)
+		log.FromContext(r.Context()).Errorf("Authentication failed: %v", err)
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := cloudwatchClientCache5xx(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCache5xx(r.Context(), *clientAuth)
 	if err != nil {
-		log.Errorf("Error getting CloudWatch client: %v", err)
+		log.FromContext(r.Context()).Errorf("Error getting CloudWatch client: %v", err)
 		http.Error(w, fmt.Sprintf("Error getting CloudWatch client: %s", err), http.StatusInternalServerError)
 		return
 	}
@@ -84,7 +86,7 @@ func GetApi5xxErrorsPanel(w http.ResponseWriter, r *http.Request) {
 		// Call APIGateway.GetApi5xxErrorData
 		jsonString, _, err := ApiGateway.GetApi5xxErrorData(cmd, clientAuth, cloudwatchClient)
 		if err != nil {
-			log.Errorf("Error getting 5xx error data: %v", err)
+			log.FromContext(r.Context()).Errorf("Error getting 5xx error data: %v", err)
 			http.Error(w, fmt.Sprintf("Error getting 5xx error data: %s", err), http.StatusInternalServerError)
 			return
 		}
@@ -93,7 +95,7 @@ func GetApi5xxErrorsPanel(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		_, err = w.Write([]byte(jsonString))
 		if err != nil {
-			log.Errorf("Error writing response: %v", err)
+			log.FromContext(r.Context()).Errorf("Error writing response: %v", err)
 			http.Error(w, fmt.Sprintf("Error writing response: %s", err), http.StatusInternalServerError)
 
 			return
@@ -124,7 +126,7 @@ func authenticateAndCache5xx(commandParam model.CommandParam) (*model.Auth, erro
 	return clientAuth, nil
 }
 
-func cloudwatchClientCache5xx(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCache5xx(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLock5xx.Lock()
@@ -132,13 +134,13 @@ func cloudwatchClientCache5xx(clientAuth model.Auth) (*cloudwatch.CloudWatch, er
 
 	if client, ok := clientCache5xx.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCache5xx.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
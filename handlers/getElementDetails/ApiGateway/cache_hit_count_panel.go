@@ -1,7 +1,9 @@
 package ApiGateway
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -23,8 +25,8 @@ type CacheHitsResult struct {
 }
 
 var (
-	authCacheCacheHit       sync.Map
-	clientCacheCacheHit     sync.Map
+	authCacheCacheHit       = cache.NewLocal(cache.CredentialCache)
+	clientCacheCacheHit     = cache.NewLocal(cache.ClientCache)
 	authCacheLockCacheHit   sync.RWMutex
 	clientCacheLockCacheHit sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetCacheHitsPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheCacheHit(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheCacheHit(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -115,7 +117,7 @@ func authenticateAndCacheCacheHit(commandParam model.CommandParam) (*model.Auth,
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheCacheHit(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheCacheHit(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockCacheHit.Lock()
@@ -123,13 +125,13 @@ func cloudwatchClientCacheCacheHit(clientAuth model.Auth) (*cloudwatch.CloudWatc
 
 	if client, ok := clientCacheCacheHit.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheCacheHit.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
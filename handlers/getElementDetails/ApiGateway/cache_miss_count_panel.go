@@ -1,7 +1,9 @@
 package ApiGateway
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -23,8 +25,8 @@ type CacheMissResult struct {
 }
 
 var (
-	authCacheCacheMiss       sync.Map
-	clientCacheCacheMiss     sync.Map
+	authCacheCacheMiss       = cache.NewLocal(cache.CredentialCache)
+	clientCacheCacheMiss     = cache.NewLocal(cache.ClientCache)
 	authCacheLockCacheMiss   sync.RWMutex
 	clientCacheLockCacheMiss sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetCacheMissPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheCacheMiss(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheCacheMiss(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -115,7 +117,7 @@ func authenticateAndCacheCacheMiss(commandParam model.CommandParam) (*model.Auth
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheCacheMiss(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheCacheMiss(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockCacheMiss.Lock()
@@ -123,13 +125,13 @@ func cloudwatchClientCacheCacheMiss(clientAuth model.Auth) (*cloudwatch.CloudWat
 
 	if client, ok := clientCacheCacheMiss.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheCacheMiss.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
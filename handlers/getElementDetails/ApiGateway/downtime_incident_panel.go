@@ -1,9 +1,11 @@
 package ApiGateway
 
 import (
+	"awsx-api/cache"
+	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"sync"
 
@@ -16,8 +18,8 @@ import (
 )
 
 var (
-	authCacheDowntime       sync.Map
-	clientCacheDowntime     sync.Map
+	authCacheDowntime       = cache.NewLocal(cache.CredentialCache)
+	clientCacheDowntime     = cache.NewLocal(cache.ClientCache)
 	authCacheLockDowntime   sync.RWMutex
 	clientCacheLockDowntime sync.RWMutex
 )
@@ -38,8 +40,8 @@ func GetDowntimeIncidentPanel(w http.ResponseWriter, r *http.Request) {
 	endTime := queries.Get("endTime")
 	logGroupName := queries.Get("logGroupName")
 
-	log.Printf("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, crossAccountRoleArn=%s, externalId=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s\n",
-		region, elementId, elementApiUrl, crossAccountRoleArn, externalId, responseType, instanceId, startTime, endTime, logGroupName)
+	log.FromContext(r.Context()).Infof("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s",
+		region, elementId, elementApiUrl, responseType, instanceId, startTime, endTime, logGroupName)
 
 	// Prepare command parameters
 	commandParam := model.CommandParam{}
@@ -60,16 +62,16 @@ func GetDowntimeIncidentPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Println("Authentication successful")
+	log.FromContext(r.Context()).Debugf("Authentication successful")
 
 	// Create CloudWatch Logs client
-	cloudWatchLogs, err := cloudwatchClientCacheDowntime(*clientAuth)
+	cloudWatchLogs, err := cloudwatchClientCacheDowntime(r.Context(), *clientAuth)
 	if err != nil {
 		sendResponseDowntime(w, fmt.Sprintf("Failed to create CloudWatch client: %s", err), http.StatusInternalServerError)
 		return
 	}
 
-	log.Println("CloudWatch client created successfully")
+	log.FromContext(r.Context()).Debugf("CloudWatch client created successfully")
 
 	// Create Cobra command for passing flags
 	cmd := &cobra.Command{}
@@ -87,7 +89,7 @@ func GetDowntimeIncidentPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Println("Flags parsed successfully")
+	log.FromContext(r.Context()).Debugf("Flags parsed successfully")
 
 	// Call the function to get instance error rate metrics data
 	cloudwatchMetricData, err := ApiGateway.GetDowntimeIncidentsData(cmd, clientAuth, cloudWatchLogs)
@@ -126,20 +128,20 @@ func authenticateAndCacheDowntime(commandParam model.CommandParam) (*model.Auth,
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheDowntime(clientAuth model.Auth) (*cloudwatchlogs.CloudWatchLogs, error) {
+func cloudwatchClientCacheDowntime(ctx context.Context, clientAuth model.Auth) (*cloudwatchlogs.CloudWatchLogs, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockDowntime.Lock()
 	defer clientCacheLockDowntime.Unlock()
 
 	if client, ok := clientCacheDowntime.Load(cacheKey); ok {
-		return client.(*cloudwatchlogs.CloudWatchLogs), nil
+		return cache.WithContext(ctx, client).(*cloudwatchlogs.CloudWatchLogs), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH_LOG).(*cloudwatchlogs.CloudWatchLogs)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH_LOG).(*cloudwatchlogs.CloudWatchLogs)
 	clientCacheDowntime.Store(cacheKey, cloudWatchClient)
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatchlogs.CloudWatchLogs), nil
 }
 
 func sendResponseDowntime(w http.ResponseWriter, message string, statusCode int) {
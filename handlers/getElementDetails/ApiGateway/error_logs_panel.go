@@ -1,9 +1,11 @@
 package ApiGateway
 
 import (
+	"awsx-api/cache"
+	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"sync"
 
@@ -16,8 +18,8 @@ import (
 )
 
 var (
-	authCacheErrorLogs       sync.Map
-	clientCacheErrorLogs     sync.Map
+	authCacheErrorLogs       = cache.NewLocal(cache.CredentialCache)
+	clientCacheErrorLogs     = cache.NewLocal(cache.ClientCache)
 	authCacheLockErrorLogs   sync.RWMutex
 	clientCacheLockErrorLogs sync.RWMutex
 )
@@ -38,8 +40,8 @@ func GetErrorLogsPanel(w http.ResponseWriter, r *http.Request) {
 	endTime := queries.Get("endTime")
 	logGroupName := queries.Get("logGroupName")
 
-	log.Printf("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, crossAccountRoleArn=%s, externalId=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s\n",
-		region, elementId, elementApiUrl, crossAccountRoleArn, externalId, responseType, instanceId, startTime, endTime, logGroupName)
+	log.FromContext(r.Context()).Infof("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s",
+		region, elementId, elementApiUrl, responseType, instanceId, startTime, endTime, logGroupName)
 
 	// Prepare command parameters
 	commandParam := model.CommandParam{}
@@ -60,16 +62,16 @@ func GetErrorLogsPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Println("Authentication successful")
+	log.FromContext(r.Context()).Debugf("Authentication successful")
 
 	// Create CloudWatch Logs client
-	cloudWatchLogs, err := cloudwatchClientCacheErrorLogs(*clientAuth)
+	cloudWatchLogs, err := cloudwatchClientCacheErrorLogs(r.Context(), *clientAuth)
 	if err != nil {
 		sendResponseErrorLogs(w, fmt.Sprintf("Failed to create CloudWatch client: %s", err), http.StatusInternalServerError)
 		return
 	}
 
-	log.Println("CloudWatch client created successfully")
+	log.FromContext(r.Context()).Debugf("CloudWatch client created successfully")
 
 	// Create Cobra command for passing flags
 	cmd := &cobra.Command{}
@@ -87,7 +89,7 @@ func GetErrorLogsPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Println("Flags parsed successfully")
+	log.FromContext(r.Context()).Debugf("Flags parsed successfully")
 
 	// Call the function to get instance error rate metrics data
 	cloudwatchMetricData, err := ApiGateway.GetErrorLogsData(cmd, clientAuth, cloudWatchLogs)
@@ -126,20 +128,20 @@ func authenticateAndCacheErrorLogs(commandParam model.CommandParam) (*model.Auth
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheErrorLogs(clientAuth model.Auth) (*cloudwatchlogs.CloudWatchLogs, error) {
+func cloudwatchClientCacheErrorLogs(ctx context.Context, clientAuth model.Auth) (*cloudwatchlogs.CloudWatchLogs, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockErrorLogs.Lock()
 	defer clientCacheLockErrorLogs.Unlock()
 
 	if client, ok := clientCacheErrorLogs.Load(cacheKey); ok {
-		return client.(*cloudwatchlogs.CloudWatchLogs), nil
+		return cache.WithContext(ctx, client).(*cloudwatchlogs.CloudWatchLogs), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH_LOG).(*cloudwatchlogs.CloudWatchLogs)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH_LOG).(*cloudwatchlogs.CloudWatchLogs)
 	clientCacheErrorLogs.Store(cacheKey, cloudWatchClient)
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatchlogs.CloudWatchLogs), nil
 }
 
 func sendResponseErrorLogs(w http.ResponseWriter, message string, statusCode int) {
@@ -1,7 +1,9 @@
 package ApiGateway
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -23,8 +25,8 @@ type ApiIntegrationLatencyResult struct {
 }
 
 var (
-	authCacheIntegLatency       sync.Map
-	clientCacheIntegLatency     sync.Map
+	authCacheIntegLatency       = cache.NewLocal(cache.CredentialCache)
+	clientCacheIntegLatency     = cache.NewLocal(cache.ClientCache)
 	authCacheLockIntegLatency   sync.RWMutex
 	clientCacheLockIntegLatency sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetIntegrationLatencyPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheIntegLatency(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheIntegLatency(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -115,7 +117,7 @@ func authenticateAndCacheIntegLatency(commandParam model.CommandParam) (*model.A
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheIntegLatency(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheIntegLatency(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockIntegLatency.Lock()
@@ -123,13 +125,13 @@ func cloudwatchClientCacheIntegLatency(clientAuth model.Auth) (*cloudwatch.Cloud
 
 	if client, ok := clientCacheIntegLatency.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheIntegLatency.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package ApiGateway
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -20,8 +22,8 @@ type ApiSuccessfulFailedResult struct {
 }
 
 var (
-	authCacheSuccessFailed       sync.Map
-	clientCacheSuccessFailed     sync.Map
+	authCacheSuccessFailed       = cache.NewLocal(cache.CredentialCache)
+	clientCacheSuccessFailed     = cache.NewLocal(cache.ClientCache)
 	authCacheLockSuccessFailed   sync.RWMutex
 	clientCacheLockSuccessFailed sync.RWMutex
 )
@@ -59,7 +61,7 @@ func GetSuccessAndFailedEventsPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheSuccessFailed(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheSuccessFailed(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -112,7 +114,7 @@ func authenticateAndCacheSuccessFailed(commandParam model.CommandParam) (*model.
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheSuccessFailed(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheSuccessFailed(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockSuccessFailed.Lock()
@@ -120,13 +122,13 @@ func cloudwatchClientCacheSuccessFailed(clientAuth model.Auth) (*cloudwatch.Clou
 
 	if client, ok := clientCacheSuccessFailed.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheSuccessFailed.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
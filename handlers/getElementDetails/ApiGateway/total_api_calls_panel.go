@@ -1,7 +1,9 @@
 package ApiGateway
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -23,8 +25,8 @@ type ApiCallsResult struct {
 }
 
 var (
-	authCacheCalls       sync.Map
-	clientCacheCalls     sync.Map
+	authCacheCalls       = cache.NewLocal(cache.CredentialCache)
+	clientCacheCalls     = cache.NewLocal(cache.ClientCache)
 	authCacheLockCalls   sync.RWMutex
 	clientCacheLockCalls sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetTotalApiCallsPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheCalls(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheCalls(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -115,7 +117,7 @@ func authenticateAndCacheCalls(commandParam model.CommandParam) (*model.Auth, er
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheCalls(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheCalls(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockCalls.Lock()
@@ -123,13 +125,13 @@ func cloudwatchClientCacheCalls(clientAuth model.Auth) (*cloudwatch.CloudWatch,
 
 	if client, ok := clientCacheCalls.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheCalls.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,6 +1,7 @@
 package ApiGateway
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
 	"fmt"
 	"net/http"
@@ -18,7 +19,7 @@ type MetricResults struct {
 }
 
 var (
-	authCache     sync.Map
+	authCache     = cache.NewLocal(cache.CredentialCache)
 	authCacheLock sync.RWMutex
 )
 
@@ -1,7 +1,9 @@
 package ApiGateway
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type uptimeResult struct {
 }
 
 var (
-	authDatabase       sync.Map
-	clientDatabase     sync.Map
+	authDatabase       = cache.NewLocal(cache.CredentialCache)
+	clientDatabase     = cache.NewLocal(cache.ClientCache)
 	authDatabaseLock   sync.RWMutex
 	clientDatabaseLock sync.RWMutex
 )
@@ -64,7 +66,7 @@ func GetUptimePercentagePanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCaheUptime(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCaheUptime(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -139,7 +141,7 @@ func authnticateAndCacheUptime(commandParam model.CommandParam) (*model.Auth, er
 	return clientAuth, nil
 }
 
-func cloudwatchClientCaheUptime(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCaheUptime(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientDatabaseLock.Lock()
@@ -147,13 +149,13 @@ func cloudwatchClientCaheUptime(clientAuth model.Auth) (*cloudwatch.CloudWatch,
 
 	if client, ok := clientDatabase.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientDatabase.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,8 +26,8 @@ type AlertandNotification struct {
 }
 
 var (
-	authCachealert       sync.Map
-	clientCachealert     sync.Map
+	authCachealert       = cache.NewLocal(cache.CredentialCache)
+	clientCachealert     = cache.NewLocal(cache.ClientCache)
 	authCacheLockalert   sync.RWMutex
 	clientCacheLockalert sync.RWMutex
 )
@@ -72,7 +74,7 @@ func GetAlertsAndNotificationsPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 		if responseType == "json" {
 			err = json.NewEncoder(w).Encode(notifications)
 			if err != nil {
@@ -127,22 +129,22 @@ func authenticateAndCacheAlert(commandParam model.CommandParam) (*model.Auth, er
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheAlert(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheAlert(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockalert.Lock()
 	if client, ok := clientCachealert.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
 		clientCacheLockalert.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCachealert.Store(cacheKey, cloudWatchClient)
 	clientCacheLockalert.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,8 +26,8 @@ type allocatableResult struct {
 }
 
 var (
-	cpuauthCache       sync.Map
-	cpuclientCache     sync.Map
+	cpuauthCache       = cache.NewLocal(cache.CredentialCache)
+	cpuclientCache     = cache.NewLocal(cache.ClientCache)
 	cpuauthCacheLock   sync.RWMutex
 	cpuclientCacheLock sync.RWMutex
 )
@@ -59,7 +61,7 @@ func GetCPUUsageUserPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := cpucloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := cpucloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -77,7 +79,7 @@ func GetCPUUsageUserPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 			if err != nil {
@@ -130,22 +132,22 @@ func authenticatecpuAndCache(commandParam model.CommandParam) (*model.Auth, erro
 	return clientAuth, nil
 }
 
-func cpucloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cpucloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	cpuclientCacheLock.Lock()
 	if client, ok := cpuclientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
 		cpuclientCacheLock.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	cpuclientCache.Store(cacheKey, cloudWatchClient)
 	cpuclientCacheLock.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type CpuUtilizationsResult struct {
 }
 
 var (
-	cpuAuthCache       sync.Map
-	cpuClientCache     sync.Map
+	cpuAuthCache       = cache.NewLocal(cache.CredentialCache)
+	cpuClientCache     = cache.NewLocal(cache.ClientCache)
 	cpuAuthCacheLock   sync.RWMutex
 	cpuClientCacheLock sync.RWMutex
 )
@@ -64,7 +66,7 @@ func GetCPUUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cpuCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := cpuCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -139,7 +141,7 @@ func authenticateandCache(commandParam model.CommandParam) (*model.Auth, error)
 	return clientAuth, nil
 }
 
-func cpuCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cpuCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	cpuClientCacheLock.Lock()
@@ -147,13 +149,13 @@ func cpuCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, er
 
 	if client, ok := cpuClientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	cpuClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -51,7 +51,7 @@ func GetCpuUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 	//	return
 	//}
 	//cloudwatchClient, err := cloudwatchClientCache(*clientAuth)
-	clientAuth, awsClient, err := cache.GetAwsCredsAndClient(commandParam, awsclient.CLOUDWATCH)
+	clientAuth, awsClient, err := cache.GetAwsCredsAndClient(r.Context(), commandParam, awsclient.CLOUDWATCH)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -67,21 +67,21 @@ func GetCpuUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 		cmd.PersistentFlags().StringVar(&responseType, "responseType", r.URL.Query().Get("responseType"), "responseType flag - json/frame")
 		jsonString, cloudwatchMetricData, err := EC2.GetCpuUtilizationPanel(cmd, clientAuth, cloudwatchClient)
 		if err != nil {
-			log.Infof("error found in GetCpuUtilizationPanel: ", err)
+			log.FromContext(r.Context()).Infof("error found in GetCpuUtilizationPanel: %v", err)
 			var awsErr awserr.Error
 			if errors.As(err, &awsErr) {
 				if awsErr.Code() == "ExpiredToken" {
-					log.Infof("aws session expired. resetting connection cache")
-					clientAuth, awsClient, err = cache.SetAwsCredsAndClientInCache(commandParam, awsclient.CLOUDWATCH)
+					log.FromContext(r.Context()).Infof("aws session expired. resetting connection cache")
+					clientAuth, awsClient, err = cache.SetAwsCredsAndClientInCache(r.Context(), commandParam, awsclient.CLOUDWATCH)
 					jsonString, cloudwatchMetricData, err = EC2.GetCpuUtilizationPanel(cmd, clientAuth, cloudwatchClient)
 				}
 			}
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
-			log.Infof("creating response frame")
-			log.Infof("response type :" + responseType)
+			log.FromContext(r.Context()).Infof("creating response frame")
+			log.FromContext(r.Context()).Infof("response type :" + responseType)
 			if filter == "SampleCount" {
 				err = json.NewEncoder(w).Encode(cloudwatchMetricData["CurrentUsage"])
 				if err != nil {
@@ -108,7 +108,7 @@ func GetCpuUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		} else {
-			log.Infof("creating response json")
+			log.FromContext(r.Context()).Infof("creating response json")
 			type UsageData struct {
 				AverageUsage float64 `json:"AverageUsage"`
 				CurrentUsage float64 `json:"CurrentUsage"`
@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,8 +26,8 @@ type CustomAlertPanel struct {
 }
 
 var (
-	authCacheCustomAlert       sync.Map
-	clientCacheCustomAlert     sync.Map
+	authCacheCustomAlert       = cache.NewLocal(cache.CredentialCache)
+	clientCacheCustomAlert     = cache.NewLocal(cache.ClientCache)
 	authCacheLockCustomAlert   sync.RWMutex
 	clientCacheLockCustomAlert sync.RWMutex
 )
@@ -120,7 +122,7 @@ func authenticateAndCacheCustomAlert(commandParam model.CommandParam) (*model.Au
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheCustomAlert(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheCustomAlert(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockStatus.Lock()
@@ -128,13 +130,13 @@ func cloudwatchClientCacheCustomAlert(clientAuth model.Auth) (*cloudwatch.CloudW
 
 	if client, ok := clientCacheStatus.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheStatus.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
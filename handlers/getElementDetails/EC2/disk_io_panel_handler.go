@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type DiskIOPerformanceResult struct {
 }
 
 var (
-	diskIOAuthCache       sync.Map
-	diskIOClientCache     sync.Map
+	diskIOAuthCache       = cache.NewLocal(cache.CredentialCache)
+	diskIOClientCache     = cache.NewLocal(cache.ClientCache)
 	diskIOAuthCacheLock   sync.RWMutex
 	diskIOClientCacheLock sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetDiskIOPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := diskIOCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := diskIOCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -128,7 +130,7 @@ func authenticateAndCached(commandParam model.CommandParam) (*model.Auth, error)
 	return clientAuth, nil
 }
 
-func diskIOCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func diskIOCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	diskIOClientCacheLock.Lock()
@@ -136,13 +138,13 @@ func diskIOCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch,
 
 	if client, ok := diskIOClientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	diskIOClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,6 +1,8 @@
 package EC2
 
 import (
+	"awsx-api/cache"
+	"awsx-api/log"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,8 +15,8 @@ import (
 )
 
 var (
-	authCacheTracking       sync.Map
-	clientCacheTracking     sync.Map
+	authCacheTracking       = cache.NewLocal(cache.CredentialCache)
+	clientCacheTracking     = cache.NewLocal(cache.ClientCache)
 	authCacheLockTracking   sync.RWMutex
 	clientCacheLockTracking sync.RWMutex
 )
@@ -35,8 +37,8 @@ func ErrorTrackingHandler(w http.ResponseWriter, r *http.Request) {
 	endTime := queries.Get("endTime")
 	logGroupName := queries.Get("logGroupName")
 
-	fmt.Printf("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, crossAccountRoleArn=%s, externalId=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s\n",
-		region, elementId, elementApiUrl, crossAccountRoleArn, externalId, responseType, instanceId, startTime, endTime, logGroupName)
+	log.FromContext(r.Context()).Infof("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s",
+		region, elementId, elementApiUrl, responseType, instanceId, startTime, endTime, logGroupName)
 
 	// Prepare command parameters
 	commandParam := model.CommandParam{}
@@ -57,7 +59,7 @@ func ErrorTrackingHandler(w http.ResponseWriter, r *http.Request) {
 	// 	return
 	// }
 
-	fmt.Println("Authentication successful")
+	log.FromContext(r.Context()).Debugf("Authentication successful")
 
 	// Create Cobra command for passing flags
 	cmd := &cobra.Command{}
@@ -75,7 +77,7 @@ func ErrorTrackingHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Println("Flags parsed successfully")
+	log.FromContext(r.Context()).Debugf("Flags parsed successfully")
 	// EC2.ListErrorEvents()
 	// Call the function to get error events data
 	errorData, err := EC2.ListErrorEvents()
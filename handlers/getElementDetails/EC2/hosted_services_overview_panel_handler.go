@@ -1,6 +1,7 @@
 package EC2
 
 import (
+	"awsx-api/log"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -27,8 +28,8 @@ func HostedServicesOverviewHandler(w http.ResponseWriter, r *http.Request) {
 	endTime := queries.Get("endTime")
 	logGroupName := queries.Get("logGroupName")
 
-	fmt.Printf("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, crossAccountRoleArn=%s, externalId=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s\n",
-		region, elementId, elementApiUrl, crossAccountRoleArn, externalId, responseType, instanceId, startTime, endTime, logGroupName)
+	log.FromContext(r.Context()).Infof("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s",
+		region, elementId, elementApiUrl, responseType, instanceId, startTime, endTime, logGroupName)
 
 	// Prepare command parameters
 	commandParam := model.CommandParam{}
@@ -49,7 +50,7 @@ func HostedServicesOverviewHandler(w http.ResponseWriter, r *http.Request) {
 	// 	return
 	// }
 
-	fmt.Println("Authentication successful")
+	log.FromContext(r.Context()).Debugf("Authentication successful")
 
 	// Create Cobra command for passing flags
 	cmd := &cobra.Command{}
@@ -67,7 +68,7 @@ func HostedServicesOverviewHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Println("Flags parsed successfully")
+	log.FromContext(r.Context()).Debugf("Flags parsed successfully")
 	// EC2.ListErrorEvents()
 	// Call the function to get hosted service data
 	hostedServiceData, err := EC2.GetHostedServicesData(cmd)
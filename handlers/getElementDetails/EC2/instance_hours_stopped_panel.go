@@ -1,13 +1,14 @@
 package EC2
 
 import (
+	"awsx-api/cache"
+	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"sync"
 
-	"log"
-
 	"github.com/Appkube-awsx/awsx-common/authenticate"
 	"github.com/Appkube-awsx/awsx-common/awsclient"
 	"github.com/Appkube-awsx/awsx-common/model"
@@ -17,8 +18,8 @@ import (
 )
 
 var (
-	authCacheInstancehourStoppedPanel       sync.Map
-	clientCacheInstancehourStoppedPanel     sync.Map
+	authCacheInstancehourStoppedPanel       = cache.NewLocal(cache.CredentialCache)
+	clientCacheInstancehourStoppedPanel     = cache.NewLocal(cache.ClientCache)
 	authCacheLockInstancehourStoppedPanel   sync.RWMutex
 	clientCacheLockInstancehourStoppedPanel sync.RWMutex
 )
@@ -39,8 +40,8 @@ func InstanceHourStoppedPanel(w http.ResponseWriter, r *http.Request) {
 	endTime := queries.Get("endTime")
 	logGroupName := queries.Get("logGroupName")
 
-	log.Printf("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, crossAccountRoleArn=%s, externalId=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s\n",
-		region, elementId, elementApiUrl, crossAccountRoleArn, externalId, responseType, instanceId, startTime, endTime, logGroupName)
+	log.FromContext(r.Context()).Infof("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s",
+		region, elementId, elementApiUrl, responseType, instanceId, startTime, endTime, logGroupName)
 
 	// Prepare command parameters
 	commandParam := model.CommandParam{}
@@ -61,16 +62,16 @@ func InstanceHourStoppedPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Println("Authentication successful")
+	log.FromContext(r.Context()).Debugf("Authentication successful")
 
 	// Create CloudWatch Logs client
-	cloudWatchLogs, err := cloudwatchClientCacheInstanceHourStoppedPanel(*clientAuth)
+	cloudWatchLogs, err := cloudwatchClientCacheInstanceHourStoppedPanel(r.Context(), *clientAuth)
 	if err != nil {
 		sendErrorrResponse(w, fmt.Sprintf("Failed to create CloudWatch client: %s", err), http.StatusInternalServerError)
 		return
 	}
 
-	log.Println("CloudWatch client created successfully")
+	log.FromContext(r.Context()).Debugf("CloudWatch client created successfully")
 
 	// Create Cobra command for passing flags
 	cmd := &cobra.Command{}
@@ -88,7 +89,7 @@ func InstanceHourStoppedPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Println("Flags parsed successfully")
+	log.FromContext(r.Context()).Debugf("Flags parsed successfully")
 
 	// Call the function to get instance start count metrics data
 	cloudwatchMetricData, err := EC2.GetInstanceStoppedCountPanel(cmd, clientAuth, cloudWatchLogs)
@@ -131,20 +132,20 @@ func authenticateAndCacheInstanceHourStoppedPanel(commandParam model.CommandPara
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheInstanceHourStoppedPanel(clientAuth model.Auth) (*cloudwatchlogs.CloudWatchLogs, error) {
+func cloudwatchClientCacheInstanceHourStoppedPanel(ctx context.Context, clientAuth model.Auth) (*cloudwatchlogs.CloudWatchLogs, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockInstancehourStoppedPanel.Lock()
 	defer clientCacheLockInstancehourStoppedPanel.Unlock()
 
 	if client, ok := clientCacheInstancehourStoppedPanel.Load(cacheKey); ok {
-		return client.(*cloudwatchlogs.CloudWatchLogs), nil
+		return cache.WithContext(ctx, client).(*cloudwatchlogs.CloudWatchLogs), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH_LOG).(*cloudwatchlogs.CloudWatchLogs)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH_LOG).(*cloudwatchlogs.CloudWatchLogs)
 	clientCacheInstancehourStoppedPanel.Store(cacheKey, cloudWatchClient)
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatchlogs.CloudWatchLogs), nil
 }
 
 func sendErrorrResponse(w http.ResponseWriter, message string, statusCode int) {
@@ -1,9 +1,11 @@
 package EC2
 
 import (
+	"awsx-api/cache"
+	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"sync"
 
@@ -16,8 +18,8 @@ import (
 )
 
 var (
-	authCacheInstanceStartPanel       sync.Map
-	clientCacheInstanceStartPanel     sync.Map
+	authCacheInstanceStartPanel       = cache.NewLocal(cache.CredentialCache)
+	clientCacheInstanceStartPanel     = cache.NewLocal(cache.ClientCache)
 	authCacheLockInstanceStartPanel   sync.RWMutex
 	clientCacheLockInstanceStartPanel sync.RWMutex
 )
@@ -38,8 +40,8 @@ func InstanceStartCountPanelHandler(w http.ResponseWriter, r *http.Request) {
 	endTime := queries.Get("endTime")
 	logGroupName := queries.Get("logGroupName")
 
-	log.Printf("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, crossAccountRoleArn=%s, externalId=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s\n",
-		region, elementId, elementApiUrl, crossAccountRoleArn, externalId, responseType, instanceId, startTime, endTime, logGroupName)
+	log.FromContext(r.Context()).Infof("Received request with parameters: region=%s, elementId=%s, elementApiUrl=%s, responseType=%s, instanceId=%s, startTime=%s, endTime=%s, logGroupName=%s",
+		region, elementId, elementApiUrl, responseType, instanceId, startTime, endTime, logGroupName)
 
 	// Prepare command parameters
 	commandParam := model.CommandParam{}
@@ -60,16 +62,16 @@ func InstanceStartCountPanelHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Println("Authentication successful")
+	log.FromContext(r.Context()).Debugf("Authentication successful")
 
 	// Create CloudWatch Logs client
-	cloudWatchLogs, err := cloudwatchClientCacheInstanceStartPanel(*clientAuth)
+	cloudWatchLogs, err := cloudwatchClientCacheInstanceStartPanel(r.Context(), *clientAuth)
 	if err != nil {
 		sendErrorResponse(w, fmt.Sprintf("Failed to create CloudWatch client: %s", err), http.StatusInternalServerError)
 		return
 	}
 
-	log.Println("CloudWatch client created successfully")
+	log.FromContext(r.Context()).Debugf("CloudWatch client created successfully")
 
 	// Create Cobra command for passing flags
 	cmd := &cobra.Command{}
@@ -87,7 +89,7 @@ func InstanceStartCountPanelHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Println("Flags parsed successfully")
+	log.FromContext(r.Context()).Debugf("Flags parsed successfully")
 
 	// Call the function to get instance start count metrics data
 	cloudwatchMetricData, err := EC2.GetInstanceStartCountPanel(cmd, clientAuth, cloudWatchLogs)
@@ -130,20 +132,20 @@ func authenticateAndCacheInstanceStartPanel(commandParam model.CommandParam) (*m
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheInstanceStartPanel(clientAuth model.Auth) (*cloudwatchlogs.CloudWatchLogs, error) {
+func cloudwatchClientCacheInstanceStartPanel(ctx context.Context, clientAuth model.Auth) (*cloudwatchlogs.CloudWatchLogs, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockInstanceStartPanel.Lock()
 	defer clientCacheLockInstanceStartPanel.Unlock()
 
 	if client, ok := clientCacheInstanceStartPanel.Load(cacheKey); ok {
-		return client.(*cloudwatchlogs.CloudWatchLogs), nil
+		return cache.WithContext(ctx, client).(*cloudwatchlogs.CloudWatchLogs), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH_LOG).(*cloudwatchlogs.CloudWatchLogs)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH_LOG).(*cloudwatchlogs.CloudWatchLogs)
 	clientCacheInstanceStartPanel.Store(cacheKey, cloudWatchClient)
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatchlogs.CloudWatchLogs), nil
 }
 
 func sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
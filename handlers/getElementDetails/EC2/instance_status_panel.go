@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,8 +26,8 @@ type InstanceStatusPanel struct {
 }
 
 var (
-	authCacheStatus       sync.Map
-	clientCacheStatus     sync.Map
+	authCacheStatus       = cache.NewLocal(cache.CredentialCache)
+	clientCacheStatus     = cache.NewLocal(cache.ClientCache)
 	authCacheLockStatus   sync.RWMutex
 	clientCacheLockStatus sync.RWMutex
 )
@@ -118,7 +120,7 @@ func authenticateAndCacheStatus(commandParam model.CommandParam) (*model.Auth, e
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheStatus(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheStatus(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockStatus.Lock()
@@ -126,13 +128,13 @@ func cloudwatchClientCacheStatus(clientAuth model.Auth) (*cloudwatch.CloudWatch,
 
 	if client, ok := clientCacheStatus.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheStatus.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
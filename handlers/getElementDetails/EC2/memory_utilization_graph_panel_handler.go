@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,8 +26,8 @@ type MemoryGraphUtilizationResult struct {
 }
 
 var (
-	memoryAuthCache       sync.Map
-	memoryClientCache     sync.Map
+	memoryAuthCache       = cache.NewLocal(cache.CredentialCache)
+	memoryClientCache     = cache.NewLocal(cache.ClientCache)
 	memoryAuthCacheLock   sync.RWMutex
 	memoryClientCacheLock sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetMemoryUtilizationPaneel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := memoryCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := memoryCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -140,7 +142,7 @@ func authenticateAndCachem(commandParam model.CommandParam) (*model.Auth, error)
 	return clientAuth, nil
 }
 
-func memoryCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func memoryCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	memoryClientCacheLock.Lock()
@@ -148,13 +150,13 @@ func memoryCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch,
 
 	if client, ok := memoryClientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	memoryClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	memauthCache       sync.Map
-	memclientCache     sync.Map
+	memauthCache       = cache.NewLocal(cache.CredentialCache)
+	memclientCache     = cache.NewLocal(cache.ClientCache)
 	memauthCacheLock   sync.RWMutex
 	memclientCacheLock sync.RWMutex
 )
@@ -55,7 +57,7 @@ func GetMemoryUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := memcloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := memcloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -73,10 +75,10 @@ func GetMemoryUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 		if responseType == "frame" {
-			log.Infof("creating response frame")
-			log.Infof("response type :" + responseType)
+			log.FromContext(r.Context()).Infof("creating response frame")
+			log.FromContext(r.Context()).Infof("response type :" + responseType)
 			if filter == "SampleCount" {
 				err = json.NewEncoder(w).Encode(cloudwatchMetricData["CurrentUsage"])
 				if err != nil {
@@ -103,7 +105,7 @@ func GetMemoryUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		} else {
-			log.Infof("creating response json")
+			log.FromContext(r.Context()).Infof("creating response json")
 			type UsageData struct {
 				AverageUsage float64 `json:"AverageUsage"`
 				CurrentUsage float64 `json:"CurrentUsage"`
@@ -159,22 +161,22 @@ func memauthenticateAndCache(commandParam model.CommandParam) (*model.Auth, erro
 	return clientAuth, nil
 }
 
-func memcloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func memcloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	memclientCacheLock.Lock()
 	if client, ok := memclientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
 		memclientCacheLock.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	memclientCache.Store(cacheKey, cloudWatchClient)
 	memclientCacheLock.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type netThroughput struct {
 }
 
 var (
-	authCacheThroughput       sync.Map
-	clientCacheThroughput     sync.Map
+	authCacheThroughput       = cache.NewLocal(cache.CredentialCache)
+	clientCacheThroughput     = cache.NewLocal(cache.ClientCache)
 	authCacheLockThroughput   sync.RWMutex
 	clientCacheLockThroughput sync.RWMutex
 )
@@ -60,7 +62,7 @@ func GetNetworkThroughputPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := cloudwatchClientCacheThroughput(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheThroughput(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -78,7 +80,7 @@ func GetNetworkThroughputPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 			if err != nil {
@@ -131,22 +133,22 @@ func authenticateAndCacheThroughput(commandParam model.CommandParam) (*model.Aut
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheThroughput(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheThroughput(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockThroughput.Lock()
 	if client, ok := clientCacheThroughput.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
 		clientCacheLockThroughput.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheThroughput.Store(cacheKey, cloudWatchClient)
 	clientCacheLockThroughput.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
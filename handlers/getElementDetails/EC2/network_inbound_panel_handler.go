@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,8 +26,8 @@ type NetworkInbound struct {
 }
 
 var (
-	authCacheNetworkInbound       sync.Map
-	clientCacheNetworkInbound     sync.Map
+	authCacheNetworkInbound       = cache.NewLocal(cache.CredentialCache)
+	clientCacheNetworkInbound     = cache.NewLocal(cache.ClientCache)
 	authCacheLockNetworkInbound   sync.RWMutex
 	clientCacheLockNetworkInbound sync.RWMutex
 )
@@ -61,7 +63,7 @@ func GetNetworkInboundPanell(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheInn(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheInn(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -82,7 +84,7 @@ func GetNetworkInboundPanell(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 			if err != nil {
@@ -134,21 +136,21 @@ func authenticateAndCacheInn(commandParam model.CommandParam) (*model.Auth, erro
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheInn(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheInn(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockNetworkInbound.Lock()
 	if client, ok := clientCacheNetworkInbound.Load(cacheKey); ok {
 		log.Infof("Cloudwatch client found in cache for given cross account role: %s", cacheKey)
 		clientCacheLockNetworkInbound.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	log.Infof("Creating new CloudWatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheNetworkInbound.Store(cacheKey, cloudWatchClient)
 	clientCacheLockNetworkInbound.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,8 +26,8 @@ type NetworkOutbound struct {
 }
 
 var (
-	authCacheNetworkOutbound       sync.Map
-	clientCacheNetworkOutbound     sync.Map
+	authCacheNetworkOutbound       = cache.NewLocal(cache.CredentialCache)
+	clientCacheNetworkOutbound     = cache.NewLocal(cache.ClientCache)
 	authCacheLockNetworkOutbound   sync.RWMutex
 	clientCacheLockNetworkOutbound sync.RWMutex
 )
@@ -61,7 +63,7 @@ func GetNetworkOutboundPanell(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheOutbound(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheOutbound(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -82,7 +84,7 @@ func GetNetworkOutboundPanell(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 			if err != nil {
@@ -134,21 +136,21 @@ func authenticateAndCacheOutbound(commandParam model.CommandParam) (*model.Auth,
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheOutbound(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheOutbound(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockNetworkOutbound.Lock()
 	if client, ok := clientCacheNetworkOutbound.Load(cacheKey); ok {
 		log.Infof("Cloudwatch client found in cache for given cross account role: %s", cacheKey)
 		clientCacheLockNetworkOutbound.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	log.Infof("Creating new CloudWatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheNetworkOutbound.Store(cacheKey, cloudWatchClient)
 	clientCacheLockNetworkOutbound.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	netauthCache       sync.Map
-	netclientCache     sync.Map
+	netauthCache       = cache.NewLocal(cache.CredentialCache)
+	netclientCache     = cache.NewLocal(cache.ClientCache)
 	netauthCacheLock   sync.RWMutex
 	netclientCacheLock sync.RWMutex
 )
@@ -55,7 +57,7 @@ func GetNetworkUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := netcloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := netcloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -73,11 +75,11 @@ func GetNetworkUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
-			log.Infof("creating response frame")
-			log.Infof("response type :" + responseType)
+			log.FromContext(r.Context()).Infof("creating response frame")
+			log.FromContext(r.Context()).Infof("response type :" + responseType)
 			if filter == "InboundTraffic" {
 				err = json.NewEncoder(w).Encode(cloudwatchMetricData["InboundTraffic"])
 				if err != nil {
@@ -118,7 +120,7 @@ func GetNetworkUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		} else {
-			log.Infof("creating response json")
+			log.FromContext(r.Context()).Infof("creating response json")
 			type UsageData struct {
 				InboundTraffic  float64 `json:"InboundTraffic"`
 				OutboundTraffic float64 `json:"OutboundTraffic"`
@@ -186,22 +188,22 @@ func netauthenticateAndCache(commandParam model.CommandParam) (*model.Auth, erro
 	return clientAuth, nil
 }
 
-func netcloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func netcloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	netclientCacheLock.Lock()
 	if client, ok := netclientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
 		netclientCacheLock.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	netclientCache.Store(cacheKey, cloudWatchClient)
 	netclientCacheLock.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EC2
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -22,8 +24,8 @@ type StorageUtilizationResult struct {
 }
 
 var (
-	storageAuthCache       sync.Map
-	storageClientCache     sync.Map
+	storageAuthCache       = cache.NewLocal(cache.CredentialCache)
+	storageClientCache     = cache.NewLocal(cache.ClientCache)
 	storageAuthCacheLock   sync.RWMutex
 	storageClientCacheLock sync.RWMutex
 )
@@ -60,7 +62,7 @@ func GetStorageUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := storageCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := storageCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -142,7 +144,7 @@ func authenticateAndCaches(commandParam model.CommandParam) (*model.Auth, error)
 	return clientAuth, nil
 }
 
-func storageCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func storageCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	storageClientCacheLock.Lock()
@@ -150,13 +152,13 @@ func storageCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch
 
 	if client, ok := storageClientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	storageClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
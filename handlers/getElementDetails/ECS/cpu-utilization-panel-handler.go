@@ -1,7 +1,9 @@
 package ECS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -16,8 +18,8 @@ import (
 )
 
 var (
-	authCache       sync.Map
-	clientCache     sync.Map
+	authCache       = cache.NewLocal(cache.CredentialCache)
+	clientCache     = cache.NewLocal(cache.ClientCache)
 	authCacheLock   sync.RWMutex
 	clientCacheLock sync.RWMutex
 	//authCacheLock sync.Mutex
@@ -54,7 +56,7 @@ func GetECScpuUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := cloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -72,10 +74,10 @@ func GetECScpuUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 		if responseType == "frame" {
-			log.Infof("creating response frame")
-			log.Infof("response type :" + responseType)
+			log.FromContext(r.Context()).Infof("creating response frame")
+			log.FromContext(r.Context()).Infof("response type :" + responseType)
 			if filter == "SampleCount" {
 				err = json.NewEncoder(w).Encode(cloudwatchMetricData["CurrentUsage"])
 				if err != nil {
@@ -103,7 +105,7 @@ func GetECScpuUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		} else {
-			log.Infof("creating response json")
+			log.FromContext(r.Context()).Infof("creating response json")
 			type UsageData struct {
 				AverageUsage float64 `json:"AverageUsage"`
 				CurrentUsage float64 `json:"CurrentUsage"`
@@ -155,22 +157,22 @@ func authenticateAndCache(commandParam model.CommandParam) (*model.Auth, error)
 	return clientAuth, nil
 }
 
-func cloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLock.Lock()
 	if client, ok := clientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
 		clientCacheLock.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCache.Store(cacheKey, cloudWatchClient)
 	clientCacheLock.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
\ No newline at end of file
@@ -1,7 +1,9 @@
 package ECS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	memoryAuthCache       sync.Map
-	memoryClientCache     sync.Map
+	memoryAuthCache       = cache.NewLocal(cache.CredentialCache)
+	memoryClientCache     = cache.NewLocal(cache.ClientCache)
 	memoryAuthCacheLock   sync.RWMutex
 	memoryClientCacheLock sync.RWMutex
 	//authCacheLock sync.Mutex
@@ -55,7 +57,7 @@ func GetECSMemoryUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := memoryCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := memoryCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -73,10 +75,10 @@ func GetECSMemoryUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 		if responseType == "frame" {
-			log.Infof("creating response frame")
-			log.Infof("response type :" + responseType)
+			log.FromContext(r.Context()).Infof("creating response frame")
+			log.FromContext(r.Context()).Infof("response type :" + responseType)
 			if filter == "SampleCount" {
 				err = json.NewEncoder(w).Encode(cloudwatchMetricData["CurrentUsage"])
 				if err != nil {
@@ -104,7 +106,7 @@ func GetECSMemoryUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		} else {
-			log.Infof("creating response json")
+			log.FromContext(r.Context()).Infof("creating response json")
 			type UsageData struct {
 				AverageUsage float64 `json:"AverageUsage"`
 				CurrentUsage float64 `json:"CurrentUsage"`
@@ -156,22 +158,22 @@ func memoryAuthenticateAndCache(commandParam model.CommandParam) (*model.Auth, e
 	return clientAuth, nil
 }
 
-func memoryCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func memoryCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	memoryClientCacheLock.Lock()
 	if client, ok := memoryClientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
 		memoryClientCacheLock.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	memoryClientCache.Store(cacheKey, cloudWatchClient)
 	memoryClientCacheLock.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package ECS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,8 +26,8 @@ type WriteBytes struct {
 }
 
 var (
-	writeBytesAuthCache       sync.Map
-	writeBytesClientCache     sync.Map
+	writeBytesAuthCache       = cache.NewLocal(cache.CredentialCache)
+	writeBytesClientCache     = cache.NewLocal(cache.ClientCache)
 	writeBytesAuthCacheLock   sync.RWMutex
 	writeBytesClientCacheLock sync.RWMutex
 )
@@ -59,7 +61,7 @@ func GetECSWriteBytesPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := writeBytesCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := writeBytesCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -77,7 +79,7 @@ func GetECSWriteBytesPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 			if err != nil {
@@ -130,22 +132,22 @@ func writeBytesAuthenticateAndCache(commandParam model.CommandParam) (*model.Aut
 	return clientAuth, nil
 }
 
-func writeBytesCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func writeBytesCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	writeBytesClientCacheLock.Lock()
 	if client, ok := writeBytesClientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
 		readBytesClientCacheLock.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	writeBytesClientCache.Store(cacheKey, cloudWatchClient)
 	writeBytesClientCacheLock.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
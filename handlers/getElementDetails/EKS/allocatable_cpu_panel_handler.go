@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	allocatableCPUAuthCache       sync.Map
-	allocatableCPUClientCache     sync.Map
+	allocatableCPUAuthCache       = cache.NewLocal(cache.CredentialCache)
+	allocatableCPUClientCache     = cache.NewLocal(cache.ClientCache)
 	allocatableCPUAuthCacheLock   sync.RWMutex
 	allocatableCPUClientCacheLock sync.RWMutex
 )
@@ -66,7 +68,7 @@ func GetEKSAllocatableCPUPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := allocatedCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := allocatedCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -89,7 +91,7 @@ func GetEKSAllocatableCPUPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 			if err != nil {
@@ -141,17 +143,17 @@ func allocatedAuthenticateAndCache(commandParam model.CommandParam) (*model.Auth
 }
 
 // cloudwatchClientCache caches cloudwatch client
-func allocatedCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func allocatedCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	allocatableCPUClientCacheLock.Lock()
 	defer allocatableCPUClientCacheLock.Unlock()
 
 	if client, ok := allocatableCPUClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	allocatableCPUClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
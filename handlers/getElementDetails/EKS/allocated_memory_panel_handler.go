@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	allocatableMemoryAuthCache       sync.Map
-	allocatableMemoryClientCache     sync.Map
+	allocatableMemoryAuthCache       = cache.NewLocal(cache.CredentialCache)
+	allocatableMemoryClientCache     = cache.NewLocal(cache.ClientCache)
 	allocatableMemoryAuthCacheLock   sync.RWMutex
 	allocatableMemoryClientCacheLock sync.RWMutex
 )
@@ -66,7 +68,7 @@ func GetEKSAllocatableMemoryPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := allocatedCloudwatchClientMemoryCache(*clientAuth)
+	cloudwatchClient, err := allocatedCloudwatchClientMemoryCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -89,7 +91,7 @@ func GetEKSAllocatableMemoryPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 			if err != nil {
@@ -141,17 +143,17 @@ func allocatedAuthenticateMemoryAndCache(commandParam model.CommandParam) (*mode
 }
 
 // cloudwatchClientCache caches cloudwatch client
-func allocatedCloudwatchClientMemoryCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func allocatedCloudwatchClientMemoryCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	allocatableMemoryClientCacheLock.Lock()
 	defer allocatableMemoryClientCacheLock.Unlock()
 
 	if client, ok := allocatableMemoryClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	allocatableMemoryClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
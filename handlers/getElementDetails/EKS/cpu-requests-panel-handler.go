@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	cpuRequestsAuthCache       sync.Map
-	cpuRequestsClientCache     sync.Map
+	cpuRequestsAuthCache       = cache.NewLocal(cache.CredentialCache)
+	cpuRequestsClientCache     = cache.NewLocal(cache.ClientCache)
 	cpuRequestsAuthCacheLock   sync.RWMutex
 	cpuRequestsClientCacheLock sync.RWMutex
 )
@@ -66,7 +68,7 @@ func GetEKSCPURequestsPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := cpuRequestsCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := cpuRequestsCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -88,7 +90,7 @@ func GetEKSCPURequestsPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
@@ -140,17 +142,17 @@ func cpuRequestsAuthenticateAndCache(commandParam model.CommandParam) (*model.Au
 }
 
 // cpuRequestsCloudwatchClientCache caches cloudwatch client for CPU requests
-func cpuRequestsCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cpuRequestsCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	cpuRequestsClientCacheLock.Lock()
 	defer cpuRequestsClientCacheLock.Unlock()
 
 	if client, ok := cpuRequestsClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	cpuRequestsClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
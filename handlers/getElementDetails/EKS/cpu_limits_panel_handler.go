@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	cpuLimitsAuthCache       sync.Map
-	cpuLimitsClientCache     sync.Map
+	cpuLimitsAuthCache       = cache.NewLocal(cache.CredentialCache)
+	cpuLimitsClientCache     = cache.NewLocal(cache.ClientCache)
 	cpuLimitsAuthCacheLock   sync.RWMutex
 	cpuLimitsClientCacheLock sync.RWMutex
 )
@@ -66,7 +68,7 @@ func GetEKSCPULimitsPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := cpuLimitsCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := cpuLimitsCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -89,7 +91,7 @@ func GetEKSCPULimitsPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		//fmt.Println(cloudwatchMetricData)
 
@@ -144,17 +146,17 @@ func cpuLimitsAuthenticateAndCache(commandParam model.CommandParam) (*model.Auth
 }
 
 // cpuLimitsCloudwatchClientCache caches cloudwatch client for CPU limits
-func cpuLimitsCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cpuLimitsCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	cpuLimitsClientCacheLock.Lock()
 	defer cpuLimitsClientCacheLock.Unlock()
 
 	if client, ok := cpuLimitsClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	cpuLimitsClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
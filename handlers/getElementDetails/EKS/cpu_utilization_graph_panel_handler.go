@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	cpuUtilizationAuthCache       sync.Map
-	cpuUtilizationClientCache     sync.Map
+	cpuUtilizationAuthCache       = cache.NewLocal(cache.CredentialCache)
+	cpuUtilizationClientCache     = cache.NewLocal(cache.ClientCache)
 	cpuUtilizationAuthCacheLock   sync.RWMutex
 	cpuUtilizationClientCacheLock sync.RWMutex
 )
@@ -66,7 +68,7 @@ func GetEKSCPUUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := cpuUtilizationCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := cpuUtilizationCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -88,7 +90,7 @@ func GetEKSCPUUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
@@ -141,17 +143,17 @@ func cpuUtilizationAuthenticateAndCache(commandParam model.CommandParam) (*model
 }
 
 // cpuUtilizationCloudwatchClientCache caches cloudwatch client for CPU utilization
-func cpuUtilizationCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cpuUtilizationCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	cpuUtilizationClientCacheLock.Lock()
 	defer cpuUtilizationClientCacheLock.Unlock()
 
 	if client, ok := cpuUtilizationClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	cpuUtilizationClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
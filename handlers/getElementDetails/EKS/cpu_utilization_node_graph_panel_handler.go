@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	cpuUtilizationNodeAuthCache       sync.Map
-	cpuUtilizationNodeClientCache     sync.Map
+	cpuUtilizationNodeAuthCache       = cache.NewLocal(cache.CredentialCache)
+	cpuUtilizationNodeClientCache     = cache.NewLocal(cache.ClientCache)
 	cpuUtilizationNodeAuthCacheLock   sync.RWMutex
 	cpuUtilizationNodeClientCacheLock sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetEKSCPUUtilizationNodeGraphPanel(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	cloudwatchClient, err := cpuUtilizationNodeCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := cpuUtilizationNodeCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -83,7 +85,7 @@ func GetEKSCPUUtilizationNodeGraphPanel(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		log.Infof("response type: %s", responseType)
+		log.FromContext(r.Context()).Infof("response type: %s", responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
@@ -133,17 +135,17 @@ func cpuUtilizationNodeAuthenticateAndCache(commandParam model.CommandParam) (*m
 	return clientAuth, nil
 }
 
-func cpuUtilizationNodeCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cpuUtilizationNodeCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	cpuUtilizationNodeClientCacheLock.Lock()
 	defer cpuUtilizationNodeClientCacheLock.Unlock()
 
 	if client, ok := cpuUtilizationNodeClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	cpuUtilizationNodeClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	authCacheDiskIo       sync.Map
-	clientCacheDiskIo     sync.Map
+	authCacheDiskIo       = cache.NewLocal(cache.CredentialCache)
+	clientCacheDiskIo     = cache.NewLocal(cache.ClientCache)
 	authCacheLockDiskIo   sync.RWMutex
 	clientCacheLockDiskIo sync.RWMutex
 )
@@ -65,7 +67,7 @@ func GetEKSDiskIoPerformancePanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := cloudwatchClientCacheDiskIo(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheDiskIo(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -87,7 +89,7 @@ func GetEKSDiskIoPerformancePanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
@@ -139,17 +141,17 @@ func authenticateAndCacheDiskIo(commandParam model.CommandParam) (*model.Auth, e
 }
 
 // cloudwatchClientCacheDiskIo caches cloudwatch client for Disk Utilization
-func cloudwatchClientCacheDiskIo(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheDiskIo(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockDiskIo.Lock()
 	defer clientCacheLockDiskIo.Unlock()
 
 	if client, ok := clientCacheDiskIo.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	clientCacheDiskIo.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	authCacheDisk       sync.Map
-	clientCacheDisk     sync.Map
+	authCacheDisk       = cache.NewLocal(cache.CredentialCache)
+	clientCacheDisk     = cache.NewLocal(cache.ClientCache)
 	authCacheLockDisk   sync.RWMutex
 	clientCacheLockDisk sync.RWMutex
 )
@@ -66,7 +68,7 @@ func GetEKSDiskUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := cloudwatchClientCacheDisk(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheDisk(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -89,7 +91,7 @@ func GetEKSDiskUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		//fmt.Println(cloudwatchMetricData)
 
@@ -144,17 +146,17 @@ func authenticateAndCacheDisk(commandParam model.CommandParam) (*model.Auth, err
 }
 
 // diskutilizationcloudwatchClientCache caches cloudwatch client for Disk Utilization
-func cloudwatchClientCacheDisk(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheDisk(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockDisk.Lock()
 	defer clientCacheLockDisk.Unlock()
 
 	if client, ok := clientCacheDisk.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	clientCacheDisk.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
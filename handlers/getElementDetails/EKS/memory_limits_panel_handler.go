@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	memLimitsAuthCache       sync.Map
-	memLimitsClientCache     sync.Map
+	memLimitsAuthCache       = cache.NewLocal(cache.CredentialCache)
+	memLimitsClientCache     = cache.NewLocal(cache.ClientCache)
 	memLimitsAuthCacheLock   sync.RWMutex
 	memLimitsClientCacheLock sync.RWMutex
 )
@@ -66,7 +68,7 @@ func GetEKSMemoryLimitsPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := memLimitsCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := memLimitsCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -88,7 +90,7 @@ func GetEKSMemoryLimitsPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
@@ -140,17 +142,17 @@ func memLimitsAuthenticateAndCache(commandParam model.CommandParam) (*model.Auth
 }
 
 // memLimitsCloudwatchClientCache caches cloudwatch client for memory limits
-func memLimitsCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func memLimitsCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	memLimitsClientCacheLock.Lock()
 	defer memLimitsClientCacheLock.Unlock()
 
 	if client, ok := memLimitsClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	memLimitsClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	memRequestAuthCache       sync.Map
-	memRequestClientCache     sync.Map
+	memRequestAuthCache       = cache.NewLocal(cache.CredentialCache)
+	memRequestClientCache     = cache.NewLocal(cache.ClientCache)
 	memRequestAuthCacheLock   sync.RWMutex
 	memRequestClientCacheLock sync.RWMutex
 )
@@ -66,7 +68,7 @@ func GetEKSMemoryRequestPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := memRequestCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := memRequestCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -88,7 +90,7 @@ func GetEKSMemoryRequestPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
@@ -141,17 +143,17 @@ func memRequestAuthenticateAndCache(commandParam model.CommandParam) (*model.Aut
 }
 
 // memRequestCloudwatchClientCache caches cloudwatch client for memory request
-func memRequestCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func memRequestCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	memRequestClientCacheLock.Lock()
 	defer memRequestClientCacheLock.Unlock()
 
 	if client, ok := memRequestClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	memRequestClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	memUsageAuthCache       sync.Map
-	memUsageClientCache     sync.Map
+	memUsageAuthCache       = cache.NewLocal(cache.CredentialCache)
+	memUsageClientCache     = cache.NewLocal(cache.ClientCache)
 	memUsageAuthCacheLock   sync.RWMutex
 	memUsageClientCacheLock sync.RWMutex
 )
@@ -66,7 +68,7 @@ func GetEKSMemoryUsagePanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := memUsageCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := memUsageCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -88,7 +90,7 @@ func GetEKSMemoryUsagePanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
@@ -141,17 +143,17 @@ func memUsageAuthenticateAndCache(commandParam model.CommandParam) (*model.Auth,
 }
 
 // memUsageCloudwatchClientCache caches cloudwatch client for memory usage
-func memUsageCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func memUsageCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	memUsageClientCacheLock.Lock()
 	defer memUsageClientCacheLock.Unlock()
 
 	if client, ok := memUsageClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	memUsageClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
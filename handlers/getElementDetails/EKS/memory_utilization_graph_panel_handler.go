@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	memUtilAuthCache       sync.Map
-	memUtilClientCache     sync.Map
+	memUtilAuthCache       = cache.NewLocal(cache.CredentialCache)
+	memUtilClientCache     = cache.NewLocal(cache.ClientCache)
 	memUtilAuthCacheLock   sync.RWMutex
 	memUtilClientCacheLock sync.RWMutex
 )
@@ -67,7 +69,7 @@ func GetEKSMemoryUtilizationGraphPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := memUtilCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := memUtilCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -90,7 +92,7 @@ func GetEKSMemoryUtilizationGraphPanel(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		log.Infof("response type: %s", responseType)
+		log.FromContext(r.Context()).Infof("response type: %s", responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(memoryUtilData)
@@ -143,17 +145,17 @@ func memUtilAuthenticateAndCache(commandParam model.CommandParam) (*model.Auth,
 }
 
 // memUtilCloudwatchClientCache caches cloudwatch client for memory utilization
-func memUtilCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func memUtilCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	memUtilClientCacheLock.Lock()
 	defer memUtilClientCacheLock.Unlock()
 
 	if client, ok := memUtilClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	memUtilClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -16,8 +18,8 @@ import (
 )
 
 var (
-	netAuthCache       sync.Map
-	netClientCache     sync.Map
+	netAuthCache       = cache.NewLocal(cache.CredentialCache)
+	netClientCache     = cache.NewLocal(cache.ClientCache)
 	netAuthCacheLock   sync.RWMutex
 	netClientCacheLock sync.RWMutex
 )
@@ -55,7 +57,7 @@ func GetEKSNetworkUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := netCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := netCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -76,10 +78,10 @@ func GetEKSNetworkUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		log.Infof("response type: %s", responseType)
+		log.FromContext(r.Context()).Infof("response type: %s", responseType)
 
 		if responseType == "frame" {
-			log.Infof("creating response frame")
+			log.FromContext(r.Context()).Infof("creating response frame")
 
 			if filter == "InboundTraffic" {
 				err = json.NewEncoder(w).Encode(cloudwatchMetricData["InboundTraffic"])
@@ -96,7 +98,7 @@ func GetEKSNetworkUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		} else {
-			log.Infof("creating response json")
+			log.FromContext(r.Context()).Infof("creating response json")
 
 			type NetworkResult struct {
 				InboundTraffic  float64 `json:"InboundTraffic"`
@@ -148,7 +150,7 @@ func netAuthenticateAndCache(commandParam model.CommandParam) (*model.Auth, erro
 	return clientAuth, nil
 }
 
-func netCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func netCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	netClientCacheLock.Lock()
@@ -156,12 +158,12 @@ func netCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, er
 
 	if client, ok := netClientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	netClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,6 +1,8 @@
 package EKS
 
 import (
+	"awsx-api/cache"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/Appkube-awsx/awsx-common/authenticate"
@@ -14,8 +16,8 @@ import (
 )
 
 var (
-	networkAuthCache       sync.Map
-	networkClientCache     sync.Map
+	networkAuthCache       = cache.NewLocal(cache.CredentialCache)
+	networkClientCache     = cache.NewLocal(cache.ClientCache)
 	networkAuthCacheLock   sync.RWMutex
 	networkClientCacheLock sync.RWMutex
 )
@@ -61,7 +63,7 @@ func GetEKSNetworkAvailabilityPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := networkCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := networkCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -141,19 +143,19 @@ func networkAuthenticateAndCache(commandParam model.CommandParam) (*model.Auth,
 }
 
 // networkCloudwatchClientCache caches cloudwatch client for network availability
-func networkCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func networkCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	networkClientCacheLock.Lock()
 	defer networkClientCacheLock.Unlock()
 
 	if client, ok := networkClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	networkClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
 func getTimestampsNodeDataNetwork(data []NodeDataNetwork) []string {
 	timestamps := make([]string, len(data))
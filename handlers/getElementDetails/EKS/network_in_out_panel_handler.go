@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	networkInOutAuthCache       sync.Map
-	networkInOutClientCache     sync.Map
+	networkInOutAuthCache       = cache.NewLocal(cache.CredentialCache)
+	networkInOutClientCache     = cache.NewLocal(cache.ClientCache)
 	networkInOutAuthCacheLock   sync.RWMutex
 	networkInOutClientCacheLock sync.RWMutex
 )
@@ -67,7 +69,7 @@ func GetEKSNetworkInOutPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := networkInOutCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := networkInOutCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -90,7 +92,7 @@ func GetEKSNetworkInOutPanel(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		log.Infof("response type: %s", responseType)
+		log.FromContext(r.Context()).Infof("response type: %s", responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(networkInOutData)
@@ -142,17 +144,17 @@ func networkInOutAuthenticateAndCache(commandParam model.CommandParam) (*model.A
 }
 
 // networkInOutCloudwatchClientCache caches cloudwatch client for network in/out panel
-func networkInOutCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func networkInOutCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	networkInOutClientCacheLock.Lock()
 	defer networkInOutClientCacheLock.Unlock()
 
 	if client, ok := networkInOutClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	networkInOutClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
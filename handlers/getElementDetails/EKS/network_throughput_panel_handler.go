@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,8 +19,8 @@ import (
 )
 
 var (
-	networkThroughputAuthCache       sync.Map
-	networkThroughputClientCache     sync.Map
+	networkThroughputAuthCache       = cache.NewLocal(cache.CredentialCache)
+	networkThroughputClientCache     = cache.NewLocal(cache.ClientCache)
 	networkThroughputAuthCacheLock   sync.RWMutex
 	networkThroughputClientCacheLock sync.RWMutex
 )
@@ -68,7 +70,7 @@ func GetEKSNeworkThroughputPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := networkThroughputCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := networkThroughputCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -89,7 +91,7 @@ func GetEKSNeworkThroughputPanel(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		log.Infof("response type: %s", responseType)
+		log.FromContext(r.Context()).Infof("response type: %s", responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(networkThroughputData)
@@ -141,17 +143,17 @@ func networkThroughputAuthenticateAndCache(commandParam model.CommandParam) (*mo
 }
 
 // networkThroughputCloudwatchClientCache caches cloudwatch client for network throughput
-func networkThroughputCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func networkThroughputCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	networkThroughputClientCacheLock.Lock()
 	defer networkThroughputClientCacheLock.Unlock()
 
 	if client, ok := networkThroughputClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	networkThroughputClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
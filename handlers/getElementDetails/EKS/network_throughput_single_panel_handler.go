@@ -1,6 +1,8 @@
 package EKS
 
 import (
+	"awsx-api/cache"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/Appkube-awsx/awsx-common/authenticate"
@@ -14,8 +16,8 @@ import (
 )
 
 var (
-	networkThroughputSingleAuthCache       sync.Map
-	networkThroughputSingleClientCache     sync.Map
+	networkThroughputSingleAuthCache       = cache.NewLocal(cache.CredentialCache)
+	networkThroughputSingleClientCache     = cache.NewLocal(cache.ClientCache)
 	networkThroughputSingleAuthCacheLock   sync.RWMutex
 	networkThroughputSingleClientCacheLock sync.RWMutex
 )
@@ -83,7 +85,7 @@ func GetNetworkThroughputSinglePanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := networkThroughputSingleCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := networkThroughputSingleCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -142,19 +144,19 @@ func networkThroughputSingleAuthenticateAndCache(commandParam model.CommandParam
 }
 
 // networkThroughputSingleCloudwatchClientCache caches cloudwatch client for network throughput single panel
-func networkThroughputSingleCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func networkThroughputSingleCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	networkThroughputSingleClientCacheLock.Lock()
 	defer networkThroughputSingleClientCacheLock.Unlock()
 
 	if client, ok := networkThroughputSingleClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	networkThroughputSingleClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
 func transformResponse(jsonString *cloudwatch.GetMetricDataOutput) ([]byte, error) {
 
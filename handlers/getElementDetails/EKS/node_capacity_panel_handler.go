@@ -1,6 +1,8 @@
 package EKS
 
 import (
+	"awsx-api/cache"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -28,8 +30,8 @@ type NodeCapacityPanel struct {
 }
 
 var (
-	capacityAuthCache       sync.Map
-	capacityClientCache     sync.Map
+	capacityAuthCache       = cache.NewLocal(cache.CredentialCache)
+	capacityClientCache     = cache.NewLocal(cache.ClientCache)
 	capacityAuthCacheLock   sync.RWMutex
 	capacityClientCacheLock sync.RWMutex
 )
@@ -70,7 +72,7 @@ func GetEKSNodeCapacityPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudWatchClient, err := capacityCloudwatchClientCache(*clientAuth)
+	cloudWatchClient, err := capacityCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("CloudWatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -129,19 +131,19 @@ func capacityAuthenticateAndCache(commandParam model.CommandParam) (*model.Auth,
 }
 
 // capacityCloudwatchClientCache caches CloudWatch client
-func capacityCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func capacityCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	capacityClientCacheLock.Lock()
 	defer capacityClientCacheLock.Unlock()
 
 	if client, ok := capacityClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	capacityClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
 
 // package EKS
@@ -207,7 +209,7 @@ func capacityCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatc
 // 		return
 // 	}
 
-// 	cloudwatchClient, err := cloudwatchClientCache(*clientAuth)
+// 	cloudwatchClient, err := cloudwatchClientCache(r.Context(), *clientAuth)
 // 	if err != nil {
 // 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 // 		return
@@ -280,7 +282,7 @@ func capacityCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatc
 //	return clientAuth, nil
 //}
 //
-//func cloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+//func cloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 //	cacheKey := clientAuth.CrossAccountRoleArn
 //
 //	nodeCapacityClientMutex.Lock()
@@ -288,14 +290,14 @@ func capacityCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatc
 //
 //	if client, ok := nodeCapacityClientCache.Load(cacheKey); ok {
 //		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-//		return client.(*cloudwatch.CloudWatch), nil
+//		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 //	}
 //
 //	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
 //	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 //
 //	nodeCapacityClientCache.Store(cacheKey, cloudWatchClient)
-//	return cloudWatchClient, nil
+//	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 //}
 
 func GetNodeCapacityPanel(cmd *cobra.Command, clientAuth *model.Auth, cloudWatchClient *cloudwatch.CloudWatch) (string, map[string]*cloudwatch.GetMetricDataOutput, error) {
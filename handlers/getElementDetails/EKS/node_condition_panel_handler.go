@@ -1,6 +1,8 @@
 package EKS
 
 import (
+	"awsx-api/cache"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -22,8 +24,8 @@ type NodeConditionPanel struct {
 }
 
 var (
-	nodeConditionAuthCache   sync.Map
-	nodeConditionClientCache sync.Map
+	nodeConditionAuthCache   = cache.NewLocal(cache.CredentialCache)
+	nodeConditionClientCache = cache.NewLocal(cache.ClientCache)
 	nodeConditionAuthMutex   sync.RWMutex
 	nodeConditionClientMutex sync.RWMutex
 )
@@ -124,17 +126,17 @@ func nodeConditionAuthenticateAndCache(commandParam model.CommandParam) (*model.
 }
 
 // nodeConditionCloudwatchClientCache caches CloudWatch client
-func nodeConditionCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func nodeConditionCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	nodeConditionClientMutex.Lock()
 	defer nodeConditionClientMutex.Unlock()
 
 	if client, ok := nodeConditionClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	nodeConditionClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
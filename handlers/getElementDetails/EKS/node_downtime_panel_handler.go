@@ -1,6 +1,8 @@
 package EKS
 
 import (
+	"awsx-api/cache"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/Appkube-awsx/awsx-common/authenticate"
@@ -19,8 +21,8 @@ type NodeDownData struct {
 }
 
 var (
-	downtimeAuthCache       sync.Map
-	downtimeClientCache     sync.Map
+	downtimeAuthCache       = cache.NewLocal(cache.CredentialCache)
+	downtimeClientCache     = cache.NewLocal(cache.ClientCache)
 	downtimeAuthCacheLock   sync.RWMutex
 	downtimeClientCacheLock sync.RWMutex
 )
@@ -61,7 +63,7 @@ func GetEKSDowntimePanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudWatchClient, err := downtimeCloudwatchClientCache(*clientAuth)
+	cloudWatchClient, err := downtimeCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("CloudWatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -142,19 +144,19 @@ func downtimeAuthenticateAndCache(commandParam model.CommandParam) (*model.Auth,
 }
 
 // downtimeCloudwatchClientCache caches CloudWatch client
-func downtimeCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func downtimeCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	downtimeClientCacheLock.Lock()
 	defer downtimeClientCacheLock.Unlock()
 
 	if client, ok := downtimeClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	downtimeClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
 
 func getTimestampNodeDowntimePanel(data []NodeDownData) []string {
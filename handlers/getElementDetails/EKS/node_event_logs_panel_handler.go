@@ -1,6 +1,8 @@
 package EKS
 
 import (
+	"awsx-api/cache"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,8 +25,8 @@ type NodeEventLog struct {
 }
 
 var (
-	eventLogsAuthCache       sync.Map
-	eventLogsClientCache     sync.Map
+	eventLogsAuthCache       = cache.NewLocal(cache.CredentialCache)
+	eventLogsClientCache     = cache.NewLocal(cache.ClientCache)
 	eventLogsAuthCacheLock   sync.RWMutex
 	eventLogsClientCacheLock sync.RWMutex
 )
@@ -65,7 +67,7 @@ func GetEKSEventLogsPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudWatchClient, err := eventLogsCloudwatchClientCache(*clientAuth)
+	cloudWatchClient, err := eventLogsCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("CloudWatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -126,17 +128,17 @@ func eventLogsauthenticateAndCache(commandParam model.CommandParam) (*model.Auth
 }
 
 // eventLogsCloudwatchClientCache caches CloudWatch client
-func eventLogsCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func eventLogsCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	eventLogsClientCacheLock.Lock()
 	defer eventLogsClientCacheLock.Unlock()
 
 	if client, ok := eventLogsClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	eventLogsClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type NodeFailureResult struct {
 }
 
 var (
-	nodeFailureAuthCache       sync.Map
-	nodeFailureClientCache     sync.Map
+	nodeFailureAuthCache       = cache.NewLocal(cache.CredentialCache)
+	nodeFailureClientCache     = cache.NewLocal(cache.ClientCache)
 	nodeFailureAuthCacheLock   sync.RWMutex
 	nodeFailureClientCacheLock sync.RWMutex
 )
@@ -64,7 +66,7 @@ func GetNodeFailurePanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := nodeFailureCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := nodeFailureCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -139,7 +141,7 @@ func authenticatAndCache(commandParam model.CommandParam) (*model.Auth, error) {
 	return clientAuth, nil
 }
 
-func nodeFailureCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func nodeFailureCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	nodeFailureClientCacheLock.Lock()
@@ -147,13 +149,13 @@ func nodeFailureCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudW
 
 	if client, ok := nodeFailureClientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	nodeFailureClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type NodeStabilityResult struct {
 }
 
 var (
-	nodeStabilityAuthCache       sync.Map
-	nodeStabilityClientCache     sync.Map
+	nodeStabilityAuthCache       = cache.NewLocal(cache.CredentialCache)
+	nodeStabilityClientCache     = cache.NewLocal(cache.ClientCache)
 	nodeStabilityAuthCacheLock   sync.RWMutex
 	nodeStabilityClientCacheLock sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetNodeStabilityIndexPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheForNodeStability(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheForNodeStability(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -83,7 +85,7 @@ func GetNodeStabilityIndexPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("Response type: %s", responseType)
+		log.FromContext(r.Context()).Infof("Response type: %s", responseType)
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 			if err != nil {
@@ -136,7 +138,7 @@ func authenticateAndCacheForNodeStability(commandParam model.CommandParam) (*mod
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheForNodeStability(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheForNodeStability(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	nodeStabilityClientCacheLock.Lock()
@@ -144,13 +146,13 @@ func cloudwatchClientCacheForNodeStability(clientAuth model.Auth) (*cloudwatch.C
 
 	if client, ok := nodeStabilityClientCache.Load(cacheKey); ok {
 		log.Infof("Cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("Creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	nodeStabilityClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
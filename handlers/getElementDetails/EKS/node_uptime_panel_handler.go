@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/Appkube-awsx/awsx-common/authenticate"
@@ -15,8 +17,8 @@ import (
 )
 
 var (
-	nodeUptimeAuthCache       sync.Map
-	nodeUptimeClientCache     sync.Map
+	nodeUptimeAuthCache       = cache.NewLocal(cache.CredentialCache)
+	nodeUptimeClientCache     = cache.NewLocal(cache.ClientCache)
 	nodeUptimeAuthCacheLock   sync.RWMutex
 	nodeUptimeClientCacheLock sync.RWMutex
 )
@@ -62,7 +64,7 @@ func NodeUptimePanelHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get CloudWatch client
-	cloudwatchClient, err := nodeUptimeCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := nodeUptimeCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -84,7 +86,7 @@ func NodeUptimePanelHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type: %s", responseType)
+		log.FromContext(r.Context()).Infof("response type: %s", responseType)
 
 		var data []NodeData
 		if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
@@ -148,19 +150,19 @@ func nodeUptimeAuthenticateAndCache(commandParam model.CommandParam) (*model.Aut
 }
 
 // nodeUptimeCloudwatchClientCache caches cloudwatch client for node uptime panel
-func nodeUptimeCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func nodeUptimeCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	nodeUptimeClientCacheLock.Lock()
 	defer nodeUptimeClientCacheLock.Unlock()
 
 	if client, ok := nodeUptimeClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	nodeUptimeClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
 func getTimestamps(data []NodeData) []string {
 	timestamps := make([]string, len(data))
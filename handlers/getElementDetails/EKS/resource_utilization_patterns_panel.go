@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type ResourceUtilizationResult struct {
 }
 
 var (
-	resourceAuthCache       sync.Map
-	resourceClientCache     sync.Map
+	resourceAuthCache       = cache.NewLocal(cache.CredentialCache)
+	resourceClientCache     = cache.NewLocal(cache.ClientCache)
 	resourceAuthCacheLock   sync.RWMutex
 	resourceClientCacheLock sync.RWMutex
 )
@@ -61,14 +63,14 @@ func GetResourceUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 	clientAuth, err := authenticateAndCacher(commandParam)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
-		log.Errorf("Authentication failed: %v", err)
+		log.FromContext(r.Context()).Errorf("Authentication failed: %v", err)
 		return
 	}
 
-	cloudwatchClient, err := resourceCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := resourceCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
-		log.Errorf("Cloudwatch client creation/store in cache failed: %v", err)
+		log.FromContext(r.Context()).Errorf("Cloudwatch client creation/store in cache failed: %v", err)
 		return
 	}
 
@@ -84,7 +86,7 @@ func GetResourceUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 		jsonString, cloudwatchMetricData, err := EKS.GetResourceUtilizationData(cmd, clientAuth, cloudwatchClient)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
-			log.Errorf("Exception: %v", err)
+			log.FromContext(r.Context()).Errorf("Exception: %v", err)
 			return
 		}
 
@@ -92,7 +94,7 @@ func GetResourceUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
-				log.Errorf("Exception: %v", err)
+				log.FromContext(r.Context()).Errorf("Exception: %v", err)
 				return
 			}
 		} else {
@@ -100,7 +102,7 @@ func GetResourceUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 			err := json.Unmarshal([]byte(jsonString), &data)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
-				log.Errorf("Exception: %v", err)
+				log.FromContext(r.Context()).Errorf("Exception: %v", err)
 				return
 			}
 
@@ -108,7 +110,7 @@ func GetResourceUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 			jsonBytes, err := json.Marshal(data)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
-				log.Errorf("Exception: %v", err)
+				log.FromContext(r.Context()).Errorf("Exception: %v", err)
 				return
 			}
 
@@ -117,7 +119,7 @@ func GetResourceUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 			_, err = w.Write(jsonBytes)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
-				log.Errorf("Exception: %v", err)
+				log.FromContext(r.Context()).Errorf("Exception: %v", err)
 				return
 			}
 		}
@@ -146,7 +148,7 @@ func authenticateAndCacher(commandParam model.CommandParam) (*model.Auth, error)
 	return clientAuth, nil
 }
 
-func resourceCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func resourceCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	resourceClientCacheLock.Lock()
@@ -154,13 +156,13 @@ func resourceCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatc
 
 	if client, ok := resourceClientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	resourceClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
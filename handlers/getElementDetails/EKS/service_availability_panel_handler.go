@@ -1,7 +1,9 @@
 package EKS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/Appkube-awsx/awsx-common/authenticate"
@@ -15,8 +17,8 @@ import (
 )
 
 var (
-	serviceAuthCache       sync.Map
-	serviceClientCache     sync.Map
+	serviceAuthCache       = cache.NewLocal(cache.CredentialCache)
+	serviceClientCache     = cache.NewLocal(cache.ClientCache)
 	serviceAuthCacheLock   sync.RWMutex
 	serviceClientCacheLock sync.RWMutex
 )
@@ -58,7 +60,7 @@ func GetEKSServiceAvailabilityPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := serviceCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := serviceCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -78,7 +80,7 @@ func GetEKSServiceAvailabilityPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type: %s", responseType)
+		log.FromContext(r.Context()).Infof("response type: %s", responseType)
 
 		var data []ServiceTimeSeriesDataPoint
 		if err := json.Unmarshal([]byte(jsonString), &data); err != nil {
@@ -140,19 +142,19 @@ func serviceAuthenticateAndCache(commandParam model.CommandParam) (*model.Auth,
 	return clientAuth, nil
 }
 
-func serviceCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func serviceCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	serviceClientCacheLock.Lock()
 	defer serviceClientCacheLock.Unlock()
 
 	if client, ok := serviceClientCache.Load(cacheKey); ok {
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 	serviceClientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
 func getTimestampsService(data []ServiceTimeSeriesDataPoint) []string {
 	timestamps := make([]string, len(data))
@@ -54,7 +54,7 @@ func GetColdStartDurationPanel(w http.ResponseWriter, r *http.Request) {
 		commandParam.Region = region
 	}
 
-	clientAuth, awsClient, err := cache.GetAwsCredsAndClient(commandParam, awsclient.CLOUDWATCH)
+	clientAuth, awsClient, err := cache.GetAwsCredsAndClient(r.Context(), commandParam, awsclient.CLOUDWATCH)
 
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
@@ -76,16 +76,16 @@ func GetColdStartDurationPanel(w http.ResponseWriter, r *http.Request) {
 		// 	return
 		// }
 		if err != nil {
-			log.Infof("error found in GetColdStartDurationPanel: ", err)
+			log.FromContext(r.Context()).Infof("error found in GetColdStartDurationPanel: %v", err)
 			var awsErr awserr.Error
 			if errors.As(err, &awsErr) {
 				if awsErr.Code() == "ExpiredToken" {
-					log.Infof("aws session expired. resetting connection cache")
-					clientAuth, awsClient, err = cache.SetAwsCredsAndClientInCache(commandParam, awsclient.CLOUDWATCH)
+					log.FromContext(r.Context()).Infof("aws session expired. resetting connection cache")
+					clientAuth, awsClient, err = cache.SetAwsCredsAndClientInCache(r.Context(), commandParam, awsclient.CLOUDWATCH)
 				}
 			}
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
@@ -1,7 +1,9 @@
 package Lambda
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,8 +25,8 @@ type ExecutionTimeData struct {
 }
 
 var (
-	authCache       sync.Map
-	clientCache     sync.Map
+	authCache       = cache.NewLocal(cache.CredentialCache)
+	clientCache     = cache.NewLocal(cache.ClientCache)
 	authCacheLock   sync.RWMutex
 	clientCacheLock sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetExecutionTimePanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -139,7 +141,7 @@ func authenticateAndCache(commandParam model.CommandParam) (*model.Auth, error)
 	return clientAuth, nil
 }
 
-func cloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLock.Lock()
@@ -147,13 +149,13 @@ func cloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error
 
 	if client, ok := clientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCache.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package Lambda
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -16,8 +18,8 @@ import (
 )
 
 var (
-	authCacheFR       sync.Map
-	clientCacheFR     sync.Map
+	authCacheFR       = cache.NewLocal(cache.CredentialCache)
+	clientCacheFR     = cache.NewLocal(cache.ClientCache)
 	authCacheLockFR   sync.RWMutex
 	clientCacheLockFR sync.RWMutex
 )
@@ -55,7 +57,7 @@ func GetFunctionByRegionPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// cloudwatchClient, err := cloudwatchClientCacheFR(*clientAuth)
+	// cloudwatchClient, err := cloudwatchClientCacheFR(r.Context(), *clientAuth)
 	// if err != nil {
 	// 	http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 	// 	return
@@ -117,7 +119,7 @@ func authenticateAndCacheFR(commandParam model.CommandParam) (*model.Auth, error
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheFR(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheFR(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockFR.Lock()
@@ -125,13 +127,13 @@ func cloudwatchClientCacheFR(clientAuth model.Auth) (*cloudwatch.CloudWatch, err
 
 	if client, ok := clientCacheFR.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheFR.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
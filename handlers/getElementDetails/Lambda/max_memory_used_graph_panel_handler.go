@@ -52,7 +52,7 @@ func GetMaxMemoryUsedPanell(w http.ResponseWriter, r *http.Request) {
 		commandParam.Region = region
 	}
 
-	clientAuth, awsClient, err := cache.GetAwsCredsAndClient(commandParam, awsclient.CLOUDWATCH)
+	clientAuth, awsClient, err := cache.GetAwsCredsAndClient(r.Context(), commandParam, awsclient.CLOUDWATCH)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
@@ -75,16 +75,16 @@ func GetMaxMemoryUsedPanell(w http.ResponseWriter, r *http.Request) {
 
 		jsonString, cloudwatchMetricData, err := Lambda.GetLambdaMaxMemoryGraphData(cmd, clientAuth, cloudwatchClient)
 		if err != nil {
-			log.Infof("error found in GetColdStartDurationPanel: ", err)
+			log.FromContext(r.Context()).Infof("error found in GetColdStartDurationPanel: %v", err)
 			var awsErr awserr.Error
 			if errors.As(err, &awsErr) {
 				if awsErr.Code() == "ExpiredToken" {
-					log.Infof("aws session expired. resetting connection cache")
-					clientAuth, awsClient, err = cache.SetAwsCredsAndClientInCache(commandParam, awsclient.CLOUDWATCH)
+					log.FromContext(r.Context()).Infof("aws session expired. resetting connection cache")
+					clientAuth, awsClient, err = cache.SetAwsCredsAndClientInCache(r.Context(), commandParam, awsclient.CLOUDWATCH)
 				}
 			}
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
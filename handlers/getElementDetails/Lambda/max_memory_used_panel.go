@@ -1,7 +1,9 @@
 package Lambda
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,8 +20,8 @@ import (
 
 // var maxMemauthCache = make(map[string]*model.Auth)
 var (
-	authCacheMax       sync.Map
-	clientCacheMax     sync.Map
+	authCacheMax       = cache.NewLocal(cache.CredentialCache)
+	clientCacheMax     = cache.NewLocal(cache.ClientCache)
 	authCacheMaxLock   sync.RWMutex
 	clientCacheMaxLock sync.RWMutex
 	//authCacheMaxLock sync.Mutex
@@ -60,7 +62,7 @@ func GetMaxMemoryUsedPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := maxMemoryCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := maxMemoryCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -78,23 +80,23 @@ func GetMaxMemoryUsedPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
-			log.Infof("creating response frame")
-			log.Infof("response type :" + responseType)
+			log.FromContext(r.Context()).Infof("creating response frame")
+			log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 			if responseType == "frame" {
 				err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 				if err != nil {
 					http.Error(w, fmt.Sprintf("Exception: %s ", err), http.StatusInternalServerError)
 					//responseType
-					log.Infof("Response type: %s", responseType)
+					log.FromContext(r.Context()).Infof("Response type: %s", responseType)
 					return
 				}
 			}
 		} else {
-			log.Infof("creating response json")
+			log.FromContext(r.Context()).Infof("creating response json")
 			type MaxMemoryData struct {
 				FunctionName string
 				MemoryUnit   float64
@@ -156,22 +158,22 @@ func maxMemoryauthenticateAndCache(commandParam model.CommandParam) (*model.Auth
 	return clientAuth, nil
 }
 
-func maxMemoryCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func maxMemoryCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheMaxLock.Lock()
 	if client, ok := clientCacheMax.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
 		clientCacheMaxLock.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheMax.Store(cacheKey, cloudWatchClient)
 	clientCacheMaxLock.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
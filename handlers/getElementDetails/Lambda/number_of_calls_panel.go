@@ -1,7 +1,9 @@
 package Lambda
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type NumberOfCallsResult struct {
 }
 
 var (
-	authCacheCalls       sync.Map
-	clientCacheCalls     sync.Map
+	authCacheCalls       = cache.NewLocal(cache.CredentialCache)
+	clientCacheCalls     = cache.NewLocal(cache.ClientCache)
 	authCacheLockCalls   sync.RWMutex
 	clientCacheLockCalls sync.RWMutex
 )
@@ -64,7 +66,7 @@ func GetNumberOfCallsPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheCalls(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheCalls(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -139,7 +141,7 @@ func authenticateAndCacheCalls(commandParam model.CommandParam) (*model.Auth, er
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheCalls(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheCalls(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockCalls.Lock()
@@ -147,13 +149,13 @@ func cloudwatchClientCacheCalls(clientAuth model.Auth) (*cloudwatch.CloudWatch,
 
 	if client, ok := clientCacheCalls.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheCalls.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package Lambda
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -22,8 +24,8 @@ type SuccessFailureResult struct {
 }
 
 var (
-	authCacheSuccessFailed       sync.Map
-	clientCacheSuccessFailed     sync.Map
+	authCacheSuccessFailed       = cache.NewLocal(cache.CredentialCache)
+	clientCacheSuccessFailed     = cache.NewLocal(cache.ClientCache)
 	authCacheSuccessFailedLock   sync.RWMutex
 	clientCacheSuccessFailedLock sync.RWMutex
 )
@@ -61,7 +63,7 @@ func GetSuccessAndFailedFunctionPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheFunction(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheFunction(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -135,7 +137,7 @@ func authenticateAndCacheFunction(commandParam model.CommandParam) (*model.Auth,
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheFunction(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheFunction(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheSuccessFailedLock.Lock()
@@ -143,13 +145,13 @@ func cloudwatchClientCacheFunction(clientAuth model.Auth) (*cloudwatch.CloudWatc
 
 	if client, ok := clientCacheSuccessFailed.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheSuccessFailed.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package Lambda
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,8 +26,8 @@ type ThrottleData struct {
 }
 
 var (
-	authCacheThrottle       sync.Map
-	clientCacheThrottle     sync.Map
+	authCacheThrottle       = cache.NewLocal(cache.CredentialCache)
+	clientCacheThrottle     = cache.NewLocal(cache.ClientCache)
 	authCacheLockThrottle   sync.RWMutex
 	clientCacheLockThrottle sync.RWMutex
 )
@@ -63,7 +65,7 @@ func GetThrottlesPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheThrottle(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheThrottle(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -130,7 +132,7 @@ func authenticateAndCacheThrottle(commandParam model.CommandParam) (*model.Auth,
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheThrottle(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheThrottle(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockThrottle.Lock()
@@ -138,13 +140,13 @@ func cloudwatchClientCacheThrottle(clientAuth model.Auth) (*cloudwatch.CloudWatc
 
 	if client, ok := clientCacheThrottle.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheThrottle.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
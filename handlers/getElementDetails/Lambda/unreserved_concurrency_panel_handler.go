@@ -45,7 +45,7 @@ func GetUnreservedConcurrencyPanel(w http.ResponseWriter, r *http.Request) {
 		commandParam.Region = region
 	}
 
-	clientAuth, awsClient, err := cache.GetAwsCredsAndClient(commandParam, awsclient.LAMBDA_CLIENT)
+	clientAuth, awsClient, err := cache.GetAwsCredsAndClient(r.Context(), commandParam, awsclient.LAMBDA_CLIENT)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
@@ -68,16 +68,16 @@ func GetUnreservedConcurrencyPanel(w http.ResponseWriter, r *http.Request) {
 
 		_, cloudwatchMetricData, err := Lambda.GetLambdaUnreservedConcurrencyCommmand(cmd, clientAuth, lambdaClient)
 		if err != nil {
-			log.Infof("error found in GetColdStartDurationPanel: ", err)
+			log.FromContext(r.Context()).Infof("error found in GetColdStartDurationPanel: %v", err)
 			var awsErr awserr.Error
 			if errors.As(err, &awsErr) {
 				if awsErr.Code() == "ExpiredToken" {
-					log.Infof("aws session expired. resetting connection cache")
-					clientAuth, awsClient, err = cache.SetAwsCredsAndClientInCache(commandParam, awsclient.LAMBDA_CLIENT)
+					log.FromContext(r.Context()).Infof("aws session expired. resetting connection cache")
+					clientAuth, awsClient, err = cache.SetAwsCredsAndClientInCache(r.Context(), commandParam, awsclient.LAMBDA_CLIENT)
 				}
 			}
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
@@ -1,7 +1,9 @@
 package Lambda
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,8 +20,8 @@ import (
 
 // var maxMemauthCache = make(map[string]*model.Auth)
 var (
-	unusedMemAuthCache       sync.Map
-	unusedMemclientCache     sync.Map
+	unusedMemAuthCache       = cache.NewLocal(cache.CredentialCache)
+	unusedMemclientCache     = cache.NewLocal(cache.ClientCache)
 	unusedMemAuthCacheLock   sync.RWMutex
 	unusedMemclientCacheLock sync.RWMutex
 	//unusedMemAuthCacheLock sync.Mutex
@@ -60,7 +62,7 @@ func GetUsedAndUnusedMemoryDataPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := usedAndUnusedMemoryCloudwatchClientCache(*clientAuth)
+	cloudwatchClient, err := usedAndUnusedMemoryCloudwatchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -78,24 +80,24 @@ func GetUsedAndUnusedMemoryDataPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
-			log.Infof("creating response frame")
-			log.Infof("response type :" + responseType)
+			log.FromContext(r.Context()).Infof("creating response frame")
+			log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 			if responseType == "frame" {
 				err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 				if err != nil {
 					http.Error(w, fmt.Sprintf("Exception: %s ", err), http.StatusInternalServerError)
 					//responseType
-					log.Infof("Response type: %s", responseType)
+					log.FromContext(r.Context()).Infof("Response type: %s", responseType)
 					return
 				}
 			}
 			//filter
 		} else {
-			log.Infof("creating response json")
+			log.FromContext(r.Context()).Infof("creating response json")
 
 			type UsedAndUnusedMemoryData struct {
 				FunctionName             string
@@ -159,22 +161,22 @@ func usedAndUnusedMemoryAuthenticateAndCache(commandParam model.CommandParam) (*
 	return clientAuth, nil
 }
 
-func usedAndUnusedMemoryCloudwatchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func usedAndUnusedMemoryCloudwatchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	unusedMemclientCacheLock.Lock()
 	if client, ok := unusedMemclientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
 		unusedMemclientCacheLock.Unlock()
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	unusedMemclientCache.Store(cacheKey, cloudWatchClient)
 	unusedMemclientCacheLock.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
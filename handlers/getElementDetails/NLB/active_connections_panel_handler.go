@@ -51,7 +51,7 @@ func GetNLBActiveConnectionsPanel(w http.ResponseWriter, r *http.Request) {
 		commandParam.Region = region
 	}
 
-	clientAuth, awsClient, err := cache.GetAwsCredsAndClient(commandParam, awsclient.CLOUDWATCH)
+	clientAuth, awsClient, err := cache.GetAwsCredsAndClient(r.Context(), commandParam, awsclient.CLOUDWATCH)
 
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
@@ -69,16 +69,16 @@ func GetNLBActiveConnectionsPanel(w http.ResponseWriter, r *http.Request) {
 
 		jsonString, cloudwatchMetricData, err := NLB.GetNLBActiveConnectionsPanel(cmd, clientAuth, cloudwatchClient)
 		if err != nil {
-			log.Infof("error found in GetNLBActiveConnectionsPanel: ", err)
+			log.FromContext(r.Context()).Infof("error found in GetNLBActiveConnectionsPanel: %v", err)
 			var awsErr awserr.Error
 			if errors.As(err, &awsErr) {
 				if awsErr.Code() == "ExpiredToken" {
-					log.Infof("aws session expired. resetting connection cache")
-					clientAuth, awsClient, err = cache.SetAwsCredsAndClientInCache(commandParam, awsclient.CLOUDWATCH)
+					log.FromContext(r.Context()).Infof("aws session expired. resetting connection cache")
+					clientAuth, awsClient, err = cache.SetAwsCredsAndClientInCache(r.Context(), commandParam, awsclient.CLOUDWATCH)
 				}
 			}
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
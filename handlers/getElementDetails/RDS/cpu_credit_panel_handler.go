@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type CpuCreditBalanceResult struct {
 }
 
 var (
-	authCacheRDS       sync.Map
-	clientCacheRDS     sync.Map
+	authCacheRDS       = cache.NewLocal(cache.CredentialCache)
+	clientCacheRDS     = cache.NewLocal(cache.ClientCache)
 	authCacheLockRDS   sync.RWMutex
 	clientCacheLockRDS sync.RWMutex
 )
@@ -64,7 +66,7 @@ func GetCpuCreditBalancePanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheRDS(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheRDS(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -139,7 +141,7 @@ func authenticateAndCacheRDS(commandParam model.CommandParam) (*model.Auth, erro
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheRDS(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheRDS(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockRDS.Lock()
@@ -147,13 +149,13 @@ func cloudwatchClientCacheRDS(clientAuth model.Auth) (*cloudwatch.CloudWatch, er
 
 	if client, ok := clientCacheRDS.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheRDS.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type CPUSurplusCreditBalanceResult struct {
 }
 
 var (
-	authCach       sync.Map
-	clientCach     sync.Map
+	authCach       = cache.NewLocal(cache.CredentialCache)
+	clientCach     = cache.NewLocal(cache.ClientCache)
 	authCacheMutex sync.RWMutex
 	clientCacheMu  sync.RWMutex
 )
@@ -64,7 +66,7 @@ func GetCPUSurplusCreditBalancePanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClietCache(*clientAuth)
+	cloudwatchClient, err := cloudwatchClietCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -140,7 +142,7 @@ func authenticaeAndCache(commandParam model.CommandParam) (*model.Auth, error) {
 	return clientAuth, nil
 }
 
-func cloudwatchClietCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClietCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheMu.Lock()
@@ -148,14 +150,14 @@ func cloudwatchClietCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error)
 
 	if client, ok := clientCach.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCach.Store(cacheKey, cloudWatchClient)
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type DBResult struct {
 }
 
 var (
-	authDatabas       sync.Map
-	clientDatabas     sync.Map
+	authDatabas       = cache.NewLocal(cache.CredentialCache)
+	clientDatabas     = cache.NewLocal(cache.ClientCache)
 	authDatabasLock   sync.RWMutex
 	clientDatabasLock sync.RWMutex
 )
@@ -64,7 +66,7 @@ func GetDatabaseConnectionPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCahe(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCahe(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -139,7 +141,7 @@ func authnticateAndCache(commandParam model.CommandParam) (*model.Auth, error) {
 	return clientAuth, nil
 }
 
-func cloudwatchClientCahe(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCahe(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientDatabasLock.Lock()
@@ -147,13 +149,13 @@ func cloudwatchClientCahe(clientAuth model.Auth) (*cloudwatch.CloudWatch, error)
 
 	if client, ok := clientDatabas.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientDatabas.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
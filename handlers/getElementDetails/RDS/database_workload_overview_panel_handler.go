@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,8 +25,8 @@ type DBWorkloadOverview struct {
 }
 
 var (
-	authDatabasew       sync.Map
-	clientDatabasew     sync.Map
+	authDatabasew       = cache.NewLocal(cache.CredentialCache)
+	clientDatabasew     = cache.NewLocal(cache.ClientCache)
 	authDatabaseLockw   sync.RWMutex
 	clientDatabaseLockw sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetDatabaseWorkloadOverviewPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatcClientCache(*clientAuth)
+	cloudwatchClient, err := cloudwatcClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -137,7 +139,7 @@ func authenticateAdCache(commandParam model.CommandParam) (*model.Auth, error) {
 	return clientAuth, nil
 }
 
-func cloudwatcClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatcClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientDatabaseLockw.Lock()
@@ -145,13 +147,13 @@ func cloudwatcClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error)
 
 	if client, ok := clientDatabasew.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientDatabasew.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -16,8 +18,8 @@ import (
 )
 
 var (
-	authDBLoadCPU       sync.Map
-	clientDBLoadCPU     sync.Map
+	authDBLoadCPU       = cache.NewLocal(cache.CredentialCache)
+	clientDBLoadCPU     = cache.NewLocal(cache.ClientCache)
 	authDBLoadCPULock   sync.RWMutex
 	clientDBLoadCPULock sync.RWMutex
 )
@@ -55,7 +57,7 @@ func GetDBLoadCPULoadPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwtchClientCache(*clientAuth)
+	cloudwatchClient, err := cloudwtchClientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -116,7 +118,7 @@ func authenicateAndCache(commandParam model.CommandParam) (*model.Auth, error) {
 	return clientAuth, nil
 }
 
-func cloudwtchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwtchClientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientDBLoadCPULock.Lock()
@@ -124,13 +126,13 @@ func cloudwtchClientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error)
 
 	if client, ok := clientDBLoadCPU.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientDBLoadCPU.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -16,8 +18,8 @@ import (
 )
 
 var (
-	authDatabasecpu       sync.Map
-	clientDatabasecpu     sync.Map
+	authDatabasecpu       = cache.NewLocal(cache.CredentialCache)
+	clientDatabasecpu     = cache.NewLocal(cache.ClientCache)
 	authDatabaseLockcpu   sync.RWMutex
 	clientDatabaseLockcpu sync.RWMutex
 )
@@ -55,7 +57,7 @@ func GetDBLoadNonCPUPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchlientCache(*clientAuth)
+	cloudwatchClient, err := cloudwatchlientCache(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -130,7 +132,7 @@ func autenticateAndCache(commandParam model.CommandParam) (*model.Auth, error) {
 	return clientAuth, nil
 }
 
-func cloudwatchlientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchlientCache(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientDatabaseLockcpu.Lock()
@@ -138,13 +140,13 @@ func cloudwatchlientCache(clientAuth model.Auth) (*cloudwatch.CloudWatch, error)
 
 	if client, ok := clientDatabasecpu.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientDatabasecpu.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
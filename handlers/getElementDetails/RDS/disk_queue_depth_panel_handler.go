@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,8 +25,8 @@ type DiskQueueDepth struct {
 }
 
 var (
-	authCached       sync.Map
-	clientCached     sync.Map
+	authCached       = cache.NewLocal(cache.CredentialCache)
+	clientCached     = cache.NewLocal(cache.ClientCache)
 	authCacheLockd   sync.RWMutex
 	clientCacheLockd sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetDiskQueueDepthPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCached(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCached(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -130,7 +132,7 @@ func authenticateAndCached(commandParam model.CommandParam) (*model.Auth, error)
 	return clientAuth, nil
 }
 
-func cloudwatchClientCached(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCached(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockd.Lock()
@@ -138,13 +140,13 @@ func cloudwatchClientCached(clientAuth model.Auth) (*cloudwatch.CloudWatch, erro
 
 	if client, ok := clientCached.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCached.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,8 +25,8 @@ type MemoryUsage struct {
 }
 
 var (
-	rdsAuthCache       sync.Map
-	rdsClientCache     sync.Map
+	rdsAuthCache       = cache.NewLocal(cache.CredentialCache)
+	rdsClientCache     = cache.NewLocal(cache.ClientCache)
 	rdsAuthCacheLock   sync.RWMutex
 	rdsClientCacheLock sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetFreeableMemoryPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCace(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCace(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -138,7 +140,7 @@ func authenticateAndCace(commandParam model.CommandParam) (*model.Auth, error) {
 	return clientAuth, nil
 }
 
-func cloudwatchClientCace(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCace(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	rdsClientCacheLock.Lock()
@@ -146,14 +148,14 @@ func cloudwatchClientCace(clientAuth model.Auth) (*cloudwatch.CloudWatch, error)
 
 	if client, ok := rdsClientCache.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	rdsClientCache.Store(cacheKey, cloudWatchClient)
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
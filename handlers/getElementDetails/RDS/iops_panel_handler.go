@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -29,8 +31,8 @@ type IOPS struct {
 }
 
 var (
-	authCacheiops       sync.Map
-	clientCacheiops     sync.Map
+	authCacheiops       = cache.NewLocal(cache.CredentialCache)
+	clientCacheiops     = cache.NewLocal(cache.ClientCache)
 	authCacheLockiops   sync.RWMutex
 	clientCacheLockiops sync.RWMutex
 )
@@ -68,7 +70,7 @@ func GetIOPPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheiops(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheiops(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -143,7 +145,7 @@ func authenticateAndCacheiops(commandParam model.CommandParam) (*model.Auth, err
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheiops(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheiops(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockiops.Lock()
@@ -151,13 +153,13 @@ func cloudwatchClientCacheiops(clientAuth model.Auth) (*cloudwatch.CloudWatch, e
 
 	if client, ok := clientCacheiops.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheiops.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
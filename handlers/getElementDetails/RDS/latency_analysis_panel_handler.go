@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,8 +25,8 @@ type TimeSeriesData struct {
 }
 
 var (
-	authCachel       sync.Map
-	clientCachel     sync.Map
+	authCachel       = cache.NewLocal(cache.CredentialCache)
+	clientCachel     = cache.NewLocal(cache.ClientCache)
 	authCacheLockl   sync.RWMutex
 	clientCacheLockl sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetLatencyAnalysisPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCachel(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCachel(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -137,7 +139,7 @@ func authenticateAndCachel(commandParam model.CommandParam) (*model.Auth, error)
 	return clientAuth, nil
 }
 
-func cloudwatchClientCachel(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCachel(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockl.Lock()
@@ -145,13 +147,13 @@ func cloudwatchClientCachel(clientAuth model.Auth) (*cloudwatch.CloudWatch, erro
 
 	if client, ok := clientCachel.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCachel.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
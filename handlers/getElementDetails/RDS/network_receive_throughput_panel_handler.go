@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,8 +25,8 @@ type NetworkReceiveThroughput struct {
 }
 
 var (
-	authCachenr       sync.Map
-	clientCachenr     sync.Map
+	authCachenr       = cache.NewLocal(cache.CredentialCache)
+	clientCachenr     = cache.NewLocal(cache.ClientCache)
 	authCacheLocknr   sync.RWMutex
 	clientCacheLocknr sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetNetworkReceiveThroughputPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCachenr(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCachenr(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -137,7 +139,7 @@ func authenticateAndCachenr(commandParam model.CommandParam) (*model.Auth, error
 	return clientAuth, nil
 }
 
-func cloudwatchClientCachenr(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCachenr(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLocknr.Lock()
@@ -145,13 +147,13 @@ func cloudwatchClientCachenr(clientAuth model.Auth) (*cloudwatch.CloudWatch, err
 
 	if client, ok := clientCachenr.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCachenr.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
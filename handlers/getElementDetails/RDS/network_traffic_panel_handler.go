@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -28,8 +30,8 @@ type NetworkTraffic struct {
 }
 
 var (
-	authCachetr       sync.Map
-	clientCachetr     sync.Map
+	authCachetr       = cache.NewLocal(cache.CredentialCache)
+	clientCachetr     = cache.NewLocal(cache.ClientCache)
 	authCacheLocktr   sync.RWMutex
 	clientCacheLocktr sync.RWMutex
 )
@@ -67,7 +69,7 @@ func GetNetworkTrafficPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCachetr(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCachetr(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -142,7 +144,7 @@ func authenticateAndCachetr(commandParam model.CommandParam) (*model.Auth, error
 	return clientAuth, nil
 }
 
-func cloudwatchClientCachetr(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCachetr(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLocktr.Lock()
@@ -150,13 +152,13 @@ func cloudwatchClientCachetr(clientAuth model.Auth) (*cloudwatch.CloudWatch, err
 
 	if client, ok := clientCachetr.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCachetr.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
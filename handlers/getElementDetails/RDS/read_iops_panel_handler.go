@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,8 +27,8 @@ type ReadIOPS struct {
 }
 
 var (
-	authCacher       sync.Map
-	clientCacher     sync.Map
+	authCacher       = cache.NewLocal(cache.CredentialCache)
+	clientCacher     = cache.NewLocal(cache.ClientCache)
 	authCacheLockr   sync.RWMutex
 	clientCacheLockr sync.RWMutex
 )
@@ -64,7 +66,7 @@ func GetReadIOPSPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacher(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacher(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -138,7 +140,7 @@ func authenticateAndCacher(commandParam model.CommandParam) (*model.Auth, error)
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacher(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacher(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockr.Lock()
@@ -146,13 +148,13 @@ func cloudwatchClientCacher(clientAuth model.Auth) (*cloudwatch.CloudWatch, erro
 
 	if client, ok := clientCacher.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacher.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
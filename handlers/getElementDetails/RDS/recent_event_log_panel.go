@@ -1,9 +1,11 @@
 package RDS
 
 import (
+	"awsx-api/cache"
+	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"sync"
 
@@ -26,8 +28,8 @@ type RecentEventLogEntry struct {
 }
 
 var (
-	authCacheEvent       sync.Map
-	clientCacheEvent     sync.Map
+	authCacheEvent       = cache.NewLocal(cache.CredentialCache)
+	clientCacheEvent     = cache.NewLocal(cache.ClientCache)
 	authCacheLockEvent   sync.RWMutex
 	clientCacheLockEvent sync.RWMutex
 )
@@ -44,7 +46,7 @@ func GetRecentEventLogsPanel(w http.ResponseWriter, r *http.Request) {
 	endTime := queries.Get("endTime")
 	logGroupName := queries.Get("logGroupName")
 
-	log.Printf("Received request with parameters: elementId=%s, elementApiUrl=%s, responseType=%s, startTime=%s, endTime=%s, logGroupName=%s\n",
+	log.FromContext(r.Context()).Infof("Received request with parameters: elementId=%s, elementApiUrl=%s, responseType=%s, startTime=%s, endTime=%s, logGroupName=%s",
 		elementId, elementApiUrl, responseType, startTime, endTime, logGroupName)
 
 	// Prepare command parameters
@@ -62,7 +64,7 @@ func GetRecentEventLogsPanel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create CloudWatch Logs client
-	cloudWatchLogs, err := cloudwatchclientCacheEvent(*clientAuth)
+	cloudWatchLogs, err := cloudwatchclientCacheEvent(r.Context(), *clientAuth)
 	if err != nil {
 		sendErrorResponseEvent(w, fmt.Sprintf("Failed to create CloudWatch client: %s", err), http.StatusInternalServerError)
 		return
@@ -81,7 +83,7 @@ func GetRecentEventLogsPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
     
-	log.Println("Flags parsed successfully")
+	log.FromContext(r.Context()).Debugf("Flags parsed successfully")
 
 	cloudwatchMetricData, err := RDS.GetRecentEventLogsPanel(cmd, clientAuth, cloudWatchLogs)
 	if cloudwatchMetricData == nil {
@@ -138,20 +140,20 @@ func authenticateAndCacheEvent(commandParam model.CommandParam) (*model.Auth, er
 	return clientAuth, nil
 }
 
-func cloudwatchclientCacheEvent(clientAuth model.Auth) (*cloudwatchlogs.CloudWatchLogs, error) {
+func cloudwatchclientCacheEvent(ctx context.Context, clientAuth model.Auth) (*cloudwatchlogs.CloudWatchLogs, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockEvent.Lock()
 	defer clientCacheLockEvent.Unlock()
 
 	if client, ok := clientCacheEvent.Load(cacheKey); ok {
-		return client.(*cloudwatchlogs.CloudWatchLogs), nil
+		return cache.WithContext(ctx, client).(*cloudwatchlogs.CloudWatchLogs), nil
 	}
 
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH_LOG).(*cloudwatchlogs.CloudWatchLogs)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH_LOG).(*cloudwatchlogs.CloudWatchLogs)
 	clientCacheEvent.Store(cacheKey, cloudWatchClient)
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatchlogs.CloudWatchLogs), nil
 }
 
 func sendErrorResponseEvent(w http.ResponseWriter, message string, statusCode int) {
@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,8 +25,8 @@ type ReplicationSlotDiskUsage struct {
 }
 
 var (
-	authCacheslot       sync.Map
-	clientCacheslot     sync.Map
+	authCacheslot       = cache.NewLocal(cache.CredentialCache)
+	clientCacheslot     = cache.NewLocal(cache.ClientCache)
 	authCacheLockslot   sync.RWMutex
 	clientCacheLockslot sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetReplicationSlotDiskUsagePanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCacheslot(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheslot(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -137,7 +139,7 @@ func authenticateAndCacheslot(commandParam model.CommandParam) (*model.Auth, err
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheslot(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheslot(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockslot.Lock()
@@ -145,13 +147,13 @@ func cloudwatchClientCacheslot(clientAuth model.Auth) (*cloudwatch.CloudWatch, e
 
 	if client, ok := clientCacheslot.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheslot.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
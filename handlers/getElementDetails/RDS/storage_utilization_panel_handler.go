@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,8 +25,8 @@ type StorageUtilizationResult struct {
 }
 
 var (
-	authCachest       sync.Map
-	clientCachest     sync.Map
+	authCachest       = cache.NewLocal(cache.CredentialCache)
+	clientCachest     = cache.NewLocal(cache.ClientCache)
 	authCacheLockst   sync.RWMutex
 	clientCacheLockst sync.RWMutex
 )
@@ -62,7 +64,7 @@ func GetStorageUtilizationPanel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwatchClientCachest(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCachest(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -136,7 +138,7 @@ func authenticateAndCachest(commandParam model.CommandParam) (*model.Auth, error
 	return clientAuth, nil
 }
 
-func cloudwatchClientCachest(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCachest(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockst.Lock()
@@ -144,13 +146,13 @@ func cloudwatchClientCachest(clientAuth model.Auth) (*cloudwatch.CloudWatch, err
 
 	if client, ok := clientCachest.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCachest.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
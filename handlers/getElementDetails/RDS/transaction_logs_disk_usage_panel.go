@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -26,8 +28,8 @@ type TransactionLogsDiskResult struct {
 
 // var authCache = make(map[string]*model.Auth)
 var (
-	authCacheTransactionDisk       sync.Map
-	clientCacheTransactionDisk     sync.Map
+	authCacheTransactionDisk       = cache.NewLocal(cache.CredentialCache)
+	clientCacheTransactionDisk     = cache.NewLocal(cache.ClientCache)
 	authCacheLockTransactionDisk   sync.RWMutex
 	clientCacheLockTransactionDisk sync.RWMutex
 	//authCacheLock sync.Mutex
@@ -67,7 +69,7 @@ func GetTransactionLogsDiskPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := cloudwatchClientCacheTransactionDisk(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheTransactionDisk(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -85,10 +87,10 @@ func GetTransactionLogsDiskPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
-			log.Infof("Response type is 'frame'. Encoding cloudwatchMetricData as JSON")
+			log.FromContext(r.Context()).Infof("Response type is 'frame'. Encoding cloudwatchMetricData as JSON")
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
@@ -97,7 +99,7 @@ func GetTransactionLogsDiskPanel(w http.ResponseWriter, r *http.Request) {
 		} else {
 			// Assuming this is meant to handle cases where responseType != "frame"
 			// Previously, this else was opening without closing the above block properly
-			log.Infof("Some other response type processing")
+			log.FromContext(r.Context()).Infof("Some other response type processing")
 			var data TransactionLogsDiskResult
 			err := json.Unmarshal([]byte(jsonString), &data)
 			if err != nil {
@@ -149,22 +151,22 @@ func authenticateAndCacheTransactionDisk(commandParam model.CommandParam) (*mode
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheTransactionDisk(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheTransactionDisk(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockTransactionDisk.Lock()
 	defer clientCacheLockTransactionDisk.Unlock()
 	if client, ok := clientCacheTransactionDisk.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheTransactionDisk.Store(cacheKey, cloudWatchClient)
 	//clientCacheLockTransactionDisk.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
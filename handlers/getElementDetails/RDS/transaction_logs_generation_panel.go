@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -26,8 +28,8 @@ type TransactionLogsGenerationResult struct {
 
 // var authCache = make(map[string]*model.Auth)
 var (
-	authCacheTransaction       sync.Map
-	clientCacheTransaction     sync.Map
+	authCacheTransaction       = cache.NewLocal(cache.CredentialCache)
+	clientCacheTransaction     = cache.NewLocal(cache.ClientCache)
 	authCacheLockTransaction   sync.RWMutex
 	clientCacheLockTransaction sync.RWMutex
 	//authCacheLock sync.Mutex
@@ -67,7 +69,7 @@ func GetTransactionLogsGenerationPanel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusInternalServerError)
 		return
 	}
-	cloudwatchClient, err := cloudwatchClientCacheTransaction(*clientAuth)
+	cloudwatchClient, err := cloudwatchClientCacheTransaction(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -85,10 +87,10 @@ func GetTransactionLogsGenerationPanel(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
 			return
 		}
-		log.Infof("response type :" + responseType)
+		log.FromContext(r.Context()).Infof("response type :" + responseType)
 
 		if responseType == "frame" {
-			log.Infof("Response type is 'frame'. Encoding cloudwatchMetricData as JSON")
+			log.FromContext(r.Context()).Infof("Response type is 'frame'. Encoding cloudwatchMetricData as JSON")
 			err = json.NewEncoder(w).Encode(cloudwatchMetricData)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
@@ -97,7 +99,7 @@ func GetTransactionLogsGenerationPanel(w http.ResponseWriter, r *http.Request) {
 		} else {
 			// Assuming this is meant to handle cases where responseType != "frame"
 			// Previously, this else was opening without closing the above block properly
-			log.Infof("Some other response type processing")
+			log.FromContext(r.Context()).Infof("Some other response type processing")
 			var data TransactionLogsGenerationResult
 			err := json.Unmarshal([]byte(jsonString), &data)
 			// log.Infof(jsonString)
@@ -150,22 +152,22 @@ func authenticateAndCacheTransaction(commandParam model.CommandParam) (*model.Au
 	return clientAuth, nil
 }
 
-func cloudwatchClientCacheTransaction(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwatchClientCacheTransaction(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientCacheLockTransaction.Lock()
 	defer clientCacheLockTransaction.Unlock()
 	if client, ok := clientCacheTransaction.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross acount role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross acount role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientCacheTransaction.Store(cacheKey, cloudWatchClient)
 	//clientCacheLockTransaction.Unlock()
 
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
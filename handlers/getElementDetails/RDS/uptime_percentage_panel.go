@@ -1,7 +1,9 @@
 package RDS
 
 import (
+	"awsx-api/cache"
 	"awsx-api/log"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -16,8 +18,8 @@ import (
 )
 
 var (
-	authDBLoadrds       sync.Map
-	clientDBLoadrds     sync.Map
+	authDBLoadrds       = cache.NewLocal(cache.CredentialCache)
+	clientDBLoadrds     = cache.NewLocal(cache.ClientCache)
 	authDBLoadLockrds   sync.RWMutex
 	clientDBLoadLockrds sync.RWMutex
 )
@@ -55,7 +57,7 @@ func GetRDSUptimeData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cloudwatchClient, err := cloudwtchClientCacherds(*clientAuth)
+	cloudwatchClient, err := cloudwtchClientCacherds(r.Context(), *clientAuth)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Cloudwatch client creation/store in cache failed: %s", err), http.StatusInternalServerError)
 		return
@@ -123,7 +125,7 @@ func authenicateAndCacherds(commandParam model.CommandParam) (*model.Auth, error
 	return clientAuth, nil
 }
 
-func cloudwtchClientCacherds(clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
+func cloudwtchClientCacherds(ctx context.Context, clientAuth model.Auth) (*cloudwatch.CloudWatch, error) {
 	cacheKey := clientAuth.CrossAccountRoleArn
 
 	clientDBLoadLockrds.Lock()
@@ -131,13 +133,13 @@ func cloudwtchClientCacherds(clientAuth model.Auth) (*cloudwatch.CloudWatch, err
 
 	if client, ok := clientDBLoadrds.Load(cacheKey); ok {
 		log.Infof("cloudwatch client found in cache for given cross account role: %s", cacheKey)
-		return client.(*cloudwatch.CloudWatch), nil
+		return cache.WithContext(ctx, client).(*cloudwatch.CloudWatch), nil
 	}
 
 	// If not in cache, create new cloud watch client
 	log.Infof("creating new cloudwatch client for given cross account role: %s", cacheKey)
-	cloudWatchClient := awsclient.GetClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
+	cloudWatchClient := cache.NewAwsClient(clientAuth, awsclient.CLOUDWATCH).(*cloudwatch.CloudWatch)
 
 	clientDBLoadrds.Store(cacheKey, cloudWatchClient)
-	return cloudWatchClient, nil
+	return cache.WithContext(ctx, cloudWatchClient).(*cloudwatch.CloudWatch), nil
 }
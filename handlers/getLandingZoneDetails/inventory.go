@@ -0,0 +1,85 @@
+package getLandingZoneDetails
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Resource is an element of the landing zone inventory, offered as a template variable value.
+type Resource struct {
+	Id   string
+	Name string
+}
+
+// resourceIdKeys are the keys that identify a resource in the AWS describe/list outputs of
+// the landing zone queries, e.g. Reservations[].Instances[].InstanceId for getEc2List. The
+// first key an object has wins, so the service specific ones come before Id and Name.
+var resourceIdKeys = []string{
+	"InstanceId",
+	"DBInstanceIdentifier",
+	"FunctionName",
+	"LoadBalancerName",
+	"ClusterName",
+	"TableName",
+	"StreamName",
+	"KeyId",
+	"VpcId",
+	"WebACLId",
+	"DistributionId",
+	"Id",
+	"Name",
+}
+
+// Resources finds the resources in a decoded landing zone inventory. The name is the Name
+// tag or the Name of the resource when it has one, the id otherwise.
+func Resources(inventory interface{}) []Resource {
+	var resources []Resource
+	seen := map[string]bool{}
+	collectResources(inventory, &resources, seen)
+	return resources
+}
+
+func collectResources(v interface{}, resources *[]Resource, seen map[string]bool) {
+	switch value := v.(type) {
+	case []interface{}:
+		for _, item := range value {
+			collectResources(item, resources, seen)
+		}
+	case map[string]interface{}:
+		for _, key := range resourceIdKeys {
+			id, ok := value[key].(string)
+			if !ok || id == "" {
+				continue
+			}
+			if !seen[id] {
+				seen[id] = true
+				*resources = append(*resources, Resource{Id: id, Name: resourceName(value, id)})
+			}
+			return
+		}
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			collectResources(value[k], resources, seen)
+		}
+	}
+}
+
+func resourceName(resource map[string]interface{}, id string) string {
+	if tags, ok := resource["Tags"].([]interface{}); ok {
+		for _, tag := range tags {
+			if t, ok := tag.(map[string]interface{}); ok && fmt.Sprint(t["Key"]) == "Name" {
+				if name := fmt.Sprint(t["Value"]); name != "" {
+					return name
+				}
+			}
+		}
+	}
+	if name, ok := resource["Name"].(string); ok && name != "" {
+		return name
+	}
+	return id
+}
@@ -1,8 +1,35 @@
 package handlers
 
-import "net/http"
+import (
+	"awsx-api/appstate"
+	"awsx-api/cache"
+	"awsx-api/log"
+	"awsx-api/status"
+	"encoding/json"
+	"net/http"
+	"time"
+)
 
+// HealthReport is the detailed state of the server returned by /app-health/awsx-api/health
+type HealthReport struct {
+	Status        string              `json:"status"`
+	Reasons       []string            `json:"reasons,omitempty"`
+	Draining      bool                `json:"draining"`
+	StartedAt     time.Time           `json:"startedAt"`
+	Uptime        string              `json:"uptime"`
+	UptimeSeconds int64               `json:"uptimeSeconds"`
+	Build         map[string]string   `json:"build"`
+	Dependencies  []status.Dependency `json:"dependencies"`
+	Caches        map[string]int      `json:"caches"`
+}
+
+// Readiness fails while the configuration is not loaded, CMDB or vault are not reachable, or the
+// server is shutting down, so no traffic is routed to a server that can not answer queries
 func Readiness(w http.ResponseWriter, r *http.Request) {
+	if reasons := status.NotReadyReasons(); len(reasons) > 0 {
+		RespondWithJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "not ready", "reasons": reasons})
+		return
+	}
 	RespondWithCode(w, http.StatusOK)
 }
 
@@ -10,6 +37,39 @@ func Liveness(w http.ResponseWriter, r *http.Request) {
 	RespondWithCode(w, http.StatusOK)
 }
 
+// Health reports the state of every dependency, the cache sizes, uptime and build info. It
+// always answers 200, the status field tells whether the server is ready
+func Health(w http.ResponseWriter, r *http.Request) {
+	reasons := status.NotReadyReasons()
+	report := HealthReport{
+		Status:        "ready",
+		Reasons:       reasons,
+		Draining:      appstate.IsDraining(),
+		StartedAt:     status.StartedAt(),
+		Uptime:        status.Uptime().Round(time.Second).String(),
+		UptimeSeconds: int64(status.Uptime().Seconds()),
+		Build:         status.Info(),
+		Dependencies:  status.Dependencies(),
+		Caches:        cache.Sizes(),
+	}
+	if len(reasons) > 0 {
+		report.Status = "not ready"
+	}
+	RespondWithJSON(w, http.StatusOK, report)
+}
+
 func RespondWithCode(w http.ResponseWriter, code int) {
 	w.WriteHeader(code)
 }
+
+func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, err := json.Marshal(payload)
+	if err != nil {
+		log.Errorf("Failed to encode response: %v", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(response)
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"awsx-api/cache"
+	"awsx-api/log"
+	"awsx-api/metricquery"
+	"awsx-api/panel"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Appkube-awsx/awsx-common/awsclient"
+	"github.com/Appkube-awsx/awsx-common/cmdb"
+	"github.com/Appkube-awsx/awsx-common/model"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// withMetricQueries sends the requests of the panels that ask for a stat or a comparison, or
+// for a period or maxDataPoints of a metric panel, to GetMetricPanel. Its responses are not
+// shaped like those of the panel library, so stat, period and maxDataPoints are only taken
+// with apiVersion=v2 or responseType=frame; plain json requests keep the library response and
+// get a 400 instead of a silently different one.
+func withMetricQueries(definitions ...panel.Definition) []panel.Definition {
+	for i, definition := range definitions {
+		_, isMetricPanel := metricquery.SpecOf(definition.ElementType, definition.Query)
+		next := definition.Handler
+		definitions[i].Handler = func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			resolution := isMetricPanel && (query.Get("period") != "" || query.Get("maxDataPoints") != "")
+			if query.Get("compareTo") == "" && (query.Get("stat") != "" || resolution) && !panel.WantsEnvelope(r) && !panel.WantsFrames(r) {
+				http.Error(w, fmt.Sprintf("stat, period and maxDataPoints change the response of panel [%s], ask for it with apiVersion=%s or responseType=frame", definition.Query, panel.EnvelopeVersion), http.StatusBadRequest)
+				return
+			}
+			if query.Get("stat") != "" || query.Get("compareTo") != "" || resolution {
+				GetMetricPanel(w, r)
+				return
+			}
+			next(w, r)
+		}
+	}
+	return definitions
+}
+
+// GetMetricPanel answers a metric panel requested with a stat, period, maxDataPoints or
+// compareTo parameter. The panel library hardcodes the statistics and the period of its
+// panels, so the metrics of the panel are queried here instead. The period and the statistic
+// are reported in the PeriodHeader and the StatisticHeader.
+//
+// The response holds the GetMetricData results of the panel metrics. compareTo (1w, 1d or
+// previous) runs the same query over the shifted window as well. The frame response then
+// holds both, as current and previous with the timestamps of previous moved onto those of
+// current, and the json response compares their summary stats.
+func GetMetricPanel(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	stat := panel.DefaultStatistic
+	if query.Get("stat") != "" {
+		var err error
+		if stat, err = metricquery.ParseStat(query.Get("stat")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	spec, ok := metricquery.SpecOf(query.Get("elementType"), query.Get("query"))
+	if !ok {
+		http.Error(w, fmt.Sprintf("Panel [%s] of elementType [%s] is not a CloudWatch metric panel, stat and compareTo are not supported", query.Get("query"), query.Get("elementType")), http.StatusBadRequest)
+		return
+	}
+	if spec.Counter && metricquery.IsExtended(stat) {
+		http.Error(w, fmt.Sprintf("Panel [%s] counts events, stat [%s] is not supported. Use Sum, SampleCount, Average, Minimum or Maximum", query.Get("query"), stat), http.StatusBadRequest)
+		return
+	}
+
+	commandParam := model.CommandParam{Region: query.Get("zone")}
+	if elementId := query.Get("elementId"); elementId != "" {
+		commandParam.CloudElementId = elementId
+		commandParam.CloudElementApiUrl = query.Get("cmdbApiUrl")
+	} else {
+		commandParam.CrossAccountRoleArn = query.Get("crossAccountRoleArn")
+		commandParam.ExternalId = query.Get("externalId")
+	}
+	element, err := metricElement(commandParam, query.Get("instanceId"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	timeRange := panel.ParseTimeRange(query.Get("startTime"), query.Get("endTime"), now)
+	requestedPeriod, maxDataPoints, err := resolutionParams(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	compareTo := query.Get("compareTo")
+	var shift time.Duration
+	if compareTo != "" {
+		// a first guess of the shift, so that the period also suits the older window
+		if shift, err = metricquery.Shift(compareTo, timeRange.From, timeRange.To, 0); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	period, err := metricquery.Period(timeRange.From.Add(-shift), timeRange.To.Add(-shift), now, requestedPeriod, maxDataPoints)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	metricQuery := metricquery.Query{
+		Spec:    spec,
+		Element: element,
+		Start:   timeRange.From,
+		End:     timeRange.To,
+		Period:  period,
+		Stat:    stat,
+	}
+	output, err := runMetricQuery(r, commandParam, metricQuery)
+	if err != nil {
+		log.FromContext(r.Context()).Errorf("GetMetricData of panel [%s] with stat [%s] failed: %v", query.Get("query"), stat, err)
+		http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
+		return
+	}
+	var previous map[string]*cloudwatch.GetMetricDataOutput
+	if compareTo != "" {
+		shift, _ = metricquery.Shift(compareTo, timeRange.From, timeRange.To, period)
+		shifted := metricQuery
+		shifted.Start, shifted.End = timeRange.From.Add(-shift), timeRange.To.Add(-shift)
+		if previous, err = runMetricQuery(r, commandParam, shifted); err != nil {
+			log.FromContext(r.Context()).Errorf("GetMetricData of panel [%s] compared to [%s] failed: %v", query.Get("query"), compareTo, err)
+			http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
+			return
+		}
+		metricquery.Align(previous, shift)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(panel.PeriodHeader, strconv.FormatInt(period, 10))
+	w.Header().Set(panel.StatisticHeader, stat)
+	switch {
+	case compareTo != "" && query.Get("responseType") == "frame":
+		err = json.NewEncoder(w).Encode(map[string]interface{}{"current": output, "previous": previous})
+	case compareTo != "":
+		err = json.NewEncoder(w).Encode(metricquery.Compare(output, previous))
+	default:
+		err = json.NewEncoder(w).Encode(output)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Exception: %s", err), http.StatusInternalServerError)
+	}
+}
+
+// runMetricQuery runs a metric query with the cached CloudWatch client of the landing zone,
+// renewing the client once when its session expired.
+func runMetricQuery(r *http.Request, commandParam model.CommandParam, metricQuery metricquery.Query) (map[string]*cloudwatch.GetMetricDataOutput, error) {
+	_, awsClient, err := cache.GetAwsCredsAndClient(r.Context(), commandParam, awsclient.CLOUDWATCH)
+	if err != nil {
+		return nil, fmt.Errorf("Cloudwatch client creation/store in cache failed: %s", err)
+	}
+	output, err := metricquery.Run(r.Context(), awsClient.(*cloudwatch.CloudWatch), metricQuery)
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == "ExpiredToken" {
+		log.FromContext(r.Context()).Infof("aws session expired. resetting connection cache")
+		if _, awsClient, err = cache.SetAwsCredsAndClientInCache(r.Context(), commandParam, awsclient.CLOUDWATCH); err == nil {
+			output, err = metricquery.Run(r.Context(), awsClient.(*cloudwatch.CloudWatch), metricQuery)
+		}
+	}
+	return output, err
+}
+
+// resolutionParams reads the period and maxDataPoints parameters, 0 when they are missing.
+func resolutionParams(query url.Values) (int64, int, error) {
+	var period int64
+	var maxDataPoints int
+	var err error
+	if value := query.Get("period"); value != "" {
+		if period, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid period [%s], expected seconds", value)
+		}
+	}
+	if value := query.Get("maxDataPoints"); value != "" {
+		if maxDataPoints, err = strconv.Atoi(value); err != nil {
+			return 0, 0, fmt.Errorf("invalid maxDataPoints [%s]", value)
+		}
+	}
+	return period, maxDataPoints, nil
+}
+
+// metricElement is the dimension value of the element: the instance id the CMDB has for the
+// cloud element, or the instanceId parameter.
+func metricElement(commandParam model.CommandParam, instanceId string) (string, error) {
+	if commandParam.CloudElementId == "" {
+		if instanceId == "" {
+			return "", errors.New("elementId or instanceId is required")
+		}
+		return instanceId, nil
+	}
+	element, err := cmdb.GetCloudElement(commandParam)
+	if err != nil {
+		return "", fmt.Errorf("cmdb lookup of element [%s] failed: %v", commandParam.CloudElementId, err)
+	}
+	if element == nil || element.InstanceId == "" {
+		return "", fmt.Errorf("cmdb has no instance id for element [%s]", commandParam.CloudElementId)
+	}
+	return element.InstanceId, nil
+}
@@ -0,0 +1,45 @@
+package internalmetrics
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Appkube-awsx/awsx-common/model"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// InstrumentAwsClient counts and times every call made through an aws client. It is meant
+// to be registered with cache.RegisterClientDecorator.
+func InstrumentAwsClient(auth model.Auth, _ string, c *client.Client) {
+	landingZone := landingZoneLabel(auth)
+	c.Handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "awsx-api.InternalMetrics",
+		Fn: func(r *request.Request) {
+			errorCode := ""
+			if r.Error != nil {
+				errorCode = "Unknown"
+				var awsErr awserr.Error
+				if errors.As(r.Error, &awsErr) {
+					errorCode = awsErr.Code()
+				}
+			}
+			ObserveAwsRequest(r.ClientInfo.ServiceID, r.Operation.Name, landingZone, errorCode, time.Since(r.Time))
+		},
+	})
+}
+
+// landingZoneLabel identifies the landing zone of a client. The landing zone id is not always
+// known, the account id of the assumed role is then the next best thing.
+func landingZoneLabel(auth model.Auth) string {
+	if auth.LandingZoneId != "" {
+		return auth.LandingZoneId
+	}
+	// arn:aws:iam::123456789012:role/name
+	if parts := strings.Split(auth.CrossAccountRoleArn, ":"); len(parts) > 4 {
+		return parts[4]
+	}
+	return ""
+}
@@ -0,0 +1,170 @@
+package internalmetrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricsType defines all of awsx-api's own internal metrics.
+type MetricsType struct {
+	APIRequests              *prometheus.CounterVec
+	APIProcessingTime        *prometheus.HistogramVec
+	AwsRequests              *prometheus.CounterVec
+	AwsRequestDuration       *prometheus.HistogramVec
+	CacheLookups             *prometheus.CounterVec
+	CmdbRequestDuration      *prometheus.HistogramVec
+	ResponseCompressionRatio *prometheus.HistogramVec
+	ResponseBytes            *prometheus.CounterVec
+}
+
+// Metrics contains all of awsx-api's own internal metrics.
+// These metrics can be accessed directly to update their values, or
+// you can use available utility functions defined below.
+var Metrics = MetricsType{
+	APIRequests: prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "awsx_api_requests_total",
+			Help: "The number of API requests served, by route, elementType, query and response status.",
+		},
+		[]string{"route", "elementType", "query", "status"},
+	),
+	APIProcessingTime: prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "awsx_api_request_duration_seconds",
+			Help:    "The time required to serve an API request, by route, elementType, query and response status.",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80},
+		},
+		[]string{"route", "elementType", "query", "status"},
+	),
+	AwsRequests: prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "awsx_api_aws_requests_total",
+			Help: "The number of outbound AWS API calls, by service, operation, landing zone and error code (empty on success).",
+		},
+		[]string{"service", "operation", "landingZone", "errorCode"},
+	),
+	AwsRequestDuration: prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "awsx_api_aws_request_duration_seconds",
+			Help:    "The time an outbound AWS API call took including retries, by service and operation.",
+			Buckets: []float64{0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"service", "operation"},
+	),
+	CacheLookups: prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "awsx_api_cache_lookups_total",
+			Help: "Cache lookups of the shared and panel caches by cache name (credential, client or response) and result (hit or miss). The hit ratio is hit / (hit + miss).",
+		},
+		[]string{"cache", "result"},
+	),
+	CmdbRequestDuration: prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "awsx_api_cmdb_request_duration_seconds",
+			Help:    "The time a CMDB call took, by operation and outcome (success or error).",
+			Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+		[]string{"operation", "outcome"},
+	),
+	ResponseCompressionRatio: prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "awsx_api_response_compression_ratio",
+			Help:    "Ratio of compressed to uncompressed response body size, by content encoding.",
+			Buckets: []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.8, 1},
+		},
+		[]string{"encoding"},
+	),
+	ResponseBytes: prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "awsx_api_response_bytes_total",
+			Help: "Response body bytes written, before (stage=raw) and after (stage=compressed) compression.",
+		},
+		[]string{"encoding", "stage"},
+	),
+}
+
+// RegisterInternalMetrics must be called at startup to prepare the Prometheus scrape endpoint.
+func RegisterInternalMetrics() {
+	prometheus.MustRegister(
+		Metrics.APIRequests,
+		Metrics.APIProcessingTime,
+		Metrics.AwsRequests,
+		Metrics.AwsRequestDuration,
+		Metrics.CacheLookups,
+		Metrics.CmdbRequestDuration,
+		Metrics.ResponseCompressionRatio,
+		Metrics.ResponseBytes,
+	)
+}
+
+//
+// The following are utility functions that can be used to update the internal metrics.
+//
+
+// otherLabelValue is the elementType and query label of the requests for an unknown panel.
+const otherLabelValue = "other"
+
+// knownPanel tells whether elementType and query name a registered panel, see SetPanelLookup.
+var knownPanel func(elementType, query string) bool
+
+// SetPanelLookup limits the elementType and query labels to the registered panels, so that
+// arbitrary query strings cannot blow up the number of series. Requests for any other panel
+// are counted with elementType and query "other".
+func SetPanelLookup(lookup func(elementType, query string) bool) {
+	knownPanel = lookup
+}
+
+func panelLabels(elementType, query string) (string, string) {
+	if elementType == "" && query == "" {
+		return "", ""
+	}
+	if knownPanel == nil || !knownPanel(elementType, query) {
+		return otherLabelValue, otherLabelValue
+	}
+	return elementType, query
+}
+
+// ObserveAPIRequest records one served API request.
+func ObserveAPIRequest(route, elementType, query string, status int, duration time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	elementType, query = panelLabels(elementType, query)
+	Metrics.APIRequests.WithLabelValues(route, elementType, query, statusLabel).Inc()
+	Metrics.APIProcessingTime.WithLabelValues(route, elementType, query, statusLabel).Observe(duration.Seconds())
+}
+
+// ObserveAwsRequest records one outbound AWS API call. errorCode is empty for successful calls.
+func ObserveAwsRequest(service, operation, landingZone, errorCode string, duration time.Duration) {
+	Metrics.AwsRequests.WithLabelValues(service, operation, landingZone, errorCode).Inc()
+	Metrics.AwsRequestDuration.WithLabelValues(service, operation).Observe(duration.Seconds())
+}
+
+// ObserveCmdbRequest records the latency of a CMDB call.
+func ObserveCmdbRequest(operation string, err error, duration time.Duration) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	Metrics.CmdbRequestDuration.WithLabelValues(operation, outcome).Observe(duration.Seconds())
+}
+
+// CacheHit counts a lookup that was served from the named cache.
+func CacheHit(cache string) {
+	Metrics.CacheLookups.WithLabelValues(cache, "hit").Inc()
+}
+
+// CacheMiss counts a lookup that was not found in the named cache.
+func CacheMiss(cache string) {
+	Metrics.CacheLookups.WithLabelValues(cache, "miss").Inc()
+}
+
+// ObserveResponseCompression records the size of a response body before and after it was
+// compressed with the given content encoding.
+func ObserveResponseCompression(encoding string, rawBytes, compressedBytes int64) {
+	Metrics.ResponseBytes.WithLabelValues(encoding, "raw").Add(float64(rawBytes))
+	Metrics.ResponseBytes.WithLabelValues(encoding, "compressed").Add(float64(compressedBytes))
+	if rawBytes > 0 {
+		Metrics.ResponseCompressionRatio.WithLabelValues(encoding).Observe(float64(compressedBytes) / float64(rawBytes))
+	}
+}
@@ -0,0 +1,15 @@
+package log
+
+import (
+	"os"
+
+	"github.com/rs/zerolog"
+)
+
+var auditLogger = zerolog.New(newRedactingWriter(zerolog.SyncWriter(os.Stdout))).With().Timestamp().Str("type", "audit").Logger()
+
+// Audit starts an audit record. Audit records are always written as JSON, independent of
+// LOG_FORMAT and LOG_LEVEL, straight to stdout, so none are lost when the process exits.
+func Audit() *zerolog.Event {
+	return auditLogger.Log()
+}
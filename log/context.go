@@ -0,0 +1,118 @@
+package log
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	RequestIdField   = "requestId"
+	ElementTypeField = "elementType"
+	QueryField       = "query"
+	LandingZoneField = "landingZone"
+)
+
+type requestIdKey struct{}
+
+type landingZoneKey struct{}
+
+// landingZone is the landing zone of a request. It is only known once the cloud element of
+// the request has been looked up, after the logger was put into the context.
+type landingZone struct {
+	mu sync.Mutex
+	id string
+}
+
+// ContextLogger writes log records that carry the fields of a request, so lines of concurrent
+// requests can be correlated.
+type ContextLogger struct {
+	logger zerolog.Logger
+}
+
+// NewContext returns a copy of ctx holding a logger that adds requestId to every record.
+func NewContext(ctx context.Context, requestId string) context.Context {
+	logger := log.Logger.With().Str(RequestIdField, requestId).Logger()
+	ctx = context.WithValue(ctx, requestIdKey{}, requestId)
+	ctx = context.WithValue(ctx, landingZoneKey{}, &landingZone{})
+	return logger.WithContext(ctx)
+}
+
+// SetLandingZone records the landing zone of the request of ctx, so the records logged from
+// then on carry it. Empty ids and contexts made without NewContext are ignored.
+func SetLandingZone(ctx context.Context, landingZoneId string) {
+	if ctx == nil || landingZoneId == "" {
+		return
+	}
+	if zone, ok := ctx.Value(landingZoneKey{}).(*landingZone); ok {
+		zone.mu.Lock()
+		zone.id = landingZoneId
+		zone.mu.Unlock()
+	}
+}
+
+// WithField returns a copy of ctx whose logger also adds key=value to every record. Empty
+// values are skipped.
+func WithField(ctx context.Context, key, value string) context.Context {
+	if value == "" {
+		return ctx
+	}
+	logger := loggerFrom(ctx).With().Str(key, value).Logger()
+	return logger.WithContext(ctx)
+}
+
+// RequestIdFromContext returns the request id stored by NewContext, or an empty string.
+func RequestIdFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+// FromContext returns the logger of the request. Without one, records go to the global logger.
+func FromContext(ctx context.Context) ContextLogger {
+	logger := loggerFrom(ctx)
+	if ctx != nil {
+		if zone, ok := ctx.Value(landingZoneKey{}).(*landingZone); ok {
+			zone.mu.Lock()
+			id := zone.id
+			zone.mu.Unlock()
+			if id != "" {
+				logger = logger.With().Str(LandingZoneField, id).Logger()
+			}
+		}
+	}
+	return ContextLogger{logger: logger}
+}
+
+func loggerFrom(ctx context.Context) zerolog.Logger {
+	if ctx != nil {
+		if logger := zerolog.Ctx(ctx); logger != zerolog.DefaultContextLogger && logger.GetLevel() != zerolog.Disabled {
+			return *logger
+		}
+	}
+	return log.Logger
+}
+
+func (l ContextLogger) Infof(format string, args ...interface{}) {
+	l.logger.Info().Msgf(format, args...)
+}
+
+func (l ContextLogger) Warningf(format string, args ...interface{}) {
+	l.logger.Warn().Msgf(format, args...)
+}
+
+func (l ContextLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Error().Msgf(format, args...)
+}
+
+func (l ContextLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debug().Msgf(format, args...)
+}
+
+func (l ContextLogger) Tracef(format string, args ...interface{}) {
+	l.logger.Trace().Msgf(format, args...)
+}
@@ -1,6 +1,9 @@
 package log
 
 import (
+	"fmt"
+	"io"
+	stdlog "log"
 	"os"
 	"strconv"
 	"strings"
@@ -51,10 +54,18 @@ func InitializeLogger() zerolog.Logger {
 		}
 	}
 
+	// secrets are masked in every record, whatever the format
+	var out io.Writer = os.Stderr
 	logFormat := resolveLogFormatFromEnv()
 	if logFormat != "json" {
-		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: zerolog.TimeFieldFormat, NoColor: true})
+		out = zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: zerolog.TimeFieldFormat, NoColor: true}
 	}
+	log.Logger = log.Output(newRedactingWriter(out))
+
+	// The panel libraries log through the standard library logger, send it through zerolog so
+	// those lines are redacted and formatted like ours
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(stdlogWriter{})
 
 	logLevel := resolveLogLevelFromEnv()
 	zerolog.SetGlobalLevel(logLevel)
@@ -62,6 +73,14 @@ func InitializeLogger() zerolog.Logger {
 	return log.Logger
 }
 
+// stdlogWriter writes standard library log lines as info records.
+type stdlogWriter struct{}
+
+func (stdlogWriter) Write(p []byte) (int, error) {
+	log.Info().Msg(strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
+
 func Info(args ...interface{}) {
 	log.Info().Msgf("%s", args...)
 }
@@ -126,26 +145,50 @@ func resolveLogLevelFromEnv() zerolog.Level {
 		return zerolog.InfoLevel
 	}
 
+	level, err := parseLevel(logLevel)
+	if err != nil {
+		log.Warn().Msgf("Provided LOG_LEVEL %s is invalid. Fallback to info.", os.Getenv("LOG_LEVEL"))
+		return zerolog.InfoLevel
+	}
+	return level
+}
+
+// SetLevel changes the global log level at runtime. It accepts the same values as LOG_LEVEL.
+func SetLevel(level string) error {
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	zerolog.SetGlobalLevel(parsed)
+	return nil
+}
+
+// GetLevel returns the name of the current global log level.
+func GetLevel() string {
+	return zerolog.GlobalLevel().String()
+}
+
+// parseLevel accepts a level name (trace, debug, info, warn, error, fatal) or its number (5 to 0).
+func parseLevel(logLevel string) (zerolog.Level, error) {
 	switch logLevel {
 	case "0":
-		return zerolog.FatalLevel
+		return zerolog.FatalLevel, nil
 	case "1":
-		return zerolog.ErrorLevel
+		return zerolog.ErrorLevel, nil
 	case "2":
-		return zerolog.WarnLevel
+		return zerolog.WarnLevel, nil
 	case "3":
-		return zerolog.InfoLevel
+		return zerolog.InfoLevel, nil
 	case "4":
-		return zerolog.DebugLevel
+		return zerolog.DebugLevel, nil
 	case "5":
-		return zerolog.TraceLevel
+		return zerolog.TraceLevel, nil
 	default:
 		logLevelFromString, err := zerolog.ParseLevel(strings.ToLower(logLevel))
-		if err != nil {
-			log.Warn().Msgf("Provided LOG_LEVEL %s is invalid. Fallback to info.", os.Getenv("LOG_LEVEL"))
-			return zerolog.InfoLevel
+		if err != nil || logLevelFromString == zerolog.NoLevel {
+			return zerolog.InfoLevel, fmt.Errorf("invalid log level [%s]", logLevel)
 		}
-		return logLevelFromString
+		return logLevelFromString, nil
 	}
 }
 
@@ -0,0 +1,47 @@
+package log
+
+import (
+	"io"
+	"regexp"
+)
+
+// Redacted replaces the secrets Redact masks.
+const Redacted = "[REDACTED]"
+
+var (
+	// secretValuePattern matches the value following a sensitive key in query strings
+	// (key=value), printf output (key: value) and JSON ("key":"value", also JSON-escaped
+	// inside a message field). The scheme of an authorization value (Bearer xyz) is kept, the
+	// credentials after it are masked.
+	secretValuePattern = regexp.MustCompile(`(?i)((?:crossAccountRoleArn|externalId|secretKey|secretAccessKey|accessKey|accessKeyId|sessionToken|password|token|authorization)(?:\\?")?\s*[:=]\s*(?:\\?")?(?:(?:Bearer|Basic|Token|AWS4-HMAC-SHA256)\s+)?)([^\s,&"\\}]+)`)
+
+	// accessKeyIdPattern matches long term (AKIA) and temporary (ASIA) aws access key ids.
+	accessKeyIdPattern = regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`)
+)
+
+// Redact masks credentials, external ids and role arns in s.
+func Redact(s string) string {
+	return string(redactBytes([]byte(s)))
+}
+
+func redactBytes(p []byte) []byte {
+	p = secretValuePattern.ReplaceAll(p, []byte("${1}"+Redacted))
+	return accessKeyIdPattern.ReplaceAll(p, []byte(Redacted))
+}
+
+// redactingWriter masks secrets in every log record before it reaches the output. Records are
+// still valid JSON afterwards, so it can sit in front of a zerolog.ConsoleWriter.
+type redactingWriter struct {
+	out io.Writer
+}
+
+func newRedactingWriter(out io.Writer) io.Writer {
+	return redactingWriter{out: out}
+}
+
+func (w redactingWriter) Write(p []byte) (int, error) {
+	if _, err := w.out.Write(redactBytes(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,142 @@
+package logsinsights
+
+import (
+	"awsx-api/cache"
+	"awsx-api/log"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Appkube-awsx/awsx-common/awsclient"
+	"github.com/Appkube-awsx/awsx-common/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+// RunningQuery is a CloudWatch Logs Insights query that was started and has not reached a
+// final status yet.
+type RunningQuery struct {
+	QueryId      string    `json:"queryId"`
+	LogGroupName string    `json:"logGroupName,omitempty"`
+	QueryString  string    `json:"queryString,omitempty"`
+	Region       string    `json:"region,omitempty"`
+	StartedAt    time.Time `json:"startedAt"`
+
+	client *client.Client
+}
+
+var (
+	runningQueries     = map[string]*RunningQuery{}
+	runningQueriesLock sync.Mutex
+)
+
+// Register installs the query tracking on every CloudWatch Logs client created through the cache.
+func Register() {
+	cache.RegisterClientDecorator(trackQueries)
+}
+
+// trackQueries records the query ids returned by StartQuery and forgets them once
+// GetQueryResults reports a final status or the query is stopped.
+func trackQueries(_ model.Auth, clientType string, c *client.Client) {
+	if clientType != awsclient.CLOUDWATCH_LOG {
+		return
+	}
+	c.Handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "awsx-api.LogsInsightsTracker",
+		Fn: func(r *request.Request) {
+			if r.Error != nil {
+				return
+			}
+			switch r.Operation.Name {
+			case "StartQuery":
+				input, _ := r.Params.(*cloudwatchlogs.StartQueryInput)
+				output, _ := r.Data.(*cloudwatchlogs.StartQueryOutput)
+				if input == nil || output == nil || output.QueryId == nil {
+					return
+				}
+				add(&RunningQuery{
+					QueryId:      aws.StringValue(output.QueryId),
+					LogGroupName: logGroupNameOf(input),
+					QueryString:  aws.StringValue(input.QueryString),
+					Region:       aws.StringValue(c.Config.Region),
+					StartedAt:    time.Now(),
+					client:       c,
+				})
+			case "GetQueryResults":
+				input, _ := r.Params.(*cloudwatchlogs.GetQueryResultsInput)
+				output, _ := r.Data.(*cloudwatchlogs.GetQueryResultsOutput)
+				if input == nil || output == nil {
+					return
+				}
+				switch aws.StringValue(output.Status) {
+				case cloudwatchlogs.QueryStatusScheduled, cloudwatchlogs.QueryStatusRunning:
+				default:
+					remove(aws.StringValue(input.QueryId))
+				}
+			case "StopQuery":
+				if input, ok := r.Params.(*cloudwatchlogs.StopQueryInput); ok {
+					remove(aws.StringValue(input.QueryId))
+				}
+			}
+		},
+	})
+}
+
+func logGroupNameOf(input *cloudwatchlogs.StartQueryInput) string {
+	if input.LogGroupName != nil {
+		return aws.StringValue(input.LogGroupName)
+	}
+	if len(input.LogGroupNames) > 0 {
+		return aws.StringValue(input.LogGroupNames[0])
+	}
+	return ""
+}
+
+func add(query *RunningQuery) {
+	runningQueriesLock.Lock()
+	defer runningQueriesLock.Unlock()
+	runningQueries[query.QueryId] = query
+}
+
+func remove(queryId string) {
+	runningQueriesLock.Lock()
+	defer runningQueriesLock.Unlock()
+	delete(runningQueries, queryId)
+}
+
+// Running returns the queries that are still in flight, oldest first.
+func Running() []RunningQuery {
+	runningQueriesLock.Lock()
+	defer runningQueriesLock.Unlock()
+	queries := make([]RunningQuery, 0, len(runningQueries))
+	for _, q := range runningQueries {
+		queries = append(queries, *q)
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].StartedAt.Before(queries[j].StartedAt) })
+	return queries
+}
+
+// CancelAll stops every running query so that it does not keep scanning (and billing) log data
+// after the request that started it is gone.
+func CancelAll(ctx context.Context) error {
+	runningQueriesLock.Lock()
+	queries := make([]*RunningQuery, 0, len(runningQueries))
+	for _, q := range runningQueries {
+		queries = append(queries, q)
+	}
+	runningQueriesLock.Unlock()
+
+	for _, q := range queries {
+		svc := &cloudwatchlogs.CloudWatchLogs{Client: q.client}
+		_, err := svc.StopQueryWithContext(ctx, &cloudwatchlogs.StopQueryInput{QueryId: aws.String(q.QueryId)})
+		if err != nil {
+			log.Warningf("Failed to stop Logs Insights query [%s] on [%s]: %v", q.QueryId, q.LogGroupName, err)
+			continue
+		}
+		log.Infof("Stopped Logs Insights query [%s] on [%s]", q.QueryId, q.LogGroupName)
+	}
+	return nil
+}
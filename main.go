@@ -16,25 +16,39 @@
 package main
 
 import (
+	"awsx-api/admin"
+	"awsx-api/alerting"
+	"awsx-api/appstate"
+	"awsx-api/cache"
 	"awsx-api/config"
+	"awsx-api/handlers"
+	"awsx-api/internalmetrics"
 	"awsx-api/log"
+	"awsx-api/logsinsights"
+	"awsx-api/notifier"
 	"awsx-api/server"
+	"awsx-api/sla"
+	"awsx-api/status"
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"path"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"regexp"
 	"strings"
 )
 
-// Identifies the build. These are set via ldflags during the build (see Makefile).
-// var (
-// 	version    = "unknown"
-// 	commitHash = "unknown"
-// )
+// Identifies the build. These are set via ldflags during the build, e.g.
+// go build -ldflags "-X main.version=1.2.0 -X main.commitHash=$(git rev-parse HEAD)"
+var (
+	version    = "unknown"
+	commitHash = "unknown"
+)
 
 // Command line arguments
 var (
@@ -57,8 +71,7 @@ func main() {
 	validateFlags()
 
 	// log startup information
-	//log.Infof("Kiali: Version: %v, Commit: %v\n", version, commitHash)
-	log.Infof("Starting server")
+	log.Infof("Starting server. Version: %v, Commit: %v", version, commitHash)
 	log.Debugf("awsx-api: command line: [%v]", strings.Join(os.Args, " "))
 	homePath, err := filepath.Abs(".")
 	if err != nil {
@@ -83,20 +96,20 @@ func main() {
 		}
 		config.Set(c)
 	} else {
-		//log.Infof("No configuration file specified. Will rely on environment for configuration.")
-		//config.Set(config.NewConfig())
+		log.Infof("No configuration file specified. Will rely on defaults for configuration.")
+		config.Set(config.NewConfig())
 	}
 
 	cfg := config.Get()
-	log.Tracef("awsx-api configuration:\n%s", cfg)
+	log.Tracef("awsx-api configuration:\n%+v", cfg)
 
-	// if err := validateConfig(); err != nil {
-	// 	log.Fatal(err)
-	// }
+	if err := validateConfig(); err != nil {
+		log.Fatal(err)
+	}
 
-	// status.Put(status.CoreVersion, version)
-	// status.Put(status.CoreCommitHash, commitHash)
-	// status.Put(status.ContainerVersion, determineContainerVersion(version))
+	status.Put(status.CoreVersion, version)
+	status.Put(status.CoreCommitHash, commitHash)
+	status.SetConfigLoaded()
 
 	// CheckVersionCompatibility check kiali version compatibility with mesh.
 	// The user session is not affected no matter what this check returns, just warning logs.
@@ -106,19 +119,91 @@ func main() {
 	// authentication.InitializeAuthenticationController(cfg.Auth.Strategy)
 
 	// prepare our internal metrics so Prometheus can scrape them
-	// internalmetrics.RegisterInternalMetrics()
+	internalmetrics.RegisterInternalMetrics()
+	cache.RegisterClientDecorator(internalmetrics.InstrumentAwsClient)
+	internalmetrics.SetPanelLookup(func(elementType, query string) bool {
+		_, ok := handlers.Panels().Lookup(elementType, query)
+		return ok
+	})
+
+	// Track Logs Insights queries so that shutdown can stop the ones still running. They are
+	// stopped when draining starts, the hook stops those started while draining
+	logsinsights.Register()
+	appstate.OnShutdown("logs-insights-queries", logsinsights.CancelAll)
+	// Nothing else to flush: audit records are written unbuffered, and the internal metrics
+	// are pulled by Prometheus from the scrape endpoint rather than pushed to a sink
+
+	// Check CMDB and vault in the background, readiness reports their last known state
+	checksCtx, stopChecks := context.WithCancel(context.Background())
+	status.StartDependencyChecks(checksCtx)
+	appstate.OnShutdown("dependency-checks", func(context.Context) error {
+		stopChecks()
+		return nil
+	})
+
+	// Evaluate the alerting rules in the background
+	if cfg.Server.Alerting.Enabled {
+		alertingCtx, stopAlerting := context.WithCancel(context.Background())
+		dispatcher, err := notifier.NewDispatcher(cfg.Server.Alerting)
+		if err != nil {
+			log.Fatal(err)
+		}
+		dispatcher.Start(alertingCtx)
+		if err := alerting.Start(alertingCtx, cfg.Server.Alerting, dispatcher.Dispatch); err != nil {
+			log.Fatal(err)
+		}
+		appstate.OnShutdown("alerting", func(context.Context) error {
+			stopAlerting()
+			dispatcher.Wait()
+			return nil
+		})
+	}
+
+	// Read the service level objectives of /awsx-api/getSlaDetails
+	if err := sla.Load(cfg.Server.SLA.SLOFiles); err != nil {
+		log.Fatal(err)
+	}
 
 	// Start listening to requests
 	server := server.NewServer()
 	server.Start()
 
+	// The admin endpoint stays up while draining, to watch the in-flight requests
+	if adminServer := admin.NewServer(); adminServer != nil {
+		adminServer.Start()
+		appstate.OnShutdown("admin-endpoint", func(ctx context.Context) error {
+			adminServer.Stop(ctx)
+			return nil
+		})
+	}
+
 	// wait forever, or at least until we are told to exit
 	log.Infof("server started. wait forever to terminate")
 	waitForTermination()
 
-	// Shutdown internal components
-	// log.Info("Shutting down internal components")
-	server.Stop()
+	// The whole shutdown has to fit in shutdown_timeout, Kubernetes kills the pod once its
+	// terminationGracePeriodSeconds (30 by default) are over
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout)*time.Second)
+	defer cancelShutdown()
+
+	// Fail readiness first, so that no new requests are routed to us while we drain
+	appstate.SetDraining(true)
+	if delay := time.Duration(cfg.Server.ShutdownDrainDelay) * time.Second; delay > 0 {
+		log.Infof("Readiness failing, waiting %v before draining in-flight requests", delay)
+		time.Sleep(delay)
+	}
+
+	// Requests polling a Logs Insights query would hold up the drain until the grace period
+	// ends, their queries are stopped first
+	logsinsights.CancelAll(shutdownCtx)
+	ctx, cancel := context.WithTimeout(shutdownCtx, time.Duration(cfg.Server.ShutdownGracePeriod)*time.Second)
+	defer cancel()
+	server.Stop(ctx)
+
+	// Shutdown internal components, with what is left of shutdown_timeout
+	log.Info("Shutting down internal components")
+	appstate.RunShutdownHooks(shutdownCtx)
+	log.Info("Shutdown complete")
 }
 
 func waitForTermination() {
@@ -126,35 +211,49 @@ func waitForTermination() {
 	// TODO: may want to make this a package variable - other things might want to tell us to exit
 	var doneChan = make(chan bool)
 
+	// Kubernetes sends SIGTERM, an interactive ctrl-c sends SIGINT
 	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 	go func() {
-		for range signalChan {
-			log.Info("Termination Signal Received")
-			doneChan <- true
-		}
+		sig := <-signalChan
+		log.Infof("Termination Signal Received [%v]", sig)
+		// A second signal skips the graceful shutdown
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+		doneChan <- true
 	}()
 
 	<-doneChan
 }
 
 func validateConfig() error {
-	// cfg := config.Get()
+	cfg := config.Get()
 
-	// if cfg.Server.Port < 0 {
-	// 	return fmt.Errorf("server port is negative: %v", cfg.Server.Port)
-	// }
+	if cfg.Server.Port < 0 {
+		return fmt.Errorf("server port is negative: %v", cfg.Server.Port)
+	}
 
-	// if strings.Contains(cfg.Server.StaticContentRootDirectory, "..") {
-	// 	return fmt.Errorf("server static content root directory must not contain '..': %v", cfg.Server.StaticContentRootDirectory)
-	// }
-	// if _, err := os.Stat(cfg.Server.StaticContentRootDirectory); os.IsNotExist(err) {
-	// 	return fmt.Errorf("server static content root directory does not exist: %v", cfg.Server.StaticContentRootDirectory)
-	// }
+	// A missing directory only disables the web console, the API is served without it
+	if strings.Contains(cfg.Server.StaticContentRootDirectory, "..") {
+		return fmt.Errorf("server static content root directory must not contain '..': %v", cfg.Server.StaticContentRootDirectory)
+	}
+	if mode := cfg.Server.WebHistoryMode; mode != "" && mode != "browser" && mode != "hash" {
+		return fmt.Errorf("web history mode must be browser or hash: %v", mode)
+	}
+
+	if cfg.Server.ShutdownDrainDelay < 0 || cfg.Server.ShutdownGracePeriod < 0 {
+		return fmt.Errorf("shutdown drain delay and grace period must not be negative")
+	}
+	if cfg.Server.ShutdownDrainDelay+cfg.Server.ShutdownGracePeriod >= cfg.Server.ShutdownTimeout {
+		return fmt.Errorf("shutdown drain delay (%ds) and grace period (%ds) must leave time for the shutdown hooks within the shutdown timeout (%ds)",
+			cfg.Server.ShutdownDrainDelay, cfg.Server.ShutdownGracePeriod, cfg.Server.ShutdownTimeout)
+	}
+
+	if err := server.ValidateCORS(cfg.Server); err != nil {
+		return err
+	}
 
 	validPathRegEx := regexp.MustCompile(`^\/[a-zA-Z0-9\-\._~!\$&\'()\*\+\,;=:@%/]*$`)
-	// webRoot := cfg.Server.WebRoot
-	webRoot := "/"
+	webRoot := cfg.Server.WebRoot
 	if !validPathRegEx.MatchString(webRoot) {
 		return fmt.Errorf("web root must begin with a / and contain valid URL path characters: %v", webRoot)
 	}
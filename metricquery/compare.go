@@ -0,0 +1,129 @@
+package metricquery
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// CompareToPrevious is the compareTo value of the window right before the queried one.
+const CompareToPrevious = "previous"
+
+// The summary stats of a series, named like the json output of the panel library.
+const (
+	CurrentUsage = "CurrentUsage"
+	AverageUsage = "AverageUsage"
+	MaxUsage     = "MaxUsage"
+	MinUsage     = "MinUsage"
+)
+
+// Summary holds the summary stats of a series by name.
+type Summary map[string]float64
+
+// Comparison is the summary of every metric of a panel in the queried and the shifted window,
+// with the change from the shifted to the queried window. DeltaPercent leaves out stats that
+// were 0 in the shifted window.
+type Comparison struct {
+	Current      map[string]Summary `json:"current"`
+	Previous     map[string]Summary `json:"previous"`
+	Delta        map[string]Summary `json:"delta"`
+	DeltaPercent map[string]Summary `json:"deltaPercent"`
+}
+
+// Shift is how far back the window of compareTo lies: 1w, 1d or any number of h, d or w, or
+// previous for the window right before start..end. The shift is a whole number of periods,
+// so the data points of both windows fall on the same timestamps once shifted back.
+func Shift(compareTo string, start, end time.Time, period int64) (time.Duration, error) {
+	var shift time.Duration
+	if compareTo == CompareToPrevious {
+		shift = end.Sub(start)
+	} else {
+		if len(compareTo) < 2 {
+			return 0, fmt.Errorf("invalid compareTo [%s], expected 1w, 1d or previous", compareTo)
+		}
+		n, err := strconv.Atoi(compareTo[:len(compareTo)-1])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid compareTo [%s], expected 1w, 1d or previous", compareTo)
+		}
+		switch compareTo[len(compareTo)-1] {
+		case 'h':
+			shift = time.Duration(n) * time.Hour
+		case 'd':
+			shift = time.Duration(n) * 24 * time.Hour
+		case 'w':
+			shift = time.Duration(n) * 7 * 24 * time.Hour
+		default:
+			return 0, fmt.Errorf("invalid compareTo [%s], expected 1w, 1d or previous", compareTo)
+		}
+	}
+	step := time.Duration(period) * time.Second
+	if step > 0 && shift%step != 0 {
+		shift = (shift/step + 1) * step
+	}
+	return shift, nil
+}
+
+// Align moves the timestamps of the shifted window forward by shift, onto the timestamps of
+// the queried window.
+func Align(output map[string]*cloudwatch.GetMetricDataOutput, shift time.Duration) {
+	for _, data := range output {
+		for _, result := range data.MetricDataResults {
+			for i, t := range result.Timestamps {
+				result.Timestamps[i] = aws.Time(aws.TimeValue(t).Add(shift))
+			}
+		}
+	}
+}
+
+// Summarize computes the summary stats of the first result of every metric. Metrics without
+// data points have no summary.
+func Summarize(output map[string]*cloudwatch.GetMetricDataOutput) map[string]Summary {
+	summaries := map[string]Summary{}
+	for key, data := range output {
+		if len(data.MetricDataResults) == 0 || len(data.MetricDataResults[0].Values) == 0 {
+			continue
+		}
+		values := aws.Float64ValueSlice(data.MetricDataResults[0].Values)
+		summary := Summary{CurrentUsage: values[0], MaxUsage: math.Inf(-1), MinUsage: math.Inf(1)}
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+			summary[MaxUsage] = math.Max(summary[MaxUsage], v)
+			summary[MinUsage] = math.Min(summary[MinUsage], v)
+		}
+		summary[AverageUsage] = sum / float64(len(values))
+		summaries[key] = summary
+	}
+	return summaries
+}
+
+// Compare summarizes the queried and the shifted window, newest value first in both, and the
+// change between them for the metrics that have data in both windows.
+func Compare(current, previous map[string]*cloudwatch.GetMetricDataOutput) Comparison {
+	comparison := Comparison{
+		Current:      Summarize(current),
+		Previous:     Summarize(previous),
+		Delta:        map[string]Summary{},
+		DeltaPercent: map[string]Summary{},
+	}
+	for key, now := range comparison.Current {
+		before, ok := comparison.Previous[key]
+		if !ok {
+			continue
+		}
+		delta, percent := Summary{}, Summary{}
+		for stat, value := range now {
+			delta[stat] = value - before[stat]
+			if before[stat] != 0 {
+				percent[stat] = (value - before[stat]) / math.Abs(before[stat]) * 100
+			}
+		}
+		comparison.Delta[key] = delta
+		comparison.DeltaPercent[key] = percent
+	}
+	return comparison
+}
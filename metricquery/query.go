@@ -0,0 +1,74 @@
+package metricquery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// Query is a CloudWatch query of the metrics of a panel for one element.
+type Query struct {
+	Spec Spec
+	// Element is the value of the dimension, e.g. the instance id.
+	Element string
+	Start   time.Time
+	End     time.Time
+	// Period in seconds.
+	Period int64
+	Stat   string
+}
+
+// Run fetches all metrics of the query with a single GetMetricData request, following the
+// pagination. The results are keyed by Metric.Key, in the shape the panel library uses for
+// frame responses, newest value first.
+func Run(ctx context.Context, client *cloudwatch.CloudWatch, q Query) (map[string]*cloudwatch.GetMetricDataOutput, error) {
+	input := &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(q.Start),
+		EndTime:   aws.Time(q.End),
+		ScanBy:    aws.String(cloudwatch.ScanByTimestampDescending),
+	}
+	keys := map[string]string{}
+	for i, m := range q.Spec.Metrics {
+		id := fmt.Sprintf("m%d", i)
+		keys[id] = m.Key
+		input.MetricDataQueries = append(input.MetricDataQueries, &cloudwatch.MetricDataQuery{
+			Id:    aws.String(id),
+			Label: aws.String(m.Key),
+			MetricStat: &cloudwatch.MetricStat{
+				Metric: &cloudwatch.Metric{
+					Namespace:  aws.String(m.Namespace),
+					MetricName: aws.String(m.Name),
+					Dimensions: []*cloudwatch.Dimension{{Name: aws.String(q.Spec.DimensionOf(m)), Value: aws.String(q.Element)}},
+				},
+				Period: aws.Int64(q.Period),
+				Stat:   aws.String(q.Stat),
+			},
+		})
+	}
+
+	results := map[string]*cloudwatch.MetricDataResult{}
+	err := client.GetMetricDataPagesWithContext(ctx, input, func(page *cloudwatch.GetMetricDataOutput, _ bool) bool {
+		for _, result := range page.MetricDataResults {
+			id := aws.StringValue(result.Id)
+			if merged, ok := results[id]; ok {
+				merged.Timestamps = append(merged.Timestamps, result.Timestamps...)
+				merged.Values = append(merged.Values, result.Values...)
+				continue
+			}
+			results[id] = result
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := map[string]*cloudwatch.GetMetricDataOutput{}
+	for id, result := range results {
+		output[keys[id]] = &cloudwatch.GetMetricDataOutput{MetricDataResults: []*cloudwatch.MetricDataResult{result}}
+	}
+	return output, nil
+}
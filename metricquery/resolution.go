@@ -0,0 +1,63 @@
+package metricquery
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultPeriod is the period, in seconds, of a query that asks for neither a period nor a
+// number of points. It is the period of the panel library.
+const DefaultPeriod = 300
+
+// CloudWatch keeps 1 minute data points for 15 days, 5 minute data points for 63 days and
+// 1 hour data points for 455 days. A period below the resolution that is left for the start
+// of a query returns no data.
+var retentionTiers = []struct {
+	age    time.Duration
+	period int64
+}{
+	{15 * 24 * time.Hour, 60},
+	{63 * 24 * time.Hour, 300},
+}
+
+const oldestPeriod = 3600
+
+// MinimumPeriod is the finest period CloudWatch still has for data points at start.
+func MinimumPeriod(start, now time.Time) int64 {
+	age := now.Sub(start)
+	for _, tier := range retentionTiers {
+		if age <= tier.age {
+			return tier.period
+		}
+	}
+	return oldestPeriod
+}
+
+// Period picks the period of a query from the period and maxDataPoints parameters, 0 when not
+// given. A requested period is kept, otherwise the period spreads the time range over
+// maxDataPoints points, or is DefaultPeriod. Either way it is raised to the minimum period of
+// the retention tier of start and rounded up to a multiple of it.
+func Period(start, end, now time.Time, requested int64, maxDataPoints int) (int64, error) {
+	if requested < 0 || requested%60 != 0 {
+		return 0, fmt.Errorf("invalid period [%d], it must be a multiple of 60 seconds", requested)
+	}
+	if maxDataPoints < 0 {
+		return 0, fmt.Errorf("invalid maxDataPoints [%d]", maxDataPoints)
+	}
+	period := requested
+	if period == 0 {
+		period = DefaultPeriod
+		if maxDataPoints > 0 {
+			period = int64(math.Ceil(end.Sub(start).Seconds() / float64(maxDataPoints)))
+		}
+	}
+	minimum := MinimumPeriod(start, now)
+	if period < minimum {
+		period = minimum
+	}
+	if period%minimum != 0 {
+		period = (period/minimum + 1) * minimum
+	}
+	return period, nil
+}
@@ -0,0 +1,65 @@
+package metricquery
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMinimumPeriod(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	tests := []struct {
+		age  time.Duration
+		want int64
+	}{
+		{0, 60},
+		{15 * day, 60},
+		{15*day + time.Second, 300},
+		{63 * day, 300},
+		{63*day + time.Second, 3600},
+		{400 * day, 3600},
+	}
+	for _, test := range tests {
+		if got := MinimumPeriod(now.Add(-test.age), now); got != test.want {
+			t.Errorf("MinimumPeriod(now-%s) = %d, want %d", test.age, got, test.want)
+		}
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	tests := []struct {
+		name          string
+		age, length   time.Duration
+		requested     int64
+		maxDataPoints int
+		want          int64
+		wantErr       bool
+	}{
+		{"default", 6 * time.Hour, 6 * time.Hour, 0, 0, DefaultPeriod, false},
+		{"requested", 6 * time.Hour, 6 * time.Hour, 60, 0, 60, false},
+		{"requested wins over maxDataPoints", 6 * time.Hour, 6 * time.Hour, 600, 10, 600, false},
+		{"maxDataPoints raised to a minute", 6 * time.Hour, 6 * time.Hour, 0, 1000, 60, false},
+		{"maxDataPoints rounded up", 6 * time.Hour, 6 * time.Hour, 0, 100, 240, false},
+		{"raised to the 5 minute tier", 20 * day, 6 * time.Hour, 60, 0, 300, false},
+		{"rounded up in the 5 minute tier", 20 * day, 6 * time.Hour, 420, 0, 600, false},
+		{"default in the 1 hour tier", 100 * day, 6 * time.Hour, 0, 0, 3600, false},
+		{"rounded up in the 1 hour tier", 100 * day, day, 5400, 0, 7200, false},
+		{"period not in minutes", 6 * time.Hour, 6 * time.Hour, 90, 0, 0, true},
+		{"negative period", 6 * time.Hour, 6 * time.Hour, -60, 0, 0, true},
+		{"negative maxDataPoints", 6 * time.Hour, 6 * time.Hour, 0, -1, 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := now.Add(-test.age)
+			got, err := Period(start, start.Add(test.length), now, test.requested, test.maxDataPoints)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Period() error = %v, want error %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("Period() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
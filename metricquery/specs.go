@@ -0,0 +1,210 @@
+package metricquery
+
+import (
+	"strings"
+)
+
+// Metric is a CloudWatch metric of a panel.
+type Metric struct {
+	// Key names the metric in the panel response, e.g. InboundTraffic.
+	Key       string
+	Namespace string
+	Name      string
+	// Dimension overrides the dimension of the element type, e.g. function_name for Lambda
+	// Insights.
+	Dimension string
+}
+
+// Spec is the CloudWatch query behind a metric panel.
+type Spec struct {
+	// Dimension identifies the element, e.g. InstanceId for EC2.
+	Dimension string
+	Metrics   []Metric
+	// Counter marks metrics that count events or bytes. Extended statistics of a counter are
+	// meaningless and rejected.
+	Counter bool
+}
+
+// DimensionOf is the dimension a metric is queried with.
+func (s Spec) DimensionOf(m Metric) string {
+	if m.Dimension != "" {
+		return m.Dimension
+	}
+	return s.Dimension
+}
+
+// dimensions are the CloudWatch dimensions of the element types.
+var dimensions = map[string]string{
+	"EC2":        "InstanceId",
+	"EKS":        "ClusterName",
+	"ECS":        "ClusterName",
+	"LAMBDA":     "FunctionName",
+	"RDS":        "DBInstanceIdentifier",
+	"APIGATEWAY": "ApiName",
+	"NLB":        "LoadBalancer",
+}
+
+const (
+	nsEC2               = "AWS/EC2"
+	nsCWAgent           = "CWAgent"
+	nsContainerInsights = "ContainerInsights"
+	nsECS               = "AWS/ECS"
+	nsECSInsights       = "ECS/ContainerInsights"
+	nsLambda            = "AWS/Lambda"
+	nsLambdaInsights    = "LambdaInsights"
+	nsRDS               = "AWS/RDS"
+	nsApiGateway        = "AWS/ApiGateway"
+	nsNetworkELB        = "AWS/NetworkELB"
+)
+
+// specs are the metric panels by canonical element type and query. Panels that derive their
+// values (uptime percentages, error rates, event counts), read logs or read metrics that
+// CloudWatch only has per disk or device (CWAgent disk_used) have no spec. The namespaces are
+// the ones CloudWatch publishes the metrics in, which is not always the one the panel library
+// queries (CWAgent memory metrics, Container Insights node metrics).
+var specs = map[string]Spec{
+	"EC2/cpu_utilization_panel":          {Metrics: []Metric{metric(nsEC2, "CPUUtilization")}},
+	"EC2/cpu_utilization_graph_panel":    {Metrics: []Metric{metric(nsEC2, "CPUUtilization")}},
+	"EC2/memory_utilization_panel":       {Metrics: []Metric{metric(nsCWAgent, "mem_used_percent")}},
+	"EC2/memory_utilization_graph_panel": {Metrics: []Metric{metric(nsCWAgent, "mem_used_percent")}},
+	"EC2/cpu_usage_user_panel":           {Metrics: []Metric{metric(nsCWAgent, "cpu_usage_user")}},
+	"EC2/cpu_usage_sys_panel":            {Metrics: []Metric{metric(nsCWAgent, "cpu_usage_system")}},
+	"EC2/cpu_usage_nice_panel":           {Metrics: []Metric{metric(nsCWAgent, "cpu_usage_nice")}},
+	"EC2/cpu_usage_idle_panel":           {Metrics: []Metric{metric(nsCWAgent, "cpu_usage_idle")}},
+	"EC2/mem_usage_free_panel":           {Metrics: []Metric{metric(nsCWAgent, "mem_free")}},
+	"EC2/mem_cached_panel":               {Metrics: []Metric{metric(nsCWAgent, "mem_cached")}},
+	"EC2/mem_usage_total_panel":          {Metrics: []Metric{metric(nsCWAgent, "mem_total")}},
+	"EC2/mem_usage_used_panel":           {Metrics: []Metric{metric(nsCWAgent, "mem_used")}},
+	"EC2/disk_reads_panel":               {Metrics: []Metric{metric(nsEC2, "DiskReadBytes")}, Counter: true},
+	"EC2/disk_writes_panel":              {Metrics: []Metric{metric(nsEC2, "DiskWriteBytes")}, Counter: true},
+	"EC2/disk_io_panel":                  {Metrics: []Metric{metric(nsEC2, "DiskReadBytes"), metric(nsEC2, "DiskWriteBytes")}, Counter: true},
+	"EC2/net_inpackets_panel":            {Metrics: []Metric{metric(nsEC2, "NetworkPacketsIn")}, Counter: true},
+	"EC2/net_outpackets_panel":           {Metrics: []Metric{metric(nsEC2, "NetworkPacketsOut")}, Counter: true},
+	"EC2/net_inbytes_panel":              {Metrics: []Metric{metric(nsEC2, "NetworkIn")}, Counter: true},
+	"EC2/net_outbytes_panel":             {Metrics: []Metric{metric(nsEC2, "NetworkOut")}, Counter: true},
+	"EC2/network_inbound_panel":          {Metrics: []Metric{metric(nsEC2, "NetworkIn")}, Counter: true},
+	"EC2/network_outbound_panel":         {Metrics: []Metric{metric(nsEC2, "NetworkOut")}, Counter: true},
+	"EC2/net_throughput_panel":           {Metrics: []Metric{metric(nsEC2, "NetworkIn"), metric(nsEC2, "NetworkOut")}, Counter: true},
+	"EC2/network_utilization_panel": {Metrics: []Metric{
+		keyed("InboundTraffic", nsEC2, "NetworkIn"),
+		keyed("OutboundTraffic", nsEC2, "NetworkOut"),
+	}, Counter: true},
+
+	"EKS/cpu_utilization_panel":               {Metrics: []Metric{metric(nsContainerInsights, "node_cpu_utilization")}},
+	"EKS/cpu_node_utilization_panel":          {Metrics: []Metric{metric(nsContainerInsights, "node_cpu_utilization")}},
+	"EKS/resource_utilization_patterns_panel": {Metrics: []Metric{metric(nsContainerInsights, "node_cpu_utilization")}},
+	"EKS/cpu_graph_utilization_panel":         {Metrics: []Metric{metric(nsContainerInsights, "pod_cpu_utilization")}},
+	"EKS/memory_utilization_panel":            {Metrics: []Metric{metric(nsContainerInsights, "node_memory_utilization")}},
+	"EKS/memory_usage_panel":                  {Metrics: []Metric{metric(nsContainerInsights, "node_memory_utilization")}},
+	"EKS/memory_graph_utilization_panel":      {Metrics: []Metric{metric(nsContainerInsights, "pod_memory_utilization")}},
+	"EKS/allocatable_cpu_panel":               {Metrics: []Metric{metric(nsContainerInsights, "node_cpu_limit")}},
+	"EKS/cpu_limits_panel":                    {Metrics: []Metric{metric(nsContainerInsights, "pod_cpu_limit")}},
+	"EKS/cpu_requests_panel":                  {Metrics: []Metric{metric(nsContainerInsights, "pod_cpu_request")}},
+	"EKS/memory_limits_panel":                 {Metrics: []Metric{metric(nsContainerInsights, "pod_memory_limit")}},
+	"EKS/memory_requests_panel":               {Metrics: []Metric{metric(nsContainerInsights, "pod_memory_request")}},
+	"EKS/disk_utilization_panel":              {Metrics: []Metric{metric(nsContainerInsights, "node_filesystem_utilization")}},
+	"EKS/disk_io_performance_panel":           {Metrics: []Metric{metric(nsContainerInsights, "node_diskio_io_serviced_total")}},
+	"EKS/network_in_out_panel":                {Metrics: []Metric{metric(nsContainerInsights, "node_network_total_bytes")}},
+	"EKS/network_utilization_panel": {Metrics: []Metric{
+		metric(nsContainerInsights, "pod_network_rx_bytes"),
+		metric(nsContainerInsights, "pod_network_tx_bytes"),
+	}},
+
+	"ECS/cpu_utilization_panel":    {Metrics: []Metric{metric(nsECS, "CPUUtilization")}},
+	"ECS/memory_utilization_panel": {Metrics: []Metric{metric(nsECS, "MemoryUtilization")}},
+	"ECS/cpu_reservation_panel":    {Metrics: []Metric{metric(nsECSInsights, "CpuReserved")}},
+	"ECS/memory_reservation_panel": {Metrics: []Metric{metric(nsECSInsights, "MemoryReserved")}},
+	"ECS/network_utilization_panel": {Metrics: []Metric{
+		keyed("InboundTraffic", nsECSInsights, "NetworkRxBytes"),
+		keyed("OutboundTraffic", nsECSInsights, "NetworkTxBytes"),
+	}, Counter: true},
+
+	"LAMBDA/execution_time_panel":        {Metrics: []Metric{metric(nsLambda, "Duration")}},
+	"LAMBDA/concurrency_panel":           {Metrics: []Metric{metric(nsLambda, "ConcurrentExecutions")}},
+	"LAMBDA/throttles_panel":             {Metrics: []Metric{metric(nsLambda, "Throttles")}, Counter: true},
+	"LAMBDA/number_of_calls_panel":       {Metrics: []Metric{metric(nsLambda, "Invocations")}, Counter: true},
+	"LAMBDA/max_memory_used_panel":       {Metrics: []Metric{lambdaInsights("used_memory_max")}},
+	"LAMBDA/max_memory_used_graph_panel": {Metrics: []Metric{lambdaInsights("used_memory_max")}},
+	"LAMBDA/cold_start_duration_panel":   {Metrics: []Metric{lambdaInsights("init_duration")}},
+	"LAMBDA/used_and_unused_memory_data_panel": {Metrics: []Metric{
+		lambdaInsights("total_memory"),
+		lambdaInsights("used_memory_max"),
+	}},
+
+	"RDS/cpu_utilization_panel":             {Metrics: []Metric{metric(nsRDS, "CPUUtilization")}},
+	"RDS/cpu_utilization_graph_panel":       {Metrics: []Metric{metric(nsRDS, "CPUUtilization")}},
+	"RDS/freeable_memory_panel":             {Metrics: []Metric{metric(nsRDS, "FreeableMemory")}},
+	"RDS/cpu_credit_balance_panel":          {Metrics: []Metric{metric(nsRDS, "CPUCreditBalance")}},
+	"RDS/cpu_credit_usage_panel":            {Metrics: []Metric{metric(nsRDS, "CPUCreditUsage")}},
+	"RDS/cpu_surplus_credit_balance_panel":  {Metrics: []Metric{metric(nsRDS, "CPUSurplusCreditBalance")}},
+	"RDS/cpu_surplus_credits_charged_panel": {Metrics: []Metric{metric(nsRDS, "CPUSurplusCreditsCharged")}},
+	"RDS/database_connections_panel":        {Metrics: []Metric{metric(nsRDS, "DatabaseConnections")}},
+	"RDS/database_workload_overview_panel":  {Metrics: []Metric{metric(nsRDS, "DBLoad")}},
+	"RDS/db_load_cpu_panel":                 {Metrics: []Metric{metric(nsRDS, "DBLoadCPU")}},
+	"RDS/db_load_non_cpu_panel":             {Metrics: []Metric{metric(nsRDS, "DBLoadNonCPU")}},
+	"RDS/disk_queue_depth_panel":            {Metrics: []Metric{metric(nsRDS, "DiskQueueDepth")}},
+	"RDS/free_storage_space_panel":          {Metrics: []Metric{metric(nsRDS, "FreeStorageSpace")}},
+	"RDS/read_iops_panel":                   {Metrics: []Metric{metric(nsRDS, "ReadIOPS")}},
+	"RDS/write_iops_panel":                  {Metrics: []Metric{metric(nsRDS, "WriteIOPS")}},
+	"RDS/iops_panel":                        {Metrics: []Metric{keyed("Read", nsRDS, "ReadIOPS"), keyed("Write", nsRDS, "WriteIOPS")}},
+	"RDS/latency_analysis_panel":            {Metrics: []Metric{metric(nsRDS, "ReadLatency"), metric(nsRDS, "WriteLatency")}},
+	"RDS/network_receive_throughput_panel":  {Metrics: []Metric{metric(nsRDS, "NetworkReceiveThroughput")}},
+	"RDS/network_transmit_throughput_panel": {Metrics: []Metric{metric(nsRDS, "NetworkTransmitThroughput")}},
+	"RDS/replication_slot_disk_usage":       {Metrics: []Metric{metric(nsRDS, "ReplicationSlotDiskUsage")}},
+	"RDS/transaction_logs_generation_panel": {Metrics: []Metric{metric(nsRDS, "TransactionLogsGeneration")}},
+	"RDS/transaction_logs_disk_usage_panel": {Metrics: []Metric{metric(nsRDS, "TransactionLogsDiskUsage")}},
+	"RDS/network_utilization_panel": {Metrics: []Metric{
+		keyed("InboundTraffic", nsRDS, "NetworkReceiveThroughput"),
+		keyed("OutboundTraffic", nsRDS, "NetworkTransmitThroughput"),
+	}},
+
+	"APIGATEWAY/latency_panel":             {Metrics: []Metric{metric(nsApiGateway, "Latency")}},
+	"APIGATEWAY/response_time_panel":       {Metrics: []Metric{metric(nsApiGateway, "Latency")}},
+	"APIGATEWAY/integration_latency_panel": {Metrics: []Metric{metric(nsApiGateway, "IntegrationLatency")}},
+	"APIGATEWAY/4xx_errors_panel":          {Metrics: []Metric{metric(nsApiGateway, "4XXError")}, Counter: true},
+	"APIGATEWAY/5xx_errors_panel":          {Metrics: []Metric{metric(nsApiGateway, "5XXError")}, Counter: true},
+	"APIGATEWAY/total_api_calls_panel":     {Metrics: []Metric{metric(nsApiGateway, "Count")}, Counter: true},
+	"APIGATEWAY/cache_hit_count_panel":     {Metrics: []Metric{metric(nsApiGateway, "CacheHitCount")}, Counter: true},
+	"APIGATEWAY/cache_miss_count_panel":    {Metrics: []Metric{metric(nsApiGateway, "CacheMissCount")}, Counter: true},
+
+	"NLB/active_connections_panel": {Metrics: []Metric{metric(nsNetworkELB, "ActiveFlowCount")}},
+	"NLB/healthy_host_count_panel": {Metrics: []Metric{metric(nsNetworkELB, "HealthyHostCount")}},
+	"NLB/new_connections_panel":    {Metrics: []Metric{metric(nsNetworkELB, "NewFlowCount")}, Counter: true},
+	"NLB/new_flow_count_tls_panel": {Metrics: []Metric{metric(nsNetworkELB, "NewFlowCount_TLS")}, Counter: true},
+	"NLB/processed_bytes_panel":    {Metrics: []Metric{metric(nsNetworkELB, "ProcessedBytes")}, Counter: true},
+}
+
+func metric(namespace, name string) Metric {
+	return Metric{Key: name, Namespace: namespace, Name: name}
+}
+
+func keyed(key, namespace, name string) Metric {
+	return Metric{Key: key, Namespace: namespace, Name: name}
+}
+
+// lambdaInsights is a Lambda Insights metric, dimensioned by function_name.
+func lambdaInsights(name string) Metric {
+	return Metric{Key: name, Namespace: nsLambdaInsights, Name: name, Dimension: "function_name"}
+}
+
+// ElementType is the canonical form of an elementType parameter: APIGATEWAY for ApiGateway and
+// AWS/ApiGateway, NLB for AWS/NetworkELB.
+func ElementType(elementType string) string {
+	canonical := strings.TrimPrefix(strings.ToUpper(elementType), "AWS/")
+	if canonical == "NETWORKELB" {
+		return "NLB"
+	}
+	return canonical
+}
+
+// SpecOf returns the CloudWatch query of a panel, false when the panel is not a plain metric
+// panel.
+func SpecOf(elementType, query string) (Spec, bool) {
+	canonical := ElementType(elementType)
+	spec, ok := specs[canonical+"/"+query]
+	if !ok {
+		return Spec{}, false
+	}
+	spec.Dimension = dimensions[canonical]
+	return spec, true
+}
@@ -0,0 +1,43 @@
+package metricquery
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// standardStats are the CloudWatch statistics every metric has, by lower case name.
+var standardStats = map[string]string{
+	"average":     "Average",
+	"minimum":     "Minimum",
+	"maximum":     "Maximum",
+	"sum":         "Sum",
+	"samplecount": "SampleCount",
+}
+
+// extendedStat matches the single value extended statistics: percentiles (p99), trimmed mean
+// (tm99), trimmed count (tc99), trimmed sum (ts99) and winsorized mean (wm99).
+var extendedStat = regexp.MustCompile(`^(p|tm|tc|ts|wm)([0-9]+(\.[0-9]+)?)$`)
+
+// ParseStat validates a stat parameter and returns it as CloudWatch expects it, e.g.
+// "average" is Average and "P99" is p99.
+func ParseStat(stat string) (string, error) {
+	if standard, ok := standardStats[strings.ToLower(stat)]; ok {
+		return standard, nil
+	}
+	m := extendedStat.FindStringSubmatch(strings.ToLower(stat))
+	if m == nil {
+		return "", fmt.Errorf("invalid stat [%s], expected Average, Minimum, Maximum, Sum, SampleCount or an extended statistic such as p99 or tm99", stat)
+	}
+	if value, _ := strconv.ParseFloat(m[2], 64); value <= 0 || value > 100 {
+		return "", fmt.Errorf("invalid stat [%s], the percentage must be above 0 and at most 100", stat)
+	}
+	return m[0], nil
+}
+
+// IsExtended reports whether a parsed stat is an extended statistic.
+func IsExtended(stat string) bool {
+	_, ok := standardStats[strings.ToLower(stat)]
+	return !ok
+}
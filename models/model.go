@@ -21,5 +21,5 @@ type CloudElement struct {
 	Id        int64                                           `json:"id,omitempty"`
 	Name      string                                          `json:"name,omitempty"`
 	AccountId string                                          `json:"accountId,omitempty"`
-	ViewJson  configservice.GetDiscoveredResourceCountsOutput `json:"viewJson, omitempty"`
+	ViewJson  configservice.GetDiscoveredResourceCountsOutput `json:"viewJson,omitempty"`
 }
@@ -0,0 +1,428 @@
+package notifier
+
+import (
+	"awsx-api/alerting"
+	"awsx-api/config"
+	"awsx-api/log"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	statusFiring   = "firing"
+	statusResolved = "resolved"
+
+	defaultTitle = `[{{.Status | upper}}{{with .Firing}}:{{len .}}{{end}}] {{.GroupLabels.alertname}}`
+	defaultBody  = `{{range .Alerts}}{{.State | upper}} {{.Rule}} on {{.Labels.element}}{{with .Labels.series}} ({{.}}){{end}}: {{.Value}}{{with .Annotations.summary}} - {{.}}{{end}}
+{{end}}`
+)
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// NotifiedAlert is an alert in a notification. Its fingerprint identifies it by its labels.
+type NotifiedAlert struct {
+	alerting.Alert
+	Fingerprint string `json:"fingerprint"`
+	// EndsAt is when Alertmanager resolves a firing alert that is not pushed again.
+	EndsAt time.Time `json:"-"`
+}
+
+// Notification is what a receiver is sent about a group of alerts. The dedup key is the same
+// for every retry and repeat of a group whose alerts did not change.
+type Notification struct {
+	Receiver    string            `json:"receiver"`
+	Status      string            `json:"status"`
+	GroupKey    string            `json:"groupKey"`
+	GroupLabels map[string]string `json:"groupLabels"`
+	DedupKey    string            `json:"dedupKey"`
+	Alerts      []NotifiedAlert   `json:"alerts"`
+	Title       string            `json:"title"`
+	Message     string            `json:"message"`
+}
+
+// Firing are the firing alerts of the notification, for templates.
+func (notification Notification) Firing() []NotifiedAlert {
+	return notification.withState(alerting.StateFiring)
+}
+
+// Resolved are the resolved alerts of the notification, for templates.
+func (notification Notification) Resolved() []NotifiedAlert {
+	return notification.withState(alerting.StateResolved)
+}
+
+func (notification Notification) withState(state string) []NotifiedAlert {
+	var alerts []NotifiedAlert
+	for _, alert := range notification.Alerts {
+		if alert.State == state {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// route is a receiver with the severities it is sent and its templates.
+type route struct {
+	name       string
+	kind       string
+	receiver   Receiver
+	severities map[string]bool
+	title      *template.Template
+	body       *template.Template
+}
+
+// group holds the alerts with the same group_by labels, and the state each alert was last
+// notified in.
+type group struct {
+	labels   map[string]string
+	alerts   map[string]NotifiedAlert
+	notified map[string]string
+	created  time.Time
+	flushed  time.Time
+	// sending counts the deliveries of the group that are still running, retries included.
+	sending int
+}
+
+// Dispatcher groups the firing and resolved alerts of the rule engine and sends them to the
+// receivers. A new group waits group_wait before it is sent, so that alerts firing together
+// are sent together. After that a group is sent again when its alerts changed, at most every
+// group_interval, and otherwise every repeat_interval while alerts are firing. Alertmanager
+// receivers are pushed the alerts after every evaluation instead, as Prometheus does.
+type Dispatcher struct {
+	settings config.Alerting
+	routes   []route
+	silences *Silences
+
+	mu         sync.Mutex
+	ctx        context.Context
+	groups     map[string]*group
+	deliveries sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher of the notification settings, with the silences of
+// /awsx-api/silences.
+func NewDispatcher(settings config.Alerting) (*Dispatcher, error) {
+	dispatcher := &Dispatcher{
+		settings: settings,
+		silences: silences,
+		ctx:      context.Background(),
+		groups:   map[string]*group{},
+	}
+	names := map[string]bool{}
+	for _, receiver := range settings.Notifications.Receivers {
+		if receiver.Name == "" || names[receiver.Name] {
+			return nil, fmt.Errorf("every receiver needs a unique name, found [%s] twice or empty", receiver.Name)
+		}
+		names[receiver.Name] = true
+		r := route{name: receiver.Name, kind: receiver.Type, severities: map[string]bool{}}
+		var err error
+		if r.receiver, err = NewReceiver(receiver); err != nil {
+			return nil, err
+		}
+		for _, severity := range receiver.Severities {
+			r.severities[severity] = true
+		}
+		if r.title, err = parseTemplate(receiver.Name+" title", receiver.Title, defaultTitle); err != nil {
+			return nil, err
+		}
+		if r.body, err = parseTemplate(receiver.Name+" body", receiver.Body, defaultBody); err != nil {
+			return nil, err
+		}
+		dispatcher.routes = append(dispatcher.routes, r)
+	}
+	return dispatcher, nil
+}
+
+func parseTemplate(name, text, def string) (*template.Template, error) {
+	if text == "" {
+		text = def
+	}
+	parsed, err := template.New(name).Option("missingkey=zero").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("receiver template [%s]: %v", name, err)
+	}
+	return parsed, nil
+}
+
+// Start sends the groups that are due every second until ctx is done.
+func (dispatcher *Dispatcher) Start(ctx context.Context) {
+	dispatcher.mu.Lock()
+	dispatcher.ctx = ctx
+	dispatcher.mu.Unlock()
+	log.Infof("Notifications started with %d receivers", len(dispatcher.routes))
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				dispatcher.Flush(ctx, now)
+			}
+		}
+	}()
+}
+
+// Dispatch takes the alerts after an evaluation. It is an alerting.Listener.
+func (dispatcher *Dispatcher) Dispatch(now time.Time, alerts []alerting.Alert) {
+	resend := 4 * time.Duration(dispatcher.settings.EvaluationInterval) * time.Second
+	groups := map[string]map[string]NotifiedAlert{}
+	groupLabels := map[string]map[string]string{}
+	var pushed []NotifiedAlert
+	for _, alert := range alerts {
+		if alert.State == alerting.StatePending || dispatcher.silences.Silenced(alert.Labels, now) {
+			continue
+		}
+		notified := NotifiedAlert{Alert: alert, Fingerprint: fingerprint(alert.Labels), EndsAt: now.Add(resend)}
+		if alert.ResolvedAt != nil {
+			notified.EndsAt = *alert.ResolvedAt
+		}
+		pushed = append(pushed, notified)
+
+		labels := map[string]string{}
+		for _, name := range dispatcher.settings.Notifications.GroupBy {
+			labels[name] = alert.Labels[name]
+		}
+		key := groupKey(labels)
+		if groups[key] == nil {
+			groups[key] = map[string]NotifiedAlert{}
+			groupLabels[key] = labels
+		}
+		groups[key][notified.Fingerprint] = notified
+	}
+
+	dispatcher.mu.Lock()
+	for key, g := range dispatcher.groups {
+		if _, ok := groups[key]; !ok {
+			g.alerts = map[string]NotifiedAlert{}
+		}
+	}
+	for key, alerts := range groups {
+		g, ok := dispatcher.groups[key]
+		if !ok {
+			if !anyFiring(alerts) {
+				continue
+			}
+			g = &group{labels: groupLabels[key], notified: map[string]string{}, created: now}
+			dispatcher.groups[key] = g
+		}
+		g.alerts = alerts
+	}
+	ctx := dispatcher.ctx
+	dispatcher.mu.Unlock()
+
+	if len(pushed) == 0 {
+		return
+	}
+	for _, r := range dispatcher.routes {
+		if r.kind == TypeAlertmanager {
+			dispatcher.deliveries.Add(1)
+			go func(r route) {
+				defer dispatcher.deliveries.Done()
+				dispatcher.send(ctx, r, Notification{Status: status(pushed), Alerts: pushed})
+			}(r)
+		}
+	}
+}
+
+// Flush starts sending the groups that are due at now. Every group is delivered to every
+// receiver on its own, so a slow or retried receiver only holds back its group: a group is
+// not due again while a delivery of it is still running.
+func (dispatcher *Dispatcher) Flush(ctx context.Context, now time.Time) {
+	settings := dispatcher.settings.Notifications
+	groupWait := time.Duration(settings.GroupWait) * time.Second
+	groupInterval := time.Duration(settings.GroupInterval) * time.Second
+	repeatInterval := time.Duration(settings.RepeatInterval) * time.Second
+
+	type delivery struct {
+		group        *group
+		notification Notification
+	}
+	var due []delivery
+	dispatcher.mu.Lock()
+	for key, g := range dispatcher.groups {
+		if g.sending > 0 {
+			continue
+		}
+		var batch []NotifiedAlert
+		firing, changed := false, false
+		for fp, alert := range g.alerts {
+			switch alert.State {
+			case alerting.StateFiring:
+				firing = true
+				batch = append(batch, alert)
+				changed = changed || g.notified[fp] != alert.State
+			case alerting.StateResolved:
+				// resolved alerts are only news to receivers that were told they fire
+				if g.notified[fp] == alerting.StateFiring {
+					batch = append(batch, alert)
+					changed = true
+				}
+			}
+		}
+		if !firing && !changed {
+			delete(dispatcher.groups, key)
+			continue
+		}
+		switch {
+		case g.flushed.IsZero() && now.Sub(g.created) < groupWait:
+			continue
+		case !g.flushed.IsZero() && changed && now.Sub(g.flushed) < groupInterval:
+			continue
+		case !g.flushed.IsZero() && !changed && now.Sub(g.flushed) < repeatInterval:
+			continue
+		}
+		for fp := range g.notified {
+			if _, ok := g.alerts[fp]; !ok {
+				delete(g.notified, fp)
+			}
+		}
+		for _, alert := range batch {
+			g.notified[alert.Fingerprint] = alert.State
+		}
+		g.flushed = now
+		sort.Slice(batch, func(i, j int) bool { return batch[i].Fingerprint < batch[j].Fingerprint })
+		notification := Notification{Status: status(batch), GroupKey: key, GroupLabels: g.labels, Alerts: batch}
+		for _, r := range dispatcher.routes {
+			if r.kind != TypeAlertmanager {
+				g.sending++
+			}
+		}
+		due = append(due, delivery{group: g, notification: notification})
+	}
+	dispatcher.mu.Unlock()
+
+	for _, d := range due {
+		for _, r := range dispatcher.routes {
+			if r.kind == TypeAlertmanager {
+				continue
+			}
+			dispatcher.deliveries.Add(1)
+			go func(r route, d delivery) {
+				defer dispatcher.deliveries.Done()
+				dispatcher.send(ctx, r, d.notification)
+				dispatcher.mu.Lock()
+				d.group.sending--
+				dispatcher.mu.Unlock()
+			}(r, d)
+		}
+	}
+}
+
+// Wait waits until the deliveries that were started have ended.
+func (dispatcher *Dispatcher) Wait() {
+	dispatcher.deliveries.Wait()
+}
+
+// send renders a notification for a receiver, with the alerts of its severities, and delivers
+// it, retrying with a doubling backoff.
+func (dispatcher *Dispatcher) send(ctx context.Context, r route, notification Notification) {
+	var alerts []NotifiedAlert
+	for _, alert := range notification.Alerts {
+		if len(r.severities) == 0 || r.severities[alert.Severity] {
+			alerts = append(alerts, alert)
+		}
+	}
+	if len(alerts) == 0 {
+		return
+	}
+	notification.Receiver = r.name
+	notification.Alerts = alerts
+	notification.Status = status(alerts)
+	notification.DedupKey = dedupKey(notification)
+	var title, body bytes.Buffer
+	if err := r.title.Execute(&title, notification); err != nil {
+		log.Errorf("Title template of receiver [%s] failed: %v", r.name, err)
+	}
+	if err := r.body.Execute(&body, notification); err != nil {
+		log.Errorf("Body template of receiver [%s] failed: %v", r.name, err)
+	}
+	notification.Title = strings.TrimSpace(title.String())
+	notification.Message = strings.TrimSpace(body.String())
+
+	retries := dispatcher.settings.Notifications.Retries
+	backoff := time.Duration(dispatcher.settings.Notifications.RetryBackoff) * time.Second
+	for attempt := 0; ; attempt++ {
+		err := r.receiver.Send(ctx, notification)
+		if err == nil {
+			log.Infof("Notified receiver [%s] of %d %s alerts", r.name, len(alerts), notification.Status)
+			return
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt >= retries || ctx.Err() != nil {
+			log.Errorf("Notification of receiver [%s] failed after %d attempts: %v", r.name, attempt+1, err)
+			return
+		}
+		log.Warningf("Notification of receiver [%s] failed, retrying in %s: %v", r.name, backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func status(alerts []NotifiedAlert) string {
+	for _, alert := range alerts {
+		if alert.State == alerting.StateFiring {
+			return statusFiring
+		}
+	}
+	return statusResolved
+}
+
+func anyFiring(alerts map[string]NotifiedAlert) bool {
+	for _, alert := range alerts {
+		if alert.State == alerting.StateFiring {
+			return true
+		}
+	}
+	return false
+}
+
+// fingerprint identifies an alert by its labels.
+func fingerprint(labels map[string]string) string {
+	return hash(groupKey(labels))
+}
+
+// groupKey is the labels in the form {a="1",b="2"}.
+func groupKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// dedupKey identifies a notification by its receiver, group and the state of its alerts.
+func dedupKey(notification Notification) string {
+	parts := []string{notification.Receiver, notification.GroupKey}
+	for _, alert := range notification.Alerts {
+		parts = append(parts, alert.Fingerprint+":"+alert.State)
+	}
+	return hash(strings.Join(parts, "\n"))
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,179 @@
+package notifier
+
+import (
+	"awsx-api/alerting"
+	"awsx-api/config"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a webhook receiver that keeps the notifications it is sent and answers with
+// the next of its statuses, 200 once they are used up.
+type recorder struct {
+	mu            sync.Mutex
+	statuses      []int
+	calls         int
+	notifications []Notification
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.calls++
+	if len(rec.statuses) > 0 {
+		status := rec.statuses[0]
+		rec.statuses = rec.statuses[1:]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+	}
+	var notification Notification
+	json.NewDecoder(r.Body).Decode(&notification)
+	rec.notifications = append(rec.notifications, notification)
+}
+
+func newTestDispatcher(t *testing.T, url string, notifications config.Notifications) *Dispatcher {
+	t.Helper()
+	notifications.Receivers = []config.Receiver{{Name: "hook", Type: TypeWebhook, URL: url}}
+	dispatcher, err := NewDispatcher(config.Alerting{EvaluationInterval: 60, Notifications: notifications})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatcher.silences = &Silences{silences: map[string]*Silence{}}
+	return dispatcher
+}
+
+func firing(rule, element string) alerting.Alert {
+	return alerting.Alert{
+		Rule:     rule,
+		State:    alerting.StateFiring,
+		Severity: "critical",
+		Labels:   map[string]string{"alertname": rule, "element": element},
+	}
+}
+
+func TestDispatcherGroupsAlerts(t *testing.T) {
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+	dispatcher := newTestDispatcher(t, server.URL, config.Notifications{GroupBy: []string{"alertname"}, GroupWait: 30, RepeatInterval: 3600})
+
+	now := time.Now()
+	dispatcher.Dispatch(now, []alerting.Alert{firing("HighCPU", "i-1"), firing("HighCPU", "i-2"), firing("HighLatency", "db-1")})
+	dispatcher.Flush(context.Background(), now.Add(10*time.Second))
+	dispatcher.Wait()
+	if rec.calls != 0 {
+		t.Fatalf("groups were sent before group_wait, %d notifications", rec.calls)
+	}
+
+	dispatcher.Flush(context.Background(), now.Add(30*time.Second))
+	dispatcher.Wait()
+	alerts := map[string]int{}
+	for _, notification := range rec.notifications {
+		alerts[notification.GroupLabels["alertname"]] = len(notification.Alerts)
+		if notification.Status != statusFiring || notification.DedupKey == "" {
+			t.Errorf("notification %+v is not firing or has no dedup key", notification)
+		}
+	}
+	if len(rec.notifications) != 2 || alerts["HighCPU"] != 2 || alerts["HighLatency"] != 1 {
+		t.Fatalf("got alerts per group %v, want 2 for HighCPU and 1 for HighLatency", alerts)
+	}
+
+	// unchanged groups wait for repeat_interval
+	dispatcher.Flush(context.Background(), now.Add(time.Hour))
+	dispatcher.Wait()
+	if len(rec.notifications) != 2 {
+		t.Errorf("unchanged groups were sent again before repeat_interval")
+	}
+}
+
+func TestDispatcherSkipsSilencedAlerts(t *testing.T) {
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+	dispatcher := newTestDispatcher(t, server.URL, config.Notifications{})
+
+	now := time.Now()
+	if _, err := dispatcher.silences.Add(Silence{Matchers: []Matcher{{Name: "element", Value: "i-[12]", IsRegex: true}}, EndsAt: now.Add(time.Hour)}, now); err != nil {
+		t.Fatal(err)
+	}
+	dispatcher.Dispatch(now, []alerting.Alert{firing("HighCPU", "i-1"), firing("HighCPU", "i-2"), firing("HighCPU", "i-3")})
+	dispatcher.Flush(context.Background(), now)
+	dispatcher.Wait()
+
+	if len(rec.notifications) != 1 || len(rec.notifications[0].Alerts) != 1 || rec.notifications[0].Alerts[0].Labels["element"] != "i-3" {
+		t.Fatalf("got %+v, want one notification about i-3", rec.notifications)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int
+		delivered bool
+	}{
+		{"retried until delivered", []int{http.StatusInternalServerError, http.StatusTooManyRequests}, 3, true},
+		{"permanent failure", []int{http.StatusBadRequest}, 1, false},
+		{"out of retries", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, 3, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := &recorder{statuses: test.statuses}
+			server := httptest.NewServer(rec)
+			defer server.Close()
+			dispatcher := newTestDispatcher(t, server.URL, config.Notifications{Retries: 2})
+
+			now := time.Now()
+			dispatcher.Dispatch(now, []alerting.Alert{firing("HighCPU", "i-1")})
+			dispatcher.Flush(context.Background(), now)
+			dispatcher.Wait()
+			if rec.calls != test.wantCalls || (len(rec.notifications) == 1) != test.delivered {
+				t.Errorf("got %d calls and %d deliveries, want %d calls, delivered %v", rec.calls, len(rec.notifications), test.wantCalls, test.delivered)
+			}
+		})
+	}
+}
+
+func TestFlushDoesNotWaitForDeliveries(t *testing.T) {
+	release := make(chan struct{})
+	var calls int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		<-release
+	}))
+	defer server.Close()
+	dispatcher := newTestDispatcher(t, server.URL, config.Notifications{GroupBy: []string{"alertname"}})
+
+	now := time.Now()
+	dispatcher.Dispatch(now, []alerting.Alert{firing("HighCPU", "i-1")})
+	returned := make(chan struct{})
+	go func() {
+		dispatcher.Flush(context.Background(), now)
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Flush waited for a slow receiver")
+	}
+
+	// the group is not sent again while its delivery runs, other groups are
+	dispatcher.Dispatch(now, []alerting.Alert{firing("HighCPU", "i-1"), firing("HighCPU", "i-2"), firing("HighLatency", "db-1")})
+	dispatcher.Flush(context.Background(), now.Add(time.Minute))
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	dispatcher.Wait()
+	if calls != 2 {
+		t.Errorf("got %d deliveries, want 2", calls)
+	}
+}
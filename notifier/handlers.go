@@ -0,0 +1,47 @@
+package notifier
+
+import (
+	"awsx-api/handlers"
+	"awsx-api/log"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// GetSilences lists the pending, active and recently expired silences.
+func GetSilences(w http.ResponseWriter, r *http.Request) {
+	handlers.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"silences": silences.List(time.Now())})
+}
+
+// CreateSilence adds the silence of the request body.
+func CreateSilence(w http.ResponseWriter, r *http.Request) {
+	var silence Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	silence, err := silences.Add(silence, time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	log.FromContext(r.Context()).Infof("Silence [%s] added by [%s] until %s", silence.ID, silence.CreatedBy, silence.EndsAt.Format(time.RFC3339))
+	handlers.RespondWithJSON(w, http.StatusCreated, silence)
+}
+
+// ExpireSilence ends the silence of the id right away.
+func ExpireSilence(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !silences.Expire(id, time.Now()) {
+		respondWithError(w, http.StatusNotFound, "unknown silence ["+id+"]")
+		return
+	}
+	log.FromContext(r.Context()).Infof("Silence [%s] expired", id)
+	handlers.RespondWithJSON(w, http.StatusOK, map[string]string{"id": id, "status": SilenceExpired})
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	handlers.RespondWithJSON(w, code, map[string]string{"error": message})
+}
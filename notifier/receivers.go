@@ -0,0 +1,306 @@
+package notifier
+
+import (
+	"awsx-api/config"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TypeWebhook      = "webhook"
+	TypeSlack        = "slack"
+	TypeTeams        = "teams"
+	TypeEmail        = "email"
+	TypeAlertmanager = "alertmanager"
+
+	sendTimeout = 10 * time.Second
+)
+
+// Receiver delivers notifications to one destination.
+type Receiver interface {
+	Send(ctx context.Context, notification Notification) error
+}
+
+// permanentError is a failed delivery that fails again when retried, e.g. a 4xx response.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+var client = &http.Client{Timeout: sendTimeout}
+
+// NewReceiver creates the receiver of a receiver configuration.
+func NewReceiver(settings config.Receiver) (Receiver, error) {
+	switch settings.Type {
+	case TypeWebhook, TypeSlack, TypeTeams, TypeAlertmanager:
+		if settings.URL == "" {
+			return nil, fmt.Errorf("receiver [%s]: %s needs a url", settings.Name, settings.Type)
+		}
+	case TypeEmail:
+		if settings.SMTP.Host == "" || settings.SMTP.From == "" || len(settings.To) == 0 {
+			return nil, fmt.Errorf("receiver [%s]: email needs smtp.host, smtp.from and to", settings.Name)
+		}
+	default:
+		return nil, fmt.Errorf("receiver [%s]: invalid type [%s], expected webhook, slack, teams, email or alertmanager", settings.Name, settings.Type)
+	}
+	switch settings.Type {
+	case TypeWebhook:
+		return &webhook{url: settings.URL, secret: settings.Secret}, nil
+	case TypeSlack:
+		return &slack{url: settings.URL}, nil
+	case TypeTeams:
+		return &teams{url: settings.URL}, nil
+	case TypeAlertmanager:
+		return &alertmanager{url: strings.TrimSuffix(settings.URL, "/") + "/api/v2/alerts"}, nil
+	default:
+		return &email{smtp: settings.SMTP, to: settings.To}, nil
+	}
+}
+
+// webhook posts the notification as JSON. With a secret, X-Awsx-Signature is the hex
+// HMAC-SHA256 of the X-Awsx-Timestamp header, a dot and the body, so receivers can verify the
+// sender and reject replays.
+type webhook struct {
+	url    string
+	secret string
+}
+
+func (receiver *webhook) Send(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return permanentError{err}
+	}
+	header := http.Header{}
+	header.Set("X-Awsx-Dedup-Key", notification.DedupKey)
+	if receiver.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		header.Set("X-Awsx-Timestamp", timestamp)
+		header.Set("X-Awsx-Signature", "sha256="+Sign(receiver.secret, timestamp, body))
+	}
+	return post(ctx, receiver.url, body, header)
+}
+
+// Sign is the webhook signature of a body sent at timestamp, in Unix seconds.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// slack posts to a Slack incoming webhook.
+type slack struct {
+	url string
+}
+
+func (receiver *slack) Send(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"text": "*" + notification.Title + "*\n" + notification.Message,
+		"attachments": []map[string]string{{
+			"color":    statusColor(notification.Status),
+			"fallback": notification.Title,
+		}},
+	})
+	if err != nil {
+		return permanentError{err}
+	}
+	return post(ctx, receiver.url, body, nil)
+}
+
+// teams posts a message card to a Microsoft Teams incoming webhook.
+type teams struct {
+	url string
+}
+
+func (receiver *teams) Send(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(map[string]string{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    notification.Title,
+		"title":      notification.Title,
+		"themeColor": strings.TrimPrefix(statusColor(notification.Status), "#"),
+		// teams renders markdown, where a line break needs two spaces before the newline
+		"text": strings.ReplaceAll(notification.Message, "\n", "  \n"),
+	})
+	if err != nil {
+		return permanentError{err}
+	}
+	return post(ctx, receiver.url, body, nil)
+}
+
+func statusColor(status string) string {
+	if status == statusFiring {
+		return "#d63232"
+	}
+	return "#2eb67d"
+}
+
+// alertmanager pushes the alerts to the v2 API of a Prometheus Alertmanager, which groups,
+// deduplicates and routes them itself. A firing alert ends at endsAt unless pushed again.
+type alertmanager struct {
+	url string
+}
+
+type postableAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+func (receiver *alertmanager) Send(ctx context.Context, notification Notification) error {
+	alerts := make([]postableAlert, 0, len(notification.Alerts))
+	for _, alert := range notification.Alerts {
+		posted := postableAlert{
+			Labels:       alert.Labels,
+			Annotations:  alert.Annotations,
+			StartsAt:     alert.ActiveAt,
+			EndsAt:       alert.EndsAt,
+			GeneratorURL: "/awsx-api/alerts?rule=" + alert.Rule,
+		}
+		if alert.FiredAt != nil {
+			posted.StartsAt = *alert.FiredAt
+		}
+		alerts = append(alerts, posted)
+	}
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return permanentError{err}
+	}
+	return post(ctx, receiver.url, body, nil)
+}
+
+// email sends the notification as a plain text mail, with the title as subject. It
+// authenticates with PLAIN when a username is set, which net/smtp only does over TLS or to
+// localhost.
+type email struct {
+	smtp config.SMTP
+	to   []string
+}
+
+func (receiver *email) Send(ctx context.Context, notification Notification) error {
+	port := receiver.smtp.Port
+	if port == 0 {
+		port = 25
+	}
+	address := net.JoinHostPort(receiver.smtp.Host, strconv.Itoa(port))
+	var auth smtp.Auth
+	if receiver.smtp.Username != "" {
+		auth = smtp.PlainAuth("", receiver.smtp.Username, receiver.smtp.Password, receiver.smtp.Host)
+	}
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", receiver.smtp.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(receiver.to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", encodeSubject(notification.Title))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "X-Awsx-Dedup-Key: %s\r\n", notification.DedupKey)
+	message.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	message.WriteString(strings.ReplaceAll(notification.Message, "\n", "\r\n"))
+
+	return receiver.sendMail(ctx, address, auth, message.Bytes())
+}
+
+// encodeSubject puts the title on a single line, so that it cannot add headers, and encodes
+// it for the Subject header.
+func encodeSubject(title string) string {
+	return mime.QEncoding.Encode("utf-8", strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(title))
+}
+
+// sendMail is smtp.SendMail over a connection that is dialed with sendTimeout and has a
+// deadline of sendTimeout, or of ctx when that is earlier, for the whole conversation.
+func (receiver *email) sendMail(ctx context.Context, address string, auth smtp.Auth, message []byte) error {
+	dialer := net.Dialer{Timeout: sendTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(sendTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, receiver.smtp.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: receiver.smtp.Host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return permanentError{err}
+		}
+	}
+	if err := c.Mail(receiver.smtp.From); err != nil {
+		return err
+	}
+	for _, to := range receiver.to {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	data, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := data.Write(message); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// post posts a JSON body. A 4xx response other than 429 is permanent, the rest is retried.
+func post(ctx context.Context, url string, body []byte, header http.Header) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	for k, v := range header {
+		request.Header[k] = v
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		io.Copy(ioutil.Discard, response.Body)
+		return nil
+	}
+	message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 512))
+	err = fmt.Errorf("%s answered %d: %s", url, response.StatusCode, strings.TrimSpace(string(message)))
+	if response.StatusCode >= 400 && response.StatusCode < 500 && response.StatusCode != http.StatusTooManyRequests {
+		return permanentError{err}
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package notifier
+
+import (
+	"awsx-api/config"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	receiver, err := NewReceiver(config.Receiver{Name: "hook", Type: TypeWebhook, URL: server.URL, Secret: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := receiver.Send(context.Background(), Notification{Receiver: "hook", DedupKey: "abc", Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(header.Get("X-Awsx-Timestamp") + "."))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); header.Get("X-Awsx-Signature") != want {
+		t.Errorf("X-Awsx-Signature = %q, want %q", header.Get("X-Awsx-Signature"), want)
+	}
+	if header.Get("X-Awsx-Timestamp") == "" {
+		t.Errorf("X-Awsx-Timestamp is missing")
+	}
+	if header.Get("X-Awsx-Dedup-Key") != "abc" {
+		t.Errorf("X-Awsx-Dedup-Key = %q, want abc", header.Get("X-Awsx-Dedup-Key"))
+	}
+}
+
+func TestWebhookWithoutSecret(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	receiver, _ := NewReceiver(config.Receiver{Name: "hook", Type: TypeWebhook, URL: server.URL})
+	if err := receiver.Send(context.Background(), Notification{}); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("X-Awsx-Signature") != "" {
+		t.Errorf("unsigned webhook sent X-Awsx-Signature %q", header.Get("X-Awsx-Signature"))
+	}
+}
+
+func TestPostClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantErr   bool
+		permanent bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusNoContent, false, false},
+		{http.StatusBadRequest, true, true},
+		{http.StatusNotFound, true, true},
+		{http.StatusTooManyRequests, true, false},
+		{http.StatusInternalServerError, true, false},
+		{http.StatusBadGateway, true, false},
+	}
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+		}))
+		err := post(context.Background(), server.URL, []byte("{}"), nil)
+		server.Close()
+
+		var permanent permanentError
+		if (err != nil) != test.wantErr || errors.As(err, &permanent) != test.permanent {
+			t.Errorf("post() answered %d: error %v, want error %v, permanent %v", test.status, err, test.wantErr, test.permanent)
+		}
+	}
+}
+
+func TestNewReceiverValidates(t *testing.T) {
+	tests := []config.Receiver{
+		{Name: "hook", Type: TypeWebhook},
+		{Name: "mail", Type: TypeEmail, To: []string{"ops@example.com"}},
+		{Name: "pager", Type: "pager", URL: "http://localhost"},
+	}
+	for _, settings := range tests {
+		if _, err := NewReceiver(settings); err == nil {
+			t.Errorf("NewReceiver(%+v) accepted an invalid receiver", settings)
+		}
+	}
+}
+
+func TestEmailHonorsDeadline(t *testing.T) {
+	// a server that accepts the connection but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	receiver, err := NewReceiver(config.Receiver{Name: "mail", Type: TypeEmail, To: []string{"ops@example.com"},
+		SMTP: config.SMTP{Host: "127.0.0.1", Port: port, From: "awsx@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := receiver.Send(ctx, Notification{Title: "t"}); err == nil {
+		t.Fatalf("Send() to a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send() took %s, want it bounded by the deadline of ctx", elapsed)
+	}
+}
+
+func TestEncodeSubject(t *testing.T) {
+	tests := []struct {
+		title, want string
+	}{
+		{"[FIRING] high-cpu", "[FIRING] high-cpu"},
+		{"[FIRING] high-cpu\r\nBcc: someone@example.com", "[FIRING] high-cpu Bcc: someone@example.com"},
+		{"a\rb\nc", "a b c"},
+		{"Überlast", "=?utf-8?q?=C3=9Cberlast?="},
+	}
+	for _, test := range tests {
+		if got := encodeSubject(test.title); got != test.want {
+			t.Errorf("encodeSubject(%q) = %q, want %q", test.title, got, test.want)
+		}
+	}
+}
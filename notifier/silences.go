@@ -0,0 +1,171 @@
+package notifier
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	SilencePending = "pending"
+	SilenceActive  = "active"
+	SilenceExpired = "expired"
+
+	// expiredRetention is how long an expired silence is still listed.
+	expiredRetention = 24 * time.Hour
+)
+
+// Matcher matches the alerts whose label equals the value, or fully matches it as a regular
+// expression.
+type Matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Silence mutes the notifications of the alerts that match all of its matchers from StartsAt
+// until EndsAt. Silenced alerts are still evaluated and listed by /awsx-api/alerts.
+type Silence struct {
+	ID        string    `json:"id"`
+	Matchers  []Matcher `json:"matchers"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	Status    string    `json:"status"`
+}
+
+// Silences are the silences of the server. They live in memory and are gone after a restart.
+type Silences struct {
+	mu       sync.RWMutex
+	silences map[string]*Silence
+}
+
+var silences = &Silences{silences: map[string]*Silence{}}
+
+// validate checks a new silence and fills in its id and, when missing, its start.
+func (silence *Silence) validate(now time.Time) error {
+	if len(silence.Matchers) == 0 {
+		return fmt.Errorf("a silence needs at least one matcher")
+	}
+	for i := range silence.Matchers {
+		matcher := &silence.Matchers[i]
+		if matcher.Name == "" {
+			return fmt.Errorf("matcher without name")
+		}
+		if matcher.IsRegex {
+			pattern, err := regexp.Compile("^(?:" + matcher.Value + ")$")
+			if err != nil {
+				return fmt.Errorf("invalid regex [%s] of matcher [%s]: %v", matcher.Value, matcher.Name, err)
+			}
+			matcher.pattern = pattern
+		}
+	}
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = now
+	}
+	if !silence.EndsAt.After(silence.StartsAt) || !silence.EndsAt.After(now) {
+		return fmt.Errorf("endsAt must be after startsAt and in the future")
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	silence.ID = hex.EncodeToString(id)
+	return nil
+}
+
+func (silence *Silence) status(now time.Time) string {
+	switch {
+	case now.Before(silence.StartsAt):
+		return SilencePending
+	case now.Before(silence.EndsAt):
+		return SilenceActive
+	default:
+		return SilenceExpired
+	}
+}
+
+func (silence *Silence) matches(labels map[string]string) bool {
+	for _, matcher := range silence.Matchers {
+		value := labels[matcher.Name]
+		if matcher.pattern != nil {
+			if !matcher.pattern.MatchString(value) {
+				return false
+			}
+		} else if value != matcher.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// Add validates a silence and adds it.
+func (s *Silences) Add(silence Silence, now time.Time) (Silence, error) {
+	if err := silence.validate(now); err != nil {
+		return silence, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.silences[silence.ID] = &silence
+	silence.Status = silence.status(now)
+	return silence, nil
+}
+
+// Expire ends a silence now. It is false when there is no such silence.
+func (s *Silences) Expire(id string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	silence, ok := s.silences[id]
+	if !ok {
+		return false
+	}
+	if silence.EndsAt.After(now) {
+		silence.EndsAt = now
+	}
+	if silence.StartsAt.After(now) {
+		silence.StartsAt = now
+	}
+	return true
+}
+
+// List lists the silences by start, forgetting the ones expired for a day.
+func (s *Silences) List(now time.Time) []Silence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Silence, 0, len(s.silences))
+	for id, silence := range s.silences {
+		if now.Sub(silence.EndsAt) > expiredRetention {
+			delete(s.silences, id)
+			continue
+		}
+		listed := *silence
+		listed.Status = silence.status(now)
+		list = append(list, listed)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].StartsAt.Equal(list[j].StartsAt) {
+			return list[i].StartsAt.Before(list[j].StartsAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Silenced tells whether an active silence matches the labels.
+func (s *Silences) Silenced(labels map[string]string, now time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, silence := range s.silences {
+		if silence.status(now) == SilenceActive && silence.matches(labels) {
+			return true
+		}
+	}
+	return false
+}
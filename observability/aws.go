@@ -0,0 +1,55 @@
+package observability
+
+import (
+	"github.com/Appkube-awsx/awsx-common/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceAwsClient creates a client span for every call made through an aws client. The span is
+// a child of the span in the request context, see cache.WithContext. It is meant to be
+// registered with cache.RegisterClientDecorator.
+func TraceAwsClient(_ model.Auth, _ string, c *client.Client) {
+	// Validate runs once per call, Send runs again on every retry
+	c.Handlers.Validate.PushFrontNamed(request.NamedHandler{
+		Name: "awsx-api.TracingStart",
+		Fn: func(r *request.Request) {
+			ctx, _ := Tracer().Start(r.Context(), r.ClientInfo.ServiceID+"/"+r.Operation.Name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.RPCSystemKey.String("aws-api"),
+					semconv.RPCService(r.ClientInfo.ServiceID),
+					semconv.RPCMethod(r.Operation.Name),
+					semconv.CloudRegion(aws.StringValue(r.Config.Region)),
+				),
+			)
+			r.SetContext(ctx)
+		},
+	})
+	c.Handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "awsx-api.TracingEnd",
+		Fn: func(r *request.Request) {
+			span := trace.SpanFromContext(r.Context())
+			if !span.IsRecording() {
+				return
+			}
+			span.SetAttributes(
+				attribute.String("aws.request_id", r.RequestID),
+				attribute.Int("aws.retry_count", r.RetryCount),
+			)
+			if r.HTTPResponse != nil {
+				span.SetAttributes(semconv.HTTPResponseStatusCode(r.HTTPResponse.StatusCode))
+			}
+			if r.Error != nil {
+				span.RecordError(r.Error)
+				span.SetStatus(codes.Error, r.Error.Error())
+			}
+			span.End()
+		},
+	})
+}
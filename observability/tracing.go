@@ -0,0 +1,109 @@
+package observability
+
+import (
+	"awsx-api/config"
+	"awsx-api/log"
+	"context"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracingService is the service name reported on every span.
+	TracingService = "awsx-api"
+
+	ExporterOTLP = "otlp"
+)
+
+// InitTracer installs the global tracer provider and the W3C trace context propagator, so
+// that an incoming traceparent header continues the caller's trace.
+func InitTracer(conf config.Tracing) (*sdktrace.TracerProvider, error) {
+	exporter, err := newExporter(conf)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := NewTracerProvider(conf, exporter)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider, nil
+}
+
+// NewTracerProvider creates a tracer provider that samples as conf says and sends the spans
+// to exporter, e.g. an in-memory exporter in tests.
+func NewTracerProvider(conf config.Tracing, exporter sdktrace.SpanExporter) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(TracingService),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SamplingRate))),
+	), nil
+}
+
+func newExporter(conf config.Tracing) (sdktrace.SpanExporter, error) {
+	switch conf.Exporter {
+	case "", ExporterOTLP:
+		u, err := url.Parse(conf.CollectorURL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid tracing collector_url [%v]", conf.CollectorURL)
+		}
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+		if u.Path != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+		}
+		if u.Scheme != "https" {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter [%v], use %s", conf.Exporter, ExporterOTLP)
+	}
+}
+
+// StopTracer flushes pending spans and shuts the provider down.
+func StopTracer(ctx context.Context, provider *sdktrace.TracerProvider) error {
+	if provider == nil {
+		return nil
+	}
+	if err := provider.ForceFlush(ctx); err != nil {
+		log.Warningf("Failed to flush pending spans: %v", err)
+	}
+	return provider.Shutdown(ctx)
+}
+
+// Tracer returns the awsx-api tracer. Without InitTracer it is a no-op tracer.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracingService)
+}
+
+// StartSpan starts a span as child of the span in ctx.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err on the span, if any, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,63 @@
+package observability
+
+import (
+	"awsx-api/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Appkube-awsx/awsx-common/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraceAwsClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(`<ListMetricsResponse><ListMetricsResult><Metrics/></ListMetricsResult></ListMetricsResponse>`))
+	}))
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider, err := NewTracerProvider(config.Tracing{SamplingRate: 1}, exporter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	client := cloudwatch.New(sess)
+	TraceAwsClient(model.Auth{}, "cloudwatch", client.Client)
+
+	ctx, parent := StartSpan(context.Background(), "request")
+	if _, err := client.ListMetricsWithContext(ctx, &cloudwatch.ListMetricsInput{}); err != nil {
+		t.Fatal(err)
+	}
+	EndSpan(parent, nil)
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want the request and the aws call", len(spans))
+	}
+	call := spans[0]
+	if call.Name != "CloudWatch/ListMetrics" {
+		t.Errorf("span name = %q, want CloudWatch/ListMetrics", call.Name)
+	}
+	if call.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("the aws call span is not a child of the request span")
+	}
+}
@@ -0,0 +1,68 @@
+package panel
+
+import (
+	"math"
+)
+
+// Downsample reduces points, sorted by time, to threshold points with Largest-Triangle-Three-
+// Buckets: the first and last points are kept, the points in between are split into
+// threshold-2 buckets and of every bucket the point that spans the largest triangle with the
+// point kept before it and the average of the next bucket is kept. Peaks and dips survive,
+// unlike with averaging. Points within the threshold, or a threshold below 3, are returned
+// as they are.
+func Downsample(points []Point, threshold int) []Point {
+	if threshold < 3 || len(points) <= threshold {
+		return points
+	}
+	sampled := make([]Point, 0, threshold)
+	sampled = append(sampled, points[0])
+	bucketSize := float64(len(points)-2) / float64(threshold-2)
+	kept := 0
+	for bucket := 0; bucket < threshold-2; bucket++ {
+		start := int(float64(bucket)*bucketSize) + 1
+		end := int(float64(bucket+1)*bucketSize) + 1
+
+		// average of the next bucket, the last point for the last bucket
+		nextStart, nextEnd := end, int(float64(bucket+2)*bucketSize)+1
+		if nextEnd > len(points) {
+			nextEnd = len(points)
+		}
+		var avgX, avgY float64
+		for _, p := range points[nextStart:nextEnd] {
+			avgX += pointX(p)
+			avgY += p.Value
+		}
+		count := float64(nextEnd - nextStart)
+		avgX, avgY = avgX/count, avgY/count
+
+		largest := -1.0
+		next := start
+		for i := start; i < end; i++ {
+			area := math.Abs((pointX(points[kept])-avgX)*(points[i].Value-points[kept].Value) -
+				(pointX(points[kept])-pointX(points[i]))*(avgY-points[kept].Value))
+			if area > largest {
+				largest, next = area, i
+			}
+		}
+		sampled = append(sampled, points[next])
+		kept = next
+	}
+	return append(sampled, points[len(points)-1])
+}
+
+// DownsampleSeries downsamples every series to threshold points and reports whether any
+// series had more.
+func DownsampleSeries(series []Series, threshold int) ([]Series, bool) {
+	downsampled := false
+	for i, s := range series {
+		if points := Downsample(s.Points, threshold); len(points) < len(s.Points) {
+			series[i].Points = points
+			downsampled = true
+		}
+	}
+	return series, downsampled
+}
+
+func pointX(p Point) float64 {
+	return float64(p.Time.UnixMilli())
+}
@@ -0,0 +1,83 @@
+package panel
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func points(values ...float64) []Point {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]Point, len(values))
+	for i, v := range values {
+		points[i] = Point{Time: start.Add(time.Duration(i) * time.Minute), Value: v}
+	}
+	return points
+}
+
+func spike(n, at int, value float64) []float64 {
+	values := make([]float64, n)
+	values[at] = value
+	return values
+}
+
+func TestDownsample(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []float64
+		threshold int
+		want      []int
+	}{
+		{"threshold below 3", []float64{1, 2, 3, 4, 5}, 2, []int{0, 1, 2, 3, 4}},
+		{"within threshold", []float64{1, 2, 3}, 3, []int{0, 1, 2}},
+		{"empty", nil, 10, []int{}},
+		{"largest triangles", []float64{0, 1, 0, 5, 0, 1, 0}, 4, []int{0, 2, 3, 6}},
+		{"keeps a peak", spike(100, 57, 100), 10, nil},
+		{"keeps a dip", spike(100, 23, -100), 10, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := points(test.values...)
+			got := Downsample(in, test.threshold)
+			if test.want != nil {
+				want := make([]Point, len(test.want))
+				for i, index := range test.want {
+					want[i] = in[index]
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("Downsample() = %v, want %v", got, want)
+				}
+				return
+			}
+			if len(got) != test.threshold {
+				t.Fatalf("Downsample() kept %d points, want %d", len(got), test.threshold)
+			}
+			if got[0] != in[0] || got[len(got)-1] != in[len(in)-1] {
+				t.Errorf("Downsample() dropped the first or last point")
+			}
+			kept := false
+			for _, p := range got {
+				if p.Value != 0 {
+					kept = true
+				}
+			}
+			if !kept {
+				t.Errorf("Downsample() dropped the extreme point: %v", got)
+			}
+		})
+	}
+}
+
+func TestDownsampleSeries(t *testing.T) {
+	series := []Series{{Name: "short", Points: points(1, 2)}, {Name: "long", Points: points(spike(50, 10, 3)...)}}
+	got, downsampled := DownsampleSeries(series, 5)
+	if !downsampled {
+		t.Fatalf("DownsampleSeries() reported nothing downsampled")
+	}
+	if len(got[0].Points) != 2 || len(got[1].Points) != 5 {
+		t.Errorf("DownsampleSeries() kept %d and %d points, want 2 and 5", len(got[0].Points), len(got[1].Points))
+	}
+	if _, downsampled := DownsampleSeries(got, 0); downsampled {
+		t.Errorf("DownsampleSeries() with threshold 0 reported a downsample")
+	}
+}
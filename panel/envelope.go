@@ -0,0 +1,165 @@
+package panel
+
+import (
+	"awsx-api/log"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// EnvelopeVersion is the value of the apiVersion query parameter that asks for the envelope.
+	EnvelopeVersion = "v2"
+
+	// PeriodHeader is the response header a panel reports the period it queried with in.
+	PeriodHeader = "X-Awsx-Period"
+	// StatisticHeader is the response header a panel reports the statistic it queried in.
+	StatisticHeader = "X-Awsx-Statistic"
+	// DefaultStatistic is the statistic of a metric panel queried without stat.
+	DefaultStatistic = "Average"
+	// defaultTimeRange is the time range of a panel queried without startTime.
+	defaultTimeRange = 5 * time.Minute
+
+	dataSourceCloudWatch = "cloudwatch"
+	dataSourceCmdb       = "cmdb"
+)
+
+// Envelope is the v2 response of a panel: its data and what the data was computed from.
+type Envelope struct {
+	Data Data `json:"data"`
+	Meta Meta `json:"meta"`
+}
+
+// Data holds the series of a metric panel. Responses that hold no series, such as log
+// records or landing zone details, are passed as they are in Records. Frames replaces both
+// for responseType=frame.
+type Data struct {
+	Series  []Series    `json:"series,omitempty"`
+	Records interface{} `json:"records,omitempty"`
+	Frames  []Frame     `json:"frames,omitempty"`
+}
+
+// Meta describes the query behind the data of an envelope. Period, Statistic and Units are
+// only known when the panel reports them and are left out otherwise.
+type Meta struct {
+	ElementType string    `json:"elementType"`
+	Query       string    `json:"query"`
+	TimeRange   TimeRange `json:"timeRange"`
+	Period      int64     `json:"period,omitempty"`
+	// MaxDataPoints is the maxDataPoints parameter, Downsampled tells whether series had more
+	// points and were downsampled to it.
+	MaxDataPoints int    `json:"maxDataPoints,omitempty"`
+	Downsampled   bool   `json:"downsampled,omitempty"`
+	Statistic     string `json:"statistic,omitempty"`
+	// CompareTo is the window the data is compared with, e.g. 1w.
+	CompareTo   string            `json:"compareTo,omitempty"`
+	Units       map[string]string `json:"units,omitempty"`
+	DataSource  string            `json:"dataSource"`
+	GeneratedAt time.Time         `json:"generatedAt"`
+	// Requested is the time range as the request gave it, when TimeRange was resolved from it.
+	Requested *RequestedRange `json:"requested,omitempty"`
+}
+
+// TimeRange is the effective time range of a query.
+type TimeRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// WantsEnvelope reports whether the request asked for the v2 envelope.
+func WantsEnvelope(r *http.Request) bool {
+	return r.URL.Query().Get("apiVersion") == EnvelopeVersion
+}
+
+// EnvelopeHandler wraps the responses of a panel handler in an Envelope when the request asks
+// for apiVersion=v2. Other requests, error responses and responses that are not JSON are
+// passed through untouched. Frame requests are enveloped by FrameHandler, exports are
+// never enveloped.
+func EnvelopeHandler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !WantsEnvelope(r) || WantsFrames(r) || ExportFormat(r) != "" {
+			next(w, r)
+			return
+		}
+		rec := Record(next, r)
+		if !rec.Succeeded() {
+			rec.WriteTo(w)
+			return
+		}
+		payload, err := rec.Decode()
+		if err != nil {
+			log.FromContext(r.Context()).Debugf("Panel response is not json, sending it without envelope: %v", err)
+			rec.WriteTo(w)
+			return
+		}
+
+		meta := NewMeta(r)
+		meta.ReadResponse(rec.Header())
+		envelope := NewEnvelope(meta, payload)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(rec.Status())
+		if err := json.NewEncoder(w).Encode(envelope); err != nil {
+			log.FromContext(r.Context()).Errorf("Failed to write panel envelope: %v", err)
+		}
+	}
+}
+
+// NewEnvelope normalizes a decoded panel response and describes it with meta.
+func NewEnvelope(meta Meta, payload interface{}) Envelope {
+	series := meta.Series(payload)
+	data := Data{Series: series}
+	if len(series) == 0 {
+		data.Records = payload
+	}
+	for _, s := range series {
+		if s.Unit != "" {
+			meta.Units[s.Name] = s.Unit
+		}
+	}
+	return Envelope{Data: data, Meta: meta}
+}
+
+// NewMeta reads the effective query parameters of a request.
+func NewMeta(r *http.Request) Meta {
+	query := r.URL.Query()
+	now := time.Now().UTC()
+	meta := Meta{
+		ElementType: query.Get("elementType"),
+		Query:       query.Get("query"),
+		TimeRange:   ParseTimeRange(query.Get("startTime"), query.Get("endTime"), now),
+		CompareTo:   query.Get("compareTo"),
+		Units:       map[string]string{},
+		DataSource:  dataSourceCloudWatch,
+		GeneratedAt: now,
+	}
+	if maxDataPoints, err := strconv.Atoi(query.Get("maxDataPoints")); err == nil && maxDataPoints > 0 {
+		meta.MaxDataPoints = maxDataPoints
+	}
+	if requested, ok := requestedRangeOf(r); ok {
+		meta.Requested = &requested
+	}
+	if meta.ElementType == "landingZone" {
+		meta.DataSource = dataSourceCmdb
+	}
+	return meta
+}
+
+// ReadResponse takes the period and the statistic a panel reported in its response headers.
+func (meta *Meta) ReadResponse(header http.Header) {
+	if period, err := strconv.ParseInt(header.Get(PeriodHeader), 10, 64); err == nil && period > 0 {
+		meta.Period = period
+	}
+	meta.Statistic = header.Get(StatisticHeader)
+}
+
+// Series normalizes a decoded panel response and downsamples the series to MaxDataPoints.
+func (meta *Meta) Series(payload interface{}) []Series {
+	series := Normalize(payload, meta.TimeRange.To)
+	if meta.MaxDataPoints > 0 {
+		var downsampled bool
+		series, downsampled = DownsampleSeries(series, meta.MaxDataPoints)
+		meta.Downsampled = meta.Downsampled || downsampled
+	}
+	return series
+}
@@ -0,0 +1,347 @@
+package panel
+
+import (
+	"awsx-api/log"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats, chosen with responseType or the Accept header.
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+
+	contentTypeCSV    = "text/csv"
+	contentTypeNDJSON = "application/x-ndjson"
+
+	// exportFlushRows is the number of rows written between two flushes of the response.
+	exportFlushRows = 500
+)
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// ExportFormat is the export format a request asks for, "" for none. responseType=csv or
+// responseType=ndjson decide, without responseType an Accept header preferring text/csv or
+// application/x-ndjson over JSON does.
+func ExportFormat(r *http.Request) string {
+	switch responseType := r.URL.Query().Get("responseType"); responseType {
+	case ExportCSV, ExportNDJSON:
+		return responseType
+	case "":
+	default:
+		return ""
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+			continue
+		}
+		switch mediaType {
+		case contentTypeCSV:
+			return ExportCSV
+		case contentTypeNDJSON, "application/ndjson":
+			return ExportNDJSON
+		case "application/json", "*/*":
+			return ""
+		}
+	}
+	return ""
+}
+
+// ExportHandler answers export requests with the data of the panel as flat rows: timestamp,
+// series, value and unit for metric panels, the columns of the records for the others. The
+// panel is asked for its frame output, which has all points. Logs Insights results are read
+// while the panel writes them and their rows are sent as they are read, other responses are
+// read in full first. Rows are flushed every exportFlushRows rows.
+func ExportHandler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := ExportFormat(r)
+		if format == "" {
+			next(w, r)
+			return
+		}
+		values := r.URL.Query()
+		values.Set("responseType", "frame")
+		panelRequest := r.Clone(r.Context())
+		panelRequest.URL.RawQuery = values.Encode()
+		s := startStream(next, panelRequest)
+		defer s.Close()
+		if !s.Succeeded() {
+			s.Record(nil).WriteTo(w)
+			return
+		}
+
+		meta := NewMeta(r)
+		meta.ReadResponse(s.sent)
+		body := newReplayReader(s)
+		var rows *rowStream
+		err := readQueryResultRows(json.NewDecoder(body), func(row Row) error {
+			if rows == nil {
+				body.Release()
+				rows = &rowStream{writer: startExport(w, meta, format)}
+			}
+			return rows.add(row)
+		})
+		switch {
+		case rows == nil && err == nil:
+			rows = &rowStream{writer: startExport(w, meta, format)}
+		case rows == nil:
+			exportRecorded(w, r, meta, format, s.Record(body.Kept()))
+			return
+		}
+		if err == nil {
+			err = rows.close()
+		}
+		if err != nil {
+			log.FromContext(r.Context()).Errorf("Failed to write panel export: %v", err)
+		}
+	}
+}
+
+// exportRecorded exports a panel response that was read in full.
+func exportRecorded(w http.ResponseWriter, r *http.Request, meta Meta, format string, rec *Recorder) {
+	payload, err := rec.Decode()
+	if err != nil {
+		log.FromContext(r.Context()).Debugf("Panel response is not json, sending it without export: %v", err)
+		rec.WriteTo(w)
+		return
+	}
+	writer := startExport(w, meta, format)
+	if series := meta.Series(payload); len(series) > 0 {
+		err = writeSeries(writer, series)
+	} else {
+		rows, ok := Rows(payload)
+		if !ok {
+			rows = []Row{{"value": payload}}
+		}
+		err = writeRows(writer, rows)
+	}
+	if err == nil {
+		err = writer.close()
+	}
+	if err != nil {
+		log.FromContext(r.Context()).Errorf("Failed to write panel export: %v", err)
+	}
+}
+
+// startExport sends the headers of an export and returns the writer of its rows.
+func startExport(w http.ResponseWriter, meta Meta, format string) *rowWriter {
+	contentType := contentTypeCSV
+	if format == ExportNDJSON {
+		contentType = contentTypeNDJSON
+	}
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", ExportFilename(meta, format)))
+	w.WriteHeader(http.StatusOK)
+	return newRowWriter(w, format)
+}
+
+// ExportFilename names an export after the panel and its time range, e.g.
+// EC2_cpu_utilization_panel_20240301T000000Z-20240301T060000Z.csv.
+func ExportFilename(meta Meta, format string) string {
+	const layout = "20060102T150405Z"
+	name := fmt.Sprintf("%s_%s_%s-%s", meta.ElementType, meta.Query, meta.TimeRange.From.Format(layout), meta.TimeRange.To.Format(layout))
+	return strings.Trim(unsafeFilenameChars.ReplaceAllString(name, "-"), "-_") + "." + format
+}
+
+func writeSeries(writer *rowWriter, series []Series) error {
+	columns := []string{"timestamp", "series", "value", "unit"}
+	if err := writer.header(columns); err != nil {
+		return err
+	}
+	for _, s := range series {
+		for _, p := range s.Points {
+			if err := writer.row(columns, []interface{}{p.Time.Format(time.RFC3339), s.Name, p.Value, s.Unit}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeRows(writer *rowWriter, rows []Row) error {
+	columns := Columns(rows)
+	if err := writer.header(columns); err != nil {
+		return err
+	}
+	cells := make([]interface{}, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			cells[i] = row[column]
+		}
+		if err := writer.row(columns, cells); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rowStream writes rows as they are read. The columns are those of the first exportFlushRows
+// rows; columns that only appear later are left out of CSV exports, NDJSON exports add them.
+type rowStream struct {
+	writer  *rowWriter
+	pending []Row
+	columns []string
+	seen    map[string]bool
+}
+
+func (rows *rowStream) add(row Row) error {
+	if rows.seen == nil {
+		rows.pending = append(rows.pending, row)
+		if len(rows.pending) < exportFlushRows {
+			return nil
+		}
+		return rows.writePending()
+	}
+	if rows.writer.csv == nil {
+		for column := range row {
+			if !rows.seen[column] {
+				rows.seen[column] = true
+				rows.columns = append(rows.columns, column)
+			}
+		}
+	}
+	return rows.write(row)
+}
+
+func (rows *rowStream) writePending() error {
+	rows.columns = Columns(rows.pending)
+	rows.seen = make(map[string]bool, len(rows.columns))
+	for _, column := range rows.columns {
+		rows.seen[column] = true
+	}
+	if err := rows.writer.header(rows.columns); err != nil {
+		return err
+	}
+	for _, row := range rows.pending {
+		if err := rows.write(row); err != nil {
+			return err
+		}
+	}
+	rows.pending = nil
+	return nil
+}
+
+func (rows *rowStream) write(row Row) error {
+	cells := make([]interface{}, len(rows.columns))
+	for i, column := range rows.columns {
+		cells[i] = row[column]
+	}
+	return rows.writer.row(rows.columns, cells)
+}
+
+func (rows *rowStream) close() error {
+	if rows.seen == nil {
+		if err := rows.writePending(); err != nil {
+			return err
+		}
+	}
+	return rows.writer.close()
+}
+
+// rowWriter writes rows as CSV lines or NDJSON objects and flushes the response every
+// exportFlushRows rows.
+type rowWriter struct {
+	flusher http.Flusher
+	csv     *csv.Writer
+	json    *json.Encoder
+	written int
+}
+
+func newRowWriter(w http.ResponseWriter, format string) *rowWriter {
+	writer := &rowWriter{}
+	writer.flusher, _ = w.(http.Flusher)
+	if format == ExportCSV {
+		writer.csv = csv.NewWriter(w)
+	} else {
+		writer.json = json.NewEncoder(w)
+	}
+	return writer
+}
+
+// header writes the column names, CSV only.
+func (writer *rowWriter) header(columns []string) error {
+	if writer.csv == nil {
+		return nil
+	}
+	return writer.csv.Write(columns)
+}
+
+func (writer *rowWriter) row(columns []string, cells []interface{}) error {
+	var err error
+	if writer.csv != nil {
+		record := make([]string, len(cells))
+		for i, cell := range cells {
+			record[i] = csvCell(cell)
+		}
+		err = writer.csv.Write(record)
+	} else {
+		object := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			object[column] = cells[i]
+		}
+		err = writer.json.Encode(object)
+	}
+	if err != nil {
+		return err
+	}
+	writer.written++
+	if writer.written%exportFlushRows == 0 {
+		writer.flush()
+	}
+	return nil
+}
+
+func (writer *rowWriter) flush() {
+	if writer.csv != nil {
+		writer.csv.Flush()
+	}
+	if writer.flusher != nil {
+		writer.flusher.Flush()
+	}
+}
+
+func (writer *rowWriter) close() error {
+	writer.flush()
+	if writer.csv != nil {
+		return writer.csv.Error()
+	}
+	return nil
+}
+
+// csvCell renders a cell. Nested values are written as JSON, text is escaped with
+// escapeFormula.
+func csvCell(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(value)
+		return string(b)
+	default:
+		return escapeFormula(fmt.Sprint(value))
+	}
+}
+
+// escapeFormula prefixes text that a spreadsheet would run as a formula (starting with =, +,
+// -, @, tab or carriage return) with a single quote, so that a log line cannot inject one.
+func escapeFormula(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
@@ -0,0 +1,352 @@
+package panel
+
+import (
+	"awsx-api/log"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Frame formats of time series, see https://grafana.com/developers/dataplane/timeseries.
+const (
+	FrameFormatWide = "wide"
+	FrameFormatLong = "long"
+
+	frameTypeWide  = "timeseries-wide"
+	frameTypeLong  = "timeseries-long"
+	frameTypeLogs  = "log-lines"
+	frameTypeTable = ""
+
+	fieldTypeTime   = "time"
+	fieldTypeNumber = "number"
+	fieldTypeString = "string"
+	fieldTypeOther  = "other"
+)
+
+// grafanaUnits maps the units of series to Grafana unit ids.
+var grafanaUnits = map[string]string{
+	"Percent":      "percent",
+	"Bytes":        "bytes",
+	"Bytes/Second": "Bps",
+	"Milliseconds": "ms",
+	"Count/Second": "cps",
+	"Count":        "short",
+}
+
+// Frame is a Grafana data frame in its JSON wire format: a schema and the values of the fields
+// as columns. Times are epoch milliseconds.
+type Frame struct {
+	Schema FrameSchema `json:"schema"`
+	Data   FrameData   `json:"data"`
+}
+
+// FrameSchema names a frame and describes its fields.
+type FrameSchema struct {
+	Name   string    `json:"name,omitempty"`
+	RefID  string    `json:"refId,omitempty"`
+	Meta   FrameMeta `json:"meta"`
+	Fields []Field   `json:"fields"`
+}
+
+// FrameMeta holds the data-plane type of a frame.
+type FrameMeta struct {
+	Type                       string `json:"type,omitempty"`
+	TypeVersion                [2]int `json:"typeVersion"`
+	PreferredVisualisationType string `json:"preferredVisualisationType,omitempty"`
+}
+
+// Field describes one column of a frame.
+type Field struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	TypeInfo FieldTypeInfo     `json:"typeInfo"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Config   *FieldConfig      `json:"config,omitempty"`
+}
+
+// FieldTypeInfo is the Go type the Grafana SDK uses for the values of a field.
+type FieldTypeInfo struct {
+	Frame    string `json:"frame"`
+	Nullable bool   `json:"nullable,omitempty"`
+}
+
+// FieldConfig holds the display settings of a field.
+type FieldConfig struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+// FrameData holds the values of the fields, one list per field.
+type FrameData struct {
+	Values [][]interface{} `json:"values"`
+}
+
+// FrameResponse is the body of a responseType=frame request.
+type FrameResponse struct {
+	Frames []Frame `json:"frames"`
+}
+
+// WantsFrames reports whether the request asked for Grafana data frames.
+func WantsFrames(r *http.Request) bool {
+	return r.URL.Query().Get("responseType") == "frame"
+}
+
+// FrameHandler answers responseType=frame requests with Grafana data frames built from the
+// response of the panel handler. frameFormat=long returns time series in long form, the
+// default is wide. With apiVersion=v2 the frames are sent in data.frames of an Envelope.
+func FrameHandler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !WantsFrames(r) {
+			next(w, r)
+			return
+		}
+		rec := Record(next, r)
+		if !rec.Succeeded() {
+			rec.WriteTo(w)
+			return
+		}
+		payload, err := rec.Decode()
+		if err != nil {
+			log.FromContext(r.Context()).Debugf("Panel response is not json, sending it without frames: %v", err)
+			rec.WriteTo(w)
+			return
+		}
+
+		meta := NewMeta(r)
+		meta.ReadResponse(rec.Header())
+		frames := NewFrames(payload, &meta, r.URL.Query().Get("frameFormat"), r.URL.Query().Get("refId"))
+		var body interface{} = FrameResponse{Frames: frames}
+		if WantsEnvelope(r) {
+			for _, frame := range frames {
+				for _, field := range frame.Schema.Fields {
+					if field.Config != nil && field.Type == fieldTypeNumber {
+						meta.Units[field.Name] = field.Config.Unit
+					}
+				}
+			}
+			body = Envelope{Data: Data{Frames: frames}, Meta: meta}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(rec.Status())
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			log.FromContext(r.Context()).Errorf("Failed to write panel frames: %v", err)
+		}
+	}
+}
+
+// NewFrames converts a decoded panel response into data frames: time series when it holds
+// any, log lines for records with a timestamp, a table for other records.
+func NewFrames(payload interface{}, meta *Meta, format, refId string) []Frame {
+	var frame Frame
+	if series := meta.Series(payload); len(series) > 0 {
+		if format == FrameFormatLong {
+			frame = longFrame(series)
+		} else {
+			frame = wideFrame(series)
+		}
+	} else if rows, ok := Rows(payload); ok {
+		if logs, ok := logFrame(rows); ok {
+			frame = logs
+		} else {
+			frame = tableFrame(rows)
+		}
+	} else {
+		frame = tableFrame([]Row{{"value": payload}})
+	}
+	frame.Schema.Name = meta.Query
+	frame.Schema.RefID = refId
+	return []Frame{frame}
+}
+
+// wideFrame has one time field and one number field per series. Series that have no point
+// at a time get null.
+func wideFrame(series []Series) Frame {
+	times := unionOfTimes(series)
+	index := make(map[int64]int, len(times))
+	timeValues := make([]interface{}, len(times))
+	for i, t := range times {
+		index[t] = i
+		timeValues[i] = t
+	}
+	fields := []Field{timeField("time")}
+	values := [][]interface{}{timeValues}
+	for _, s := range series {
+		column := make([]interface{}, len(times))
+		for _, p := range s.Points {
+			column[index[p.Time.UnixMilli()]] = p.Value
+		}
+		fields = append(fields, numberField(s.Name, s.Labels, s.Unit, true))
+		values = append(values, column)
+	}
+	return Frame{
+		Schema: FrameSchema{Meta: FrameMeta{Type: frameTypeWide, TypeVersion: [2]int{0, 1}}, Fields: fields},
+		Data:   FrameData{Values: values},
+	}
+}
+
+// longFrame has a time, a value and a series field, one row per point, ordered by time.
+func longFrame(series []Series) Frame {
+	type row struct {
+		time   int64
+		value  float64
+		series string
+	}
+	var rows []row
+	unit := ""
+	for i, s := range series {
+		if i == 0 {
+			unit = s.Unit
+		} else if unit != s.Unit {
+			unit = ""
+		}
+		for _, p := range s.Points {
+			rows = append(rows, row{time: p.Time.UnixMilli(), value: p.Value, series: s.Name})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].time < rows[j].time })
+	times := make([]interface{}, len(rows))
+	values := make([]interface{}, len(rows))
+	names := make([]interface{}, len(rows))
+	for i, r := range rows {
+		times[i], values[i], names[i] = r.time, r.value, r.series
+	}
+	return Frame{
+		Schema: FrameSchema{
+			Meta:   FrameMeta{Type: frameTypeLong, TypeVersion: [2]int{0, 1}},
+			Fields: []Field{timeField("time"), numberField("value", nil, unit, false), stringField("series")},
+		},
+		Data: FrameData{Values: [][]interface{}{times, values, names}},
+	}
+}
+
+// logFrame builds a log-lines frame: timestamp, body, severity and id fields and the other
+// columns of a record as labels.
+func logFrame(rows []Row) (Frame, bool) {
+	lines, ok := LogLines(rows)
+	if !ok {
+		return Frame{}, false
+	}
+	var timestamps, bodies, severities, ids, labels []interface{}
+	hasSeverity, hasId := false, false
+	for _, line := range lines {
+		timestamps = append(timestamps, line.Time.UnixMilli())
+		bodies = append(bodies, line.Body)
+		severities = append(severities, stringOrNil(line.Severity))
+		ids = append(ids, stringOrNil(line.Id))
+		labels = append(labels, line.Labels)
+		hasSeverity = hasSeverity || line.Severity != ""
+		hasId = hasId || line.Id != ""
+	}
+
+	fields := []Field{timeField("timestamp"), stringField("body")}
+	values := [][]interface{}{timestamps, bodies}
+	if hasSeverity {
+		fields = append(fields, stringField("severity"))
+		values = append(values, severities)
+	}
+	if hasId {
+		fields = append(fields, stringField("id"))
+		values = append(values, ids)
+	}
+	fields = append(fields, Field{Name: "labels", Type: fieldTypeOther, TypeInfo: FieldTypeInfo{Frame: "json.RawMessage"}})
+	values = append(values, labels)
+	return Frame{
+		Schema: FrameSchema{
+			Meta:   FrameMeta{Type: frameTypeLogs, TypeVersion: [2]int{0, 0}, PreferredVisualisationType: "logs"},
+			Fields: fields,
+		},
+		Data: FrameData{Values: values},
+	}, true
+}
+
+// tableFrame has one field per column. Numbers stay numbers, everything else is a string.
+func tableFrame(rows []Row) Frame {
+	columns := Columns(rows)
+	fields := make([]Field, 0, len(columns))
+	values := make([][]interface{}, 0, len(columns))
+	for _, column := range columns {
+		numeric := true
+		for _, row := range rows {
+			if _, isNumber := row[column].(float64); row[column] != nil && !isNumber {
+				numeric = false
+				break
+			}
+		}
+		cells := make([]interface{}, len(rows))
+		for i, row := range rows {
+			if numeric {
+				cells[i] = row[column]
+			} else {
+				cells[i] = cellString(row[column])
+			}
+		}
+		if numeric {
+			fields = append(fields, numberField(column, nil, "", true))
+		} else {
+			fields = append(fields, stringField(column))
+		}
+		values = append(values, cells)
+	}
+	return Frame{
+		Schema: FrameSchema{Meta: FrameMeta{Type: frameTypeTable}, Fields: fields},
+		Data:   FrameData{Values: values},
+	}
+}
+
+func timeField(name string) Field {
+	return Field{Name: name, Type: fieldTypeTime, TypeInfo: FieldTypeInfo{Frame: "time.Time"}}
+}
+
+func numberField(name string, labels map[string]string, unit string, nullable bool) Field {
+	field := Field{Name: name, Type: fieldTypeNumber, TypeInfo: FieldTypeInfo{Frame: "float64", Nullable: nullable}, Labels: labels}
+	if nullable {
+		field.TypeInfo.Frame = "*float64"
+	}
+	if grafanaUnit, ok := grafanaUnits[unit]; ok {
+		field.Config = &FieldConfig{Unit: grafanaUnit}
+	}
+	return field
+}
+
+func stringField(name string) Field {
+	return Field{Name: name, Type: fieldTypeString, TypeInfo: FieldTypeInfo{Frame: "*string", Nullable: true}}
+}
+
+func unionOfTimes(series []Series) []int64 {
+	seen := map[int64]bool{}
+	var times []int64
+	for _, s := range series {
+		for _, p := range s.Points {
+			t := p.Time.UnixMilli()
+			if !seen[t] {
+				seen[t] = true
+				times = append(times, t)
+			}
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times
+}
+
+func stringOrNil(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// cellString renders a table cell. Nested values are sent as JSON rather than Go syntax.
+func cellString(v interface{}) interface{} {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return value
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(value)
+		return string(b)
+	default:
+		return strings.TrimSpace(fmt.Sprint(value))
+	}
+}
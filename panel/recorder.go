@@ -0,0 +1,92 @@
+package panel
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Recorder buffers the response of a panel handler so that it can be reshaped before it is
+// sent. It has its own header map, nothing reaches the client until WriteTo is called.
+type Recorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// Record runs the handler against a Recorder.
+func Record(next http.HandlerFunc, r *http.Request) *Recorder {
+	rec := &Recorder{header: http.Header{}, status: http.StatusOK}
+	next(rec, r)
+	return rec
+}
+
+func (rec *Recorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *Recorder) WriteHeader(code int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.wroteHeader = true
+	rec.status = code
+}
+
+func (rec *Recorder) Write(p []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.body.Write(p)
+}
+
+// Status is the status code the handler answered with.
+func (rec *Recorder) Status() int {
+	return rec.status
+}
+
+// Body is the response body the handler wrote.
+func (rec *Recorder) Body() []byte {
+	return rec.body.Bytes()
+}
+
+// Succeeded reports whether the handler answered with a 2xx status.
+func (rec *Recorder) Succeeded() bool {
+	return rec.status >= 200 && rec.status < 300
+}
+
+// Decode parses the JSON body. Some handlers encode more than one document, those are
+// returned as a list.
+func (rec *Recorder) Decode() (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(rec.body.Bytes()))
+	var documents []interface{}
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	switch len(documents) {
+	case 0:
+		return nil, io.EOF
+	case 1:
+		return documents[0], nil
+	default:
+		return documents, nil
+	}
+}
+
+// WriteTo sends the recorded response unchanged.
+func (rec *Recorder) WriteTo(w http.ResponseWriter) {
+	for k, v := range rec.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.status)
+	_, _ = w.Write(rec.body.Bytes())
+}
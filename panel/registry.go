@@ -0,0 +1,63 @@
+package panel
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Definition is a panel served by getQueryOutput, selected by the elementType and query
+// parameters.
+type Definition struct {
+	ElementType string
+	Query       string
+	Handler     http.HandlerFunc
+}
+
+type registryKey struct {
+	elementType string
+	query       string
+}
+
+// Registry holds the panel definitions. It is built once and only read afterwards.
+type Registry struct {
+	definitions []Definition
+	index       map[registryKey]int
+}
+
+// NewRegistry creates a registry of the definitions. A later definition of the same
+// elementType and query replaces an earlier one.
+func NewRegistry(definitions ...Definition) *Registry {
+	registry := &Registry{index: make(map[registryKey]int, len(definitions))}
+	for _, definition := range definitions {
+		key := registryKey{definition.ElementType, definition.Query}
+		if i, ok := registry.index[key]; ok {
+			registry.definitions[i] = definition
+			continue
+		}
+		registry.index[key] = len(registry.definitions)
+		registry.definitions = append(registry.definitions, definition)
+	}
+	return registry
+}
+
+// Lookup finds the panel of an elementType and query.
+func (registry *Registry) Lookup(elementType, query string) (Definition, bool) {
+	i, ok := registry.index[registryKey{elementType, query}]
+	if !ok {
+		return Definition{}, false
+	}
+	return registry.definitions[i], true
+}
+
+// Definitions lists the panels ordered by elementType and query.
+func (registry *Registry) Definitions() []Definition {
+	definitions := make([]Definition, len(registry.definitions))
+	copy(definitions, registry.definitions)
+	sort.Slice(definitions, func(i, j int) bool {
+		if definitions[i].ElementType != definitions[j].ElementType {
+			return definitions[i].ElementType < definitions[j].ElementType
+		}
+		return definitions[i].Query < definitions[j].Query
+	})
+	return definitions
+}
@@ -0,0 +1,272 @@
+package panel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Row is one record of a panel that answers with records instead of series, e.g. a log line.
+type Row map[string]interface{}
+
+// Rows reads the records of a decoded panel response: a list of objects, or the output of
+// Logs Insights GetQueryResults, where every record is a list of {Field, Value} pairs.
+// The second result is false when the response holds no records.
+func Rows(v interface{}) ([]Row, bool) {
+	items, ok := v.([]interface{})
+	if !ok {
+		if m, isMap := v.(map[string]interface{}); isMap {
+			if _, isQueryResult := m["Results"]; isQueryResult {
+				items, ok = []interface{}{m}, true
+			}
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+	rows := make([]Row, 0, len(items))
+	for _, item := range items {
+		m, isMap := item.(map[string]interface{})
+		if !isMap {
+			return nil, false
+		}
+		if results, isQueryResult := m["Results"].([]interface{}); isQueryResult {
+			rows = append(rows, queryResultRows(results)...)
+			continue
+		}
+		rows = append(rows, Row(m))
+	}
+	return rows, true
+}
+
+func queryResultRows(results []interface{}) []Row {
+	rows := make([]Row, 0, len(results))
+	for _, result := range results {
+		fields, ok := result.([]interface{})
+		if !ok {
+			continue
+		}
+		row := Row{}
+		for _, field := range fields {
+			if f, ok := field.(map[string]interface{}); ok {
+				if name, ok := f["Field"].(string); ok {
+					row[name] = f["Value"]
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// errNoQueryResults is returned by readQueryResultRows for a response that holds no Logs
+// Insights results.
+var errNoQueryResults = errors.New("panel response holds no Logs Insights results")
+
+// readQueryResultRows reads the rows of Logs Insights results, a GetQueryResults output or a
+// list of them, from a panel response while it arrives and passes them to emit one at a time.
+// Objects of the list without Results are one row each, as in Rows. It fails with
+// errNoQueryResults, before anything is passed to emit, when the first object has no Results.
+func readQueryResultRows(decoder *json.Decoder, emit func(Row) error) error {
+	found := false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) && found {
+			return nil
+		}
+		if err != nil {
+			if !found {
+				return errNoQueryResults
+			}
+			return err
+		}
+		switch token {
+		case json.Delim('['):
+			for decoder.More() {
+				if token, err = decoder.Token(); err != nil {
+					return err
+				}
+				if token != json.Delim('{') {
+					if !found {
+						return errNoQueryResults
+					}
+					return fmt.Errorf("unexpected %v in Logs Insights results", token)
+				}
+				if err := readQueryResultObject(decoder, &found, emit); err != nil {
+					return err
+				}
+			}
+			if _, err := decoder.Token(); err != nil {
+				return err
+			}
+		case json.Delim('{'):
+			if err := readQueryResultObject(decoder, &found, emit); err != nil {
+				return err
+			}
+		default:
+			if !found {
+				return errNoQueryResults
+			}
+			return fmt.Errorf("unexpected %v in Logs Insights results", token)
+		}
+	}
+}
+
+// readQueryResultObject reads an object after its opening brace, passing the rows of its
+// Results to emit as they are read, or the object itself when it has none.
+func readQueryResultObject(decoder *json.Decoder, found *bool, emit func(Row) error) error {
+	record := Row{}
+	hasResults := false
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+		if key != "Results" {
+			var value interface{}
+			if err := decoder.Decode(&value); err != nil {
+				return err
+			}
+			record[key] = value
+			continue
+		}
+		if token, err = decoder.Token(); err != nil {
+			return err
+		}
+		if delim, isDelim := token.(json.Delim); isDelim && delim != '[' {
+			if !*found {
+				return errNoQueryResults
+			}
+			return fmt.Errorf("unexpected %v in Logs Insights results", token)
+		}
+		if token != json.Delim('[') {
+			record[key] = token
+			continue
+		}
+		hasResults, *found = true, true
+		for decoder.More() {
+			var result interface{}
+			if err := decoder.Decode(&result); err != nil {
+				return err
+			}
+			for _, row := range queryResultRows([]interface{}{result}) {
+				if err := emit(row); err != nil {
+					return err
+				}
+			}
+		}
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	if hasResults {
+		return nil
+	}
+	if !*found {
+		return errNoQueryResults
+	}
+	return emit(record)
+}
+
+// Columns lists the column names of rows in a stable order, the union of all rows.
+func Columns(rows []Row) []string {
+	seen := map[string]bool{}
+	var columns []string
+	for _, row := range rows {
+		for k := range row {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// logTimestampColumns, logBodyColumns, logSeverityColumns and logIdColumns are the columns
+// that make records log lines, in order of preference.
+var (
+	logTimestampColumns = []string{"@timestamp", "timestamp", "Timestamp", "time", "eventTime", "EventTime"}
+	logBodyColumns      = []string{"@message", "message", "Message", "description", "Description", "EventName", "body"}
+	logSeverityColumns  = []string{"severity", "Severity", "level", "@logLevel"}
+	logIdColumns        = []string{"@ptr", "event_id", "EventId", "id"}
+)
+
+// LogLine is a record of a log panel.
+type LogLine struct {
+	Time     time.Time
+	Body     string
+	Severity string
+	Id       string
+	Labels   map[string]string
+}
+
+// LogLines reads records as log lines. The body is the message column, or the whole record
+// as JSON when there is none, the columns that are not timestamp, body, severity or id become
+// labels. Records without a parsable timestamp are not log lines.
+func LogLines(rows []Row) ([]LogLine, bool) {
+	if len(rows) == 0 {
+		return nil, false
+	}
+	columns := Columns(rows)
+	timestampColumn := firstColumn(columns, logTimestampColumns)
+	if timestampColumn == "" {
+		return nil, false
+	}
+	bodyColumn := firstColumn(columns, logBodyColumns)
+	severityColumn := firstColumn(columns, logSeverityColumns)
+	idColumn := firstColumn(columns, logIdColumns)
+
+	lines := make([]LogLine, 0, len(rows))
+	for _, row := range rows {
+		t, ok := parseTime(row[timestampColumn])
+		if !ok {
+			return nil, false
+		}
+		line := LogLine{
+			Time:     t,
+			Severity: stringOf(row[severityColumn]),
+			Id:       stringOf(row[idColumn]),
+			Labels:   map[string]string{},
+		}
+		if bodyColumn != "" {
+			line.Body = stringOf(row[bodyColumn])
+		} else {
+			body, _ := json.Marshal(row)
+			line.Body = string(body)
+		}
+		for k, v := range row {
+			if k != timestampColumn && k != bodyColumn && k != severityColumn && k != idColumn && v != nil {
+				line.Labels[k] = fmt.Sprint(v)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines, true
+}
+
+func firstColumn(columns []string, candidates []string) string {
+	for _, candidate := range candidates {
+		for _, column := range columns {
+			if column == candidate {
+				return column
+			}
+		}
+	}
+	return ""
+}
+
+func stringOf(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
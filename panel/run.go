@@ -0,0 +1,60 @@
+package panel
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// NewRequest builds the getQueryOutput request of a panel call made on behalf of r. It keeps
+// the context of r, so the panel logs with the request id and runs in the trace of r.
+func NewRequest(r *http.Request, params url.Values) *http.Request {
+	sub := r.Clone(r.Context())
+	sub.Method = http.MethodGet
+	sub.Body = http.NoBody
+	sub.ContentLength = 0
+	sub.URL = &url.URL{Path: "/awsx-api/getQueryOutput", RawQuery: params.Encode()}
+	sub.RequestURI = sub.URL.RequestURI()
+	return sub
+}
+
+// Error is the error response of a panel called by Run.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("panel answered %d: %s", e.Status, e.Message)
+}
+
+// Run calls a panel over a time range and decodes its response. The panel is asked for its
+// frame output, which is the raw CloudWatch response and has the most points. A zero time
+// range is the default range of the panels.
+func Run(r *http.Request, definition Definition, params url.Values, from, to time.Time) (interface{}, error) {
+	if to.IsZero() {
+		defaults := ParseTimeRange("", "", time.Now().UTC())
+		from, to = defaults.From, defaults.To
+	}
+	values := url.Values{}
+	for k, v := range params {
+		values[k] = v
+	}
+	values.Set("elementType", definition.ElementType)
+	values.Set("query", definition.Query)
+	values.Set("responseType", "frame")
+	values.Set("startTime", from.UTC().Format(time.RFC3339))
+	values.Set("endTime", to.UTC().Format(time.RFC3339))
+
+	rec := Record(definition.Handler, NewRequest(r, values))
+	if !rec.Succeeded() {
+		return nil, &Error{Status: rec.Status(), Message: strings.TrimSpace(string(rec.Body()))}
+	}
+	payload, err := rec.Decode()
+	if err != nil {
+		return nil, fmt.Errorf("panel answered with no data")
+	}
+	return payload, nil
+}
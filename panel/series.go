@@ -0,0 +1,197 @@
+package panel
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Series is one named time series of a panel, in the same shape for every panel so that a
+// single chart component can render any of them. Unit is only set when the panel response
+// names it; CloudWatch does not return units with GetMetricData.
+type Series struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Unit   string            `json:"unit,omitempty"`
+	Points []Point           `json:"points"`
+}
+
+// Point is a single value of a series.
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Normalize turns a decoded panel response into series. The panel libraries answer with a
+// map of GetMetricDataOutput, a single MetricDataResult, lists of timestamp/value pairs,
+// plain numbers (e.g. {"AverageUsage": 12.5}) or a JSON document embedded in a string.
+// Numbers without a timestamp become single point series at the given time. Values that
+// hold no numbers, such as log records, produce no series.
+func Normalize(v interface{}, at time.Time) []Series {
+	var series []Series
+	collect(&series, "", v, at)
+	return series
+}
+
+func collect(series *[]Series, name string, v interface{}, at time.Time) {
+	switch value := v.(type) {
+	case float64:
+		if name == "" {
+			name = "value"
+		}
+		*series = append(*series, Series{Name: name, Points: []Point{{Time: at, Value: value}}})
+	case string:
+		trimmed := strings.TrimSpace(value)
+		if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			var embedded interface{}
+			if json.Unmarshal([]byte(trimmed), &embedded) == nil {
+				collect(series, name, embedded, at)
+			}
+		}
+	case []interface{}:
+		if points, unit, ok := timeValuePairs(value); ok {
+			if name == "" {
+				name = "value"
+			}
+			*series = append(*series, Series{Name: name, Unit: unit, Points: points})
+			return
+		}
+		for _, item := range value {
+			if m, ok := item.(map[string]interface{}); ok && isMetricDataResult(m) {
+				*series = append(*series, metricDataResult(name, m, len(value) > 1))
+			}
+		}
+	case map[string]interface{}:
+		if results, ok := value["MetricDataResults"].([]interface{}); ok {
+			for _, item := range results {
+				if m, ok := item.(map[string]interface{}); ok && isMetricDataResult(m) {
+					*series = append(*series, metricDataResult(name, m, len(results) > 1))
+				}
+			}
+			return
+		}
+		if isMetricDataResult(value) {
+			*series = append(*series, metricDataResult(name, value, false))
+			return
+		}
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if name != "" {
+				child = name + "." + k
+			}
+			collect(series, child, value[k], at)
+		}
+	}
+}
+
+func isMetricDataResult(m map[string]interface{}) bool {
+	_, hasTimestamps := m["Timestamps"].([]interface{})
+	_, hasValues := m["Values"].([]interface{})
+	return hasTimestamps && hasValues
+}
+
+// metricDataResult converts a cloudwatch.MetricDataResult. The label is part of the name
+// when a query returned several results, otherwise the key of the result is enough.
+func metricDataResult(name string, m map[string]interface{}, qualify bool) Series {
+	label, _ := m["Label"].(string)
+	id, _ := m["Id"].(string)
+	switch {
+	case name == "":
+		name = label
+	case qualify && label != "":
+		name = name + "." + label
+	}
+	if name == "" {
+		name = id
+	}
+	s := Series{Name: name, Labels: map[string]string{}}
+	if label != "" {
+		s.Labels["label"] = label
+	}
+	if id != "" {
+		s.Labels["id"] = id
+	}
+	timestamps := m["Timestamps"].([]interface{})
+	values := m["Values"].([]interface{})
+	for i := 0; i < len(timestamps) && i < len(values); i++ {
+		t, okTime := parseTime(timestamps[i])
+		v, okValue := values[i].(float64)
+		if okTime && okValue {
+			s.Points = append(s.Points, Point{Time: t, Value: v})
+		}
+	}
+	// CloudWatch returns the newest point first
+	sort.SliceStable(s.Points, func(i, j int) bool { return s.Points[i].Time.Before(s.Points[j].Time) })
+	return s
+}
+
+// timeValuePairs reads lists like [{"Timestamp": "...", "Value": 1.5, "Unit": "Percent"}],
+// the shape several panel libraries use for their json output, and the unit the points name.
+func timeValuePairs(items []interface{}) ([]Point, string, bool) {
+	if len(items) == 0 {
+		return nil, "", false
+	}
+	points := make([]Point, 0, len(items))
+	unit := ""
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, "", false
+		}
+		var t time.Time
+		var v float64
+		var hasTime, hasValue bool
+		for k, field := range m {
+			switch strings.ToLower(k) {
+			case "timestamp", "time", "date":
+				t, hasTime = parseTime(field)
+			case "value":
+				v, hasValue = field.(float64)
+			case "unit":
+				if name, ok := field.(string); ok {
+					unit = name
+				}
+			}
+		}
+		if !hasTime || !hasValue {
+			return nil, "", false
+		}
+		points = append(points, Point{Time: t, Value: v})
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return points, unit, true
+}
+
+// timeLayouts are the timestamp formats found in panel responses: CloudWatch, Logs Insights
+// (@timestamp) and the hand written event lists of some panels.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05",
+	"2006-01-02 03:04 PM",
+	"2006-01-02",
+}
+
+func parseTime(v interface{}) (time.Time, bool) {
+	switch value := v.(type) {
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t.UTC(), true
+			}
+		}
+	case float64:
+		// epoch seconds or milliseconds
+		if value > 1e12 {
+			return time.UnixMilli(int64(value)).UTC(), true
+		}
+		return time.Unix(int64(value), 0).UTC(), true
+	}
+	return time.Time{}, false
+}
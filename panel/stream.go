@@ -0,0 +1,134 @@
+package panel
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// errStreamClosed is returned to the writes of a panel handler once nobody reads its stream.
+var errStreamClosed = errors.New("panel stream closed")
+
+// stream runs a panel handler in its own goroutine and hands over its body while it is written,
+// so that it can be reshaped without holding all of it. The status and the headers are those
+// at the first write.
+type stream struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+
+	// sent is the header at the first write, read once started is closed.
+	sent    http.Header
+	started chan struct{}
+	done    chan struct{}
+	panic   interface{}
+
+	reader *io.PipeReader
+	writer *io.PipeWriter
+}
+
+// startStream runs the handler and returns once it has written its status or its first bytes,
+// or has returned.
+func startStream(next http.HandlerFunc, r *http.Request) *stream {
+	s := &stream{header: http.Header{}, status: http.StatusOK, started: make(chan struct{}), done: make(chan struct{})}
+	s.reader, s.writer = io.Pipe()
+	go func() {
+		defer close(s.done)
+		defer func() {
+			if p := recover(); p != nil {
+				s.panic = p
+				s.writer.CloseWithError(errStreamClosed)
+				if !s.wroteHeader {
+					close(s.started)
+					return
+				}
+			}
+			s.WriteHeader(http.StatusOK)
+			s.writer.Close()
+		}()
+		next(s, r)
+	}()
+	<-s.started
+	if s.sent == nil {
+		// The handler panicked before it wrote anything.
+		<-s.done
+		panic(s.panic)
+	}
+	return s
+}
+
+func (s *stream) Header() http.Header {
+	return s.header
+}
+
+func (s *stream) WriteHeader(code int) {
+	if s.wroteHeader {
+		return
+	}
+	s.wroteHeader = true
+	s.status = code
+	s.sent = s.header.Clone()
+	close(s.started)
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	s.WriteHeader(http.StatusOK)
+	return s.writer.Write(p)
+}
+
+// Read reads the body as the handler writes it.
+func (s *stream) Read(p []byte) (int, error) {
+	return s.reader.Read(p)
+}
+
+// Succeeded reports whether the handler answered with a 2xx status.
+func (s *stream) Succeeded() bool {
+	return s.status >= 200 && s.status < 300
+}
+
+// Record reads the rest of the body into a Recorder, after the part already read.
+func (s *stream) Record(read []byte) *Recorder {
+	rec := &Recorder{header: s.sent, status: s.status, wroteHeader: true}
+	rec.body.Write(read)
+	_, _ = io.Copy(&rec.body, s.reader)
+	return rec
+}
+
+// Close stops reading the body, waits for the handler and passes on its panic.
+func (s *stream) Close() {
+	s.reader.CloseWithError(errStreamClosed)
+	<-s.done
+	if s.panic != nil {
+		panic(s.panic)
+	}
+}
+
+// replayReader keeps what is read until Release is called, so that a response can still be
+// sent as it came once reading it as a stream turned out impossible.
+type replayReader struct {
+	r    io.Reader
+	read *bytes.Buffer
+}
+
+func newReplayReader(r io.Reader) *replayReader {
+	return &replayReader{r: r, read: &bytes.Buffer{}}
+}
+
+func (rr *replayReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	if rr.read != nil {
+		rr.read.Write(p[:n])
+	}
+	return n, err
+}
+
+// Kept returns what was read so far.
+func (rr *replayReader) Kept() []byte {
+	return rr.read.Bytes()
+}
+
+// Release stops keeping what is read.
+func (rr *replayReader) Release() {
+	rr.read = nil
+}
@@ -0,0 +1,252 @@
+package panel
+
+import (
+	"awsx-api/log"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	// time zones of the tz parameter, also where the image has no zoneinfo
+	_ "time/tzdata"
+)
+
+// calendarRanges are the windows of the range parameter as relative expressions. A rounded
+// endTime is the end of its unit, so yesterday is from the start of yesterday to the start of
+// today.
+var calendarRanges = map[string][2]string{
+	"today":      {"now/d", "now/d"},
+	"yesterday":  {"now-1d/d", "now-1d/d"},
+	"this_week":  {"now/w", "now/w"},
+	"last_week":  {"now-1w/w", "now-1w/w"},
+	"this_month": {"now/M", "now/M"},
+	"last_month": {"now-1M/M", "now-1M/M"},
+	"this_year":  {"now/y", "now/y"},
+	"last_year":  {"now-1y/y", "now-1y/y"},
+}
+
+// localLayouts are the absolute times without zone, read in the tz of the request.
+var localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+
+// RequestedRange is the time range as the request gave it, before it was resolved.
+type RequestedRange struct {
+	StartTime string `json:"startTime,omitempty"`
+	EndTime   string `json:"endTime,omitempty"`
+	Range     string `json:"range,omitempty"`
+	TimeZone  string `json:"tz,omitempty"`
+}
+
+type requestedRangeKey struct{}
+
+// TimeRangeHandler resolves the time range of a request before the panels see it. startTime
+// and endTime may be RFC3339, local times in tz, unix seconds or milliseconds, or Grafana
+// style relative expressions (now-6h, now-7d/d); range names a calendar window (today,
+// yesterday, this_week, last_week, this_month, last_month, this_year, last_year). Missing
+// times are the last five minutes. The request goes on with RFC3339 UTC startTime and endTime,
+// which every panel library understands; the requested range is kept for the envelope meta.
+func TimeRangeHandler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		requested := RequestedRange{
+			StartTime: query.Get("startTime"),
+			EndTime:   query.Get("endTime"),
+			Range:     query.Get("range"),
+			TimeZone:  query.Get("tz"),
+		}
+		tr, err := ResolveTimeRange(requested, time.Now())
+		if err != nil {
+			log.FromContext(r.Context()).Warningf("Invalid time range: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query.Set("startTime", tr.From.Format(time.RFC3339))
+		query.Set("endTime", tr.To.Format(time.RFC3339))
+		resolved := r.WithContext(context.WithValue(r.Context(), requestedRangeKey{}, requested))
+		resolved.URL.RawQuery = query.Encode()
+		next(w, resolved)
+	}
+}
+
+// requestedRangeOf is the range TimeRangeHandler resolved for the request, if any.
+func requestedRangeOf(r *http.Request) (RequestedRange, bool) {
+	requested, ok := r.Context().Value(requestedRangeKey{}).(RequestedRange)
+	return requested, ok
+}
+
+// ResolveTimeRange turns a requested range into an absolute one, in UTC.
+func ResolveTimeRange(requested RequestedRange, now time.Time) (TimeRange, error) {
+	loc := time.UTC
+	if requested.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(requested.TimeZone); err != nil {
+			return TimeRange{}, fmt.Errorf("unknown tz [%s]", requested.TimeZone)
+		}
+	}
+	start, end := requested.StartTime, requested.EndTime
+	if requested.Range != "" {
+		name := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(requested.Range)))
+		window, ok := calendarRanges[name]
+		if !ok {
+			return TimeRange{}, fmt.Errorf("unknown range [%s], expected one of today, yesterday, this_week, last_week, this_month, last_month, this_year, last_year", requested.Range)
+		}
+		start, end = window[0], window[1]
+	}
+
+	tr := TimeRange{From: now.Add(-defaultTimeRange), To: now}
+	var err error
+	if start != "" {
+		if tr.From, err = parseTimeExpression(start, now, loc, false); err != nil {
+			return TimeRange{}, fmt.Errorf("invalid startTime [%s]: %v", start, err)
+		}
+	}
+	if end != "" {
+		if tr.To, err = parseTimeExpression(end, now, loc, true); err != nil {
+			return TimeRange{}, fmt.Errorf("invalid endTime [%s]: %v", end, err)
+		}
+	}
+	if start != "" && end == "" && tr.From.After(now) {
+		return TimeRange{}, fmt.Errorf("startTime [%s] is in the future", start)
+	}
+	if !tr.From.Before(tr.To) {
+		return TimeRange{}, fmt.Errorf("startTime [%s] must be before endTime [%s]", tr.From.Format(time.RFC3339), tr.To.Format(time.RFC3339))
+	}
+	tr.From, tr.To = tr.From.UTC(), tr.To.UTC()
+	return tr, nil
+}
+
+// ParseTimeRange parses startTime and endTime in UTC and falls back to the default range of
+// the panel libraries, now-5m to now, for a missing or invalid time.
+func ParseTimeRange(startTime, endTime string, now time.Time) TimeRange {
+	tr := TimeRange{From: now.Add(-defaultTimeRange), To: now}
+	if t, err := parseTimeExpression(startTime, now, time.UTC, false); err == nil {
+		tr.From = t.UTC()
+	}
+	if t, err := parseTimeExpression(endTime, now, time.UTC, true); err == nil {
+		tr.To = t.UTC()
+	}
+	return tr
+}
+
+// parseTimeExpression reads an absolute time or a relative expression. Rounding an end time
+// rounds up to the end of the unit.
+func parseTimeExpression(value string, now time.Time, loc *time.Location, isEnd bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty time")
+	}
+	if strings.HasPrefix(value, "now") {
+		return parseRelative(value, now.In(loc), isEnd)
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// unix milliseconds have 13 digits for the years this API will see
+		if n >= 1e11 {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("expected RFC3339, a unix timestamp or an expression such as now-6h or now-7d/d")
+}
+
+// parseRelative reads now followed by offsets (-6h, +1d) and roundings (/d) in any order.
+// Units are s, m, h, d, w, M and y; days and larger follow the calendar of the time zone.
+func parseRelative(expression string, now time.Time, isEnd bool) (time.Time, error) {
+	t := now
+	rest := strings.TrimPrefix(expression, "now")
+	for rest != "" {
+		op := rest[0]
+		rest = rest[1:]
+		switch op {
+		case '/':
+			if rest == "" {
+				return time.Time{}, fmt.Errorf("missing unit after /")
+			}
+			unit := rest[0]
+			rest = rest[1:]
+			start, err := startOf(t, unit)
+			if err != nil {
+				return time.Time{}, err
+			}
+			t = start
+			if isEnd {
+				t = add(start, 1, unit)
+			}
+		case '+', '-':
+			i := 0
+			for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+				i++
+			}
+			amount := 1
+			if i > 0 {
+				amount, _ = strconv.Atoi(rest[:i])
+			}
+			if i >= len(rest) {
+				return time.Time{}, fmt.Errorf("missing unit after %c%s", op, rest[:i])
+			}
+			unit := rest[i]
+			rest = rest[i+1:]
+			if !strings.ContainsRune("smhdwMy", rune(unit)) {
+				return time.Time{}, fmt.Errorf("unknown unit %c", unit)
+			}
+			if op == '-' {
+				amount = -amount
+			}
+			t = add(t, amount, unit)
+		default:
+			return time.Time{}, fmt.Errorf("unexpected %c", op)
+		}
+	}
+	return t, nil
+}
+
+func add(t time.Time, amount int, unit byte) time.Time {
+	switch unit {
+	case 's':
+		return t.Add(time.Duration(amount) * time.Second)
+	case 'm':
+		return t.Add(time.Duration(amount) * time.Minute)
+	case 'h':
+		return t.Add(time.Duration(amount) * time.Hour)
+	case 'd':
+		return t.AddDate(0, 0, amount)
+	case 'w':
+		return t.AddDate(0, 0, 7*amount)
+	case 'M':
+		return t.AddDate(0, amount, 0)
+	default:
+		return t.AddDate(amount, 0, 0)
+	}
+}
+
+// startOf truncates t to the start of its second, minute, hour, day, week (Monday), month or
+// year in the location of t.
+func startOf(t time.Time, unit byte) (time.Time, error) {
+	y, mo, d := t.Date()
+	switch unit {
+	case 's':
+		return time.Date(y, mo, d, t.Hour(), t.Minute(), t.Second(), 0, t.Location()), nil
+	case 'm':
+		return time.Date(y, mo, d, t.Hour(), t.Minute(), 0, 0, t.Location()), nil
+	case 'h':
+		return time.Date(y, mo, d, t.Hour(), 0, 0, 0, t.Location()), nil
+	case 'd':
+		return time.Date(y, mo, d, 0, 0, 0, 0, t.Location()), nil
+	case 'w':
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, mo, d-daysSinceMonday, 0, 0, 0, 0, t.Location()), nil
+	case 'M':
+		return time.Date(y, mo, 1, 0, 0, 0, 0, t.Location()), nil
+	case 'y':
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, t.Location()), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown unit %c", unit)
+}
@@ -0,0 +1,109 @@
+package panel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveTimeRange(t *testing.T) {
+	// Sunday, the day New York switches to daylight saving time at 2:00
+	springForward := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	// Sunday, the day New York switches back at 2:00
+	fallBack := time.Date(2024, 11, 3, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		requested RequestedRange
+		now       time.Time
+		from, to  string
+	}{
+		{"omitted times", RequestedRange{}, springForward, "2024-03-10T15:25:00Z", "2024-03-10T15:30:00Z"},
+		{"omitted endTime", RequestedRange{StartTime: "now-1h"}, springForward, "2024-03-10T14:30:00Z", "2024-03-10T15:30:00Z"},
+		{"omitted startTime", RequestedRange{EndTime: "now-1m"}, springForward, "2024-03-10T15:25:00Z", "2024-03-10T15:29:00Z"},
+		{"rfc3339", RequestedRange{StartTime: "2024-03-10T10:00:00+01:00", EndTime: "2024-03-10T12:00:00Z"}, springForward, "2024-03-10T09:00:00Z", "2024-03-10T12:00:00Z"},
+		{"unix seconds and milliseconds", RequestedRange{StartTime: "1710075600", EndTime: "1710079200000"}, springForward, "2024-03-10T13:00:00Z", "2024-03-10T14:00:00Z"},
+		{"now-7d/d", RequestedRange{StartTime: "now-7d/d"}, springForward, "2024-03-03T00:00:00Z", "2024-03-10T15:30:00Z"},
+		{"now-7d/d to now/d", RequestedRange{StartTime: "now-7d/d", EndTime: "now/d"}, springForward, "2024-03-03T00:00:00Z", "2024-03-11T00:00:00Z"},
+		{"now-7d/d in tz", RequestedRange{StartTime: "now-7d/d", TimeZone: "America/New_York"}, springForward, "2024-03-03T05:00:00Z", "2024-03-10T15:30:00Z"},
+		{"rounding before offset", RequestedRange{StartTime: "now/d-1h", EndTime: "now/d+1h"}, springForward, "2024-03-09T23:00:00Z", "2024-03-11T01:00:00Z"},
+		{"today", RequestedRange{Range: "today"}, springForward, "2024-03-10T00:00:00Z", "2024-03-11T00:00:00Z"},
+		{"yesterday", RequestedRange{Range: "yesterday"}, springForward, "2024-03-09T00:00:00Z", "2024-03-10T00:00:00Z"},
+		{"this week starts on monday", RequestedRange{Range: "this_week"}, springForward, "2024-03-04T00:00:00Z", "2024-03-11T00:00:00Z"},
+		{"this month", RequestedRange{Range: "this month"}, springForward, "2024-03-01T00:00:00Z", "2024-04-01T00:00:00Z"},
+		{"last month", RequestedRange{Range: "Last-Month"}, springForward, "2024-02-01T00:00:00Z", "2024-03-01T00:00:00Z"},
+		{"this month over a dst change", RequestedRange{Range: "this_month", TimeZone: "Europe/Berlin"}, springForward, "2024-02-29T23:00:00Z", "2024-03-31T22:00:00Z"},
+		{"today with 23 hours", RequestedRange{Range: "today", TimeZone: "America/New_York"}, springForward, "2024-03-10T05:00:00Z", "2024-03-11T04:00:00Z"},
+		{"today with 25 hours", RequestedRange{Range: "today", TimeZone: "America/New_York"}, fallBack, "2024-11-03T04:00:00Z", "2024-11-04T05:00:00Z"},
+		{"yesterday before a dst change", RequestedRange{Range: "yesterday", TimeZone: "America/New_York"}, springForward, "2024-03-09T05:00:00Z", "2024-03-10T05:00:00Z"},
+		{"now-1d over a dst change", RequestedRange{StartTime: "now-1d", TimeZone: "America/New_York"}, springForward, "2024-03-09T16:30:00Z", "2024-03-10T15:30:00Z"},
+		{"local times over a dst change", RequestedRange{StartTime: "2024-03-10 01:30", EndTime: "2024-03-10 03:30", TimeZone: "America/New_York"}, springForward, "2024-03-10T06:30:00Z", "2024-03-10T07:30:00Z"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr, err := ResolveTimeRange(test.requested, test.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if from := tr.From.Format(time.RFC3339); from != test.from {
+				t.Errorf("from = %s, want %s", from, test.from)
+			}
+			if to := tr.To.Format(time.RFC3339); to != test.to {
+				t.Errorf("to = %s, want %s", to, test.to)
+			}
+		})
+	}
+}
+
+func TestResolveTimeRangeErrors(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		requested RequestedRange
+	}{
+		{"unknown tz", RequestedRange{TimeZone: "Mars/Olympus"}},
+		{"unknown range", RequestedRange{Range: "fortnight"}},
+		{"unknown unit", RequestedRange{StartTime: "now-5x"}},
+		{"missing unit", RequestedRange{StartTime: "now-5"}},
+		{"missing rounding unit", RequestedRange{StartTime: "now/"}},
+		{"not a time", RequestedRange{StartTime: "yesterday"}},
+		{"start in the future", RequestedRange{StartTime: "now+1h"}},
+		{"start after end", RequestedRange{StartTime: "now-1h", EndTime: "now-2h"}},
+		{"empty range", RequestedRange{StartTime: "now/d", EndTime: "now/d-1d"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if tr, err := ResolveTimeRange(test.requested, now); err == nil {
+				t.Errorf("ResolveTimeRange() = %v to %v, want an error", tr.From, tr.To)
+			}
+		})
+	}
+}
+
+func TestParseRelative(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 45, 0, time.UTC)
+	tests := []struct {
+		expression string
+		isEnd      bool
+		want       string
+	}{
+		{"now", false, "2024-03-10T15:30:45Z"},
+		{"now-6h", false, "2024-03-10T09:30:45Z"},
+		{"now-m", false, "2024-03-10T15:29:45Z"},
+		{"now/m", false, "2024-03-10T15:30:00Z"},
+		{"now/h", true, "2024-03-10T16:00:00Z"},
+		{"now-7d/d", false, "2024-03-03T00:00:00Z"},
+		{"now-7d/d", true, "2024-03-04T00:00:00Z"},
+		{"now-1M/M", false, "2024-02-01T00:00:00Z"},
+		{"now-1y/y", true, "2024-01-01T00:00:00Z"},
+		{"now+2w", false, "2024-03-24T15:30:45Z"},
+	}
+	for _, test := range tests {
+		got, err := parseRelative(test.expression, now, test.isEnd)
+		if err != nil {
+			t.Errorf("parseRelative(%q) failed: %v", test.expression, err)
+			continue
+		}
+		if got.Format(time.RFC3339) != test.want {
+			t.Errorf("parseRelative(%q, %v) = %s, want %s", test.expression, test.isEnd, got.Format(time.RFC3339), test.want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package promapi
+
+import (
+	"awsx-api/handlers"
+	"awsx-api/log"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	errorBadData   = "bad_data"
+	errorExecution = "execution"
+
+	// instantLookback is how far back an instant query looks for the latest point. CloudWatch
+	// publishes most metrics every five minutes and with some delay.
+	instantLookback = 15 * time.Minute
+)
+
+type response struct {
+	Status    string      `json:"status"`
+	Data      interface{} `json:"data,omitempty"`
+	ErrorType string      `json:"errorType,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Warnings  []string    `json:"warnings,omitempty"`
+}
+
+type queryData struct {
+	ResultType string      `json:"resultType"`
+	Result     interface{} `json:"result"`
+}
+
+type matrixSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values [][2]interface{}  `json:"values"`
+}
+
+type vectorSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]interface{}    `json:"value"`
+}
+
+// QueryRange answers /api/v1/query_range with a matrix of the panels the selector names.
+func QueryRange(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithError(w, http.StatusBadRequest, errorBadData, err.Error())
+		return
+	}
+	selector, err := ParseSelector(r.Form.Get("query"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, errorBadData, err.Error())
+		return
+	}
+	start, err := parseTime(r.Form.Get("start"), time.Time{})
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, errorBadData, "invalid parameter \"start\": "+err.Error())
+		return
+	}
+	end, err := parseTime(r.Form.Get("end"), time.Time{})
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, errorBadData, "invalid parameter \"end\": "+err.Error())
+		return
+	}
+	if end.Before(start) {
+		respondWithError(w, http.StatusBadRequest, errorBadData, "end timestamp must not be before start time")
+		return
+	}
+	step, err := parseDuration(r.Form.Get("step"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, errorBadData, "invalid parameter \"step\": "+err.Error())
+		return
+	}
+
+	samples, warnings, err := evaluate(r, selector, start, end, step)
+	if errors.Is(err, errTooManyMetrics) {
+		respondWithError(w, http.StatusBadRequest, errorBadData, err.Error())
+		return
+	}
+	if err != nil {
+		log.FromContext(r.Context()).Warningf("query_range [%s] failed: %v", r.Form.Get("query"), err)
+		respondWithError(w, http.StatusUnprocessableEntity, errorExecution, err.Error())
+		return
+	}
+	result := make([]matrixSeries, 0, len(samples))
+	for _, s := range samples {
+		values := make([][2]interface{}, 0, len(s.points))
+		for _, p := range s.points {
+			values = append(values, [2]interface{}{unixSeconds(p.Time), formatValue(p.Value)})
+		}
+		result = append(result, matrixSeries{Metric: s.labels, Values: values})
+	}
+	respondWithWarnings(w, queryData{ResultType: "matrix", Result: result}, warnings)
+}
+
+// Query answers /api/v1/query with the latest point of every series at the given time.
+// Numbers and arithmetic on two numbers (the "1+1" connection test of Grafana) are
+// evaluated as scalars.
+func Query(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithError(w, http.StatusBadRequest, errorBadData, err.Error())
+		return
+	}
+	at, err := parseTime(r.Form.Get("time"), time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, errorBadData, "invalid parameter \"time\": "+err.Error())
+		return
+	}
+	expression := r.Form.Get("query")
+	if value, ok := evalScalar(expression); ok {
+		respond(w, queryData{ResultType: "scalar", Result: [2]interface{}{unixSeconds(at), formatValue(value)}})
+		return
+	}
+	selector, err := ParseSelector(expression)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, errorBadData, err.Error())
+		return
+	}
+	samples, warnings, err := evaluate(r, selector, at.Add(-instantLookback), at, 0)
+	if errors.Is(err, errTooManyMetrics) {
+		respondWithError(w, http.StatusBadRequest, errorBadData, err.Error())
+		return
+	}
+	if err != nil {
+		log.FromContext(r.Context()).Warningf("query [%s] failed: %v", expression, err)
+		respondWithError(w, http.StatusUnprocessableEntity, errorExecution, err.Error())
+		return
+	}
+	result := make([]vectorSample, 0, len(samples))
+	for _, s := range samples {
+		if len(s.points) == 0 {
+			continue
+		}
+		latest := s.points[len(s.points)-1]
+		result = append(result, vectorSample{Metric: s.labels, Value: [2]interface{}{unixSeconds(at), formatValue(latest.Value)}})
+	}
+	respondWithWarnings(w, queryData{ResultType: "vector", Result: result}, warnings)
+}
+
+// Series answers /api/v1/series with the label sets of the match[] selectors. The panels are
+// not called, the series are the metrics with the labels the selector pins.
+func Series(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithError(w, http.StatusBadRequest, errorBadData, err.Error())
+		return
+	}
+	matches := r.Form["match[]"]
+	if len(matches) == 0 {
+		respondWithError(w, http.StatusBadRequest, errorBadData, "no match[] parameter provided")
+		return
+	}
+	byName, _ := metrics()
+	seen := map[string]bool{}
+	result := []map[string]string{}
+	for _, match := range matches {
+		selector, err := ParseSelector(match)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, errorBadData, err.Error())
+			return
+		}
+		for _, name := range matchingMetrics(selector) {
+			labels := labelsOf(name, byName[name])
+			for k, v := range selector.Equalities() {
+				labels[k] = v
+			}
+			if key := labelKey(labels); !seen[key] {
+				seen[key] = true
+				result = append(result, labels)
+			}
+		}
+	}
+	respond(w, result)
+}
+
+// Labels answers /api/v1/labels.
+func Labels(w http.ResponseWriter, r *http.Request) {
+	labels := []string{labelName, labelElementType, labelSeries, "id", "label"}
+	labels = append(labels, panelParameterLabels...)
+	sort.Strings(labels)
+	respond(w, labels)
+}
+
+// LabelValues answers /api/v1/label/{name}/values. Only the metric names and element types
+// are known without calling the panels.
+func LabelValues(w http.ResponseWriter, r *http.Request) {
+	byName, names := metrics()
+	values := []string{}
+	switch mux.Vars(r)["name"] {
+	case labelName:
+		values = names
+	case labelElementType:
+		seen := map[string]bool{}
+		for _, name := range names {
+			if elementType := byName[name].ElementType; !seen[elementType] {
+				seen[elementType] = true
+				values = append(values, elementType)
+			}
+		}
+		sort.Strings(values)
+	}
+	respond(w, values)
+}
+
+func respond(w http.ResponseWriter, data interface{}) {
+	handlers.RespondWithJSON(w, http.StatusOK, response{Status: "success", Data: data})
+}
+
+// respondWithWarnings answers with the panels that failed as warnings, which Grafana shows
+// next to the data of the others.
+func respondWithWarnings(w http.ResponseWriter, data interface{}, warnings []string) {
+	handlers.RespondWithJSON(w, http.StatusOK, response{Status: "success", Data: data, Warnings: warnings})
+}
+
+func respondWithError(w http.ResponseWriter, code int, errorType, message string) {
+	handlers.RespondWithJSON(w, code, response{Status: "error", ErrorType: errorType, Error: message})
+}
+
+// parseTime reads a unix timestamp in seconds or an RFC3339 time, def when it is empty.
+func parseTime(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		if def.IsZero() {
+			return time.Time{}, fmt.Errorf("missing timestamp")
+		}
+		return def, nil
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		whole, fraction := math.Modf(seconds)
+		return time.Unix(int64(whole), int64(fraction*1e9)).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", value)
+	}
+	return t.UTC(), nil
+}
+
+// parseDuration reads a step in seconds or as duration such as 5m.
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %q to a valid duration", value)
+	}
+	return d, nil
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// evalScalar evaluates a number or two numbers joined by +, -, * or /.
+func evalScalar(expression string) (float64, bool) {
+	expression = strings.TrimSpace(expression)
+	if v, err := strconv.ParseFloat(expression, 64); err == nil {
+		return v, true
+	}
+	for _, op := range []string{"+", "-", "*", "/"} {
+		i := strings.LastIndex(expression, op)
+		if i <= 0 {
+			continue
+		}
+		left, errLeft := strconv.ParseFloat(strings.TrimSpace(expression[:i]), 64)
+		right, errRight := strconv.ParseFloat(strings.TrimSpace(expression[i+1:]), 64)
+		if errLeft != nil || errRight != nil {
+			continue
+		}
+		switch op {
+		case "+":
+			return left + right, true
+		case "-":
+			return left - right, true
+		case "*":
+			return left * right, true
+		default:
+			return left / right, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,170 @@
+package promapi
+
+import (
+	"awsx-api/handlers"
+	"awsx-api/panel"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	labelName        = "__name__"
+	labelElementType = "elementType"
+	labelSeries      = "series"
+	metricPrefix     = "awsx_"
+
+	// maxQueriedMetrics is how many panels a selector without an exact metric name may run
+	// in one query, each one calls AWS.
+	maxQueriedMetrics = 10
+)
+
+// errTooManyMetrics is returned for a selector that matches more than maxQueriedMetrics.
+var errTooManyMetrics = errors.New("too many metrics")
+
+// generatedLabels are set on every series by the API, they are not panel parameters.
+var generatedLabels = map[string]bool{labelName: true, labelElementType: true, labelSeries: true, "id": true, "label": true}
+
+// panelParameterLabels are the parameters of the panels offered as label names.
+var panelParameterLabels = []string{"cmdbApiUrl", "crossAccountRoleArn", "elementId", "externalId", "filter", "instanceId", "logGroupName", "stat", "zone"}
+
+var nonMetricChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// MetricName is the metric of a panel: awsx_<elementType>_<query without _panel>, e.g.
+// awsx_ec2_cpu_utilization for the EC2 cpu_utilization_panel.
+func MetricName(definition panel.Definition) string {
+	elementType := strings.Trim(nonMetricChars.ReplaceAllString(strings.ToLower(definition.ElementType), "_"), "_")
+	query := strings.Trim(nonMetricChars.ReplaceAllString(strings.ToLower(strings.TrimSuffix(definition.Query, "_panel")), "_"), "_")
+	return metricPrefix + elementType + "_" + query
+}
+
+// metrics maps the metric names to the panels. Element types that only differ in case or
+// punctuation (APIGATEWAY, ApiGateway) give the same name, the first in registry order wins.
+func metrics() (map[string]panel.Definition, []string) {
+	byName := map[string]panel.Definition{}
+	var names []string
+	for _, definition := range handlers.Panels().Definitions() {
+		name := MetricName(definition)
+		if _, ok := byName[name]; !ok {
+			byName[name] = definition
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return byName, names
+}
+
+// matchingMetrics lists the panels whose metric name the selector accepts.
+func matchingMetrics(selector Selector) []string {
+	byName, names := metrics()
+	if name := selector.MetricName(); name != "" {
+		if _, ok := byName[name]; ok {
+			return []string{name}
+		}
+		return nil
+	}
+	var matching []string
+	for _, name := range names {
+		accepted := true
+		for _, m := range selector.Matchers {
+			if (m.Name == labelName || m.Name == labelElementType) && !m.Matches(labelsOf(name, byName[name])[m.Name]) {
+				accepted = false
+				break
+			}
+		}
+		if accepted {
+			matching = append(matching, name)
+		}
+	}
+	return matching
+}
+
+func labelsOf(name string, definition panel.Definition) map[string]string {
+	return map[string]string{labelName: name, labelElementType: definition.ElementType}
+}
+
+// sample is a series of a query result with its Prometheus labels.
+type sample struct {
+	labels map[string]string
+	points []panel.Point
+}
+
+// evaluate runs the panels of the selector over a time range and returns the series that
+// satisfy all matchers. step, rounded up to whole minutes, is passed to the panels as their
+// period. A panel that fails is skipped with a warning; the query only fails when all do.
+func evaluate(r *http.Request, selector Selector, start, end time.Time, step time.Duration) ([]sample, []string, error) {
+	byName, _ := metrics()
+	names := matchingMetrics(selector)
+	if len(names) > maxQueriedMetrics {
+		return nil, nil, fmt.Errorf("%w: the selector matches %d metrics, at most %d can be queried at once, select an exact __name__", errTooManyMetrics, len(names), maxQueriedMetrics)
+	}
+	params := url.Values{}
+	for name, value := range selector.Equalities() {
+		if !generatedLabels[name] {
+			params.Set(name, value)
+		}
+	}
+	if step > 0 {
+		// CloudWatch periods are whole minutes
+		minutes := int64(math.Ceil(step.Minutes()))
+		params.Set("period", strconv.FormatInt(minutes*60, 10))
+	}
+
+	var samples []sample
+	var warnings []string
+	for _, name := range names {
+		definition := byName[name]
+		payload, err := panel.Run(r, definition, params, start, end)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		for _, s := range panel.Normalize(payload, end) {
+			labels := labelsOf(name, definition)
+			for k := range params {
+				if k != "period" {
+					labels[k] = params.Get(k)
+				}
+			}
+			for k, v := range s.Labels {
+				labels[k] = v
+			}
+			labels[labelSeries] = s.Name
+			if !selector.Matches(labels) {
+				continue
+			}
+			var points []panel.Point
+			for _, p := range s.Points {
+				if !p.Time.Before(start) && !p.Time.After(end) {
+					points = append(points, p)
+				}
+			}
+			samples = append(samples, sample{labels: labels, points: points})
+		}
+	}
+	if len(names) > 0 && len(warnings) == len(names) {
+		return nil, nil, errors.New(strings.Join(warnings, "; "))
+	}
+	sort.Slice(samples, func(i, j int) bool { return labelKey(samples[i].labels) < labelKey(samples[j].labels) })
+	return samples, warnings, nil
+}
+
+func labelKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k + "=" + labels[k] + ",")
+	}
+	return b.String()
+}
@@ -0,0 +1,213 @@
+package promapi
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	matchEqual     = "="
+	matchNotEqual  = "!="
+	matchRegexp    = "=~"
+	matchNotRegexp = "!~"
+)
+
+// Matcher is a label matcher of a selector, e.g. elementId="900000".
+type Matcher struct {
+	Name  string
+	Type  string
+	Value string
+	re    *regexp.Regexp
+}
+
+// Matches reports whether a label value satisfies the matcher. A missing label is "".
+func (m Matcher) Matches(value string) bool {
+	switch m.Type {
+	case matchEqual:
+		return value == m.Value
+	case matchNotEqual:
+		return value != m.Value
+	case matchRegexp:
+		return m.re.MatchString(value)
+	case matchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+// Selector is an instant vector selector, the only kind of PromQL expression served.
+type Selector struct {
+	Matchers []Matcher
+}
+
+// MetricName is the metric name the selector asks for, "" when it matches names by regexp.
+func (s Selector) MetricName() string {
+	for _, m := range s.Matchers {
+		if m.Name == labelName && m.Type == matchEqual {
+			return m.Value
+		}
+	}
+	return ""
+}
+
+// Matches reports whether all matchers accept the labels.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, m := range s.Matchers {
+		if !m.Matches(labels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equalities are the labels the selector pins to a single value. They are passed to the
+// panel as its parameters.
+func (s Selector) Equalities() map[string]string {
+	equalities := map[string]string{}
+	for _, m := range s.Matchers {
+		if m.Type == matchEqual {
+			equalities[m.Name] = m.Value
+		}
+	}
+	return equalities
+}
+
+// ParseSelector parses name{label="value", ...}. The name or the braces may be left out,
+// but not both.
+func ParseSelector(input string) (Selector, error) {
+	p := &selectorParser{input: strings.TrimSpace(input)}
+	var selector Selector
+	if name := p.identifier(); name != "" {
+		selector.Matchers = append(selector.Matchers, Matcher{Name: labelName, Type: matchEqual, Value: name})
+	}
+	p.skipSpace()
+	if p.peek() == '{' {
+		p.pos++
+		for {
+			p.skipSpace()
+			if p.peek() == '}' {
+				p.pos++
+				break
+			}
+			matcher, err := p.matcher()
+			if err != nil {
+				return Selector{}, err
+			}
+			selector.Matchers = append(selector.Matchers, matcher)
+			p.skipSpace()
+			switch p.peek() {
+			case ',':
+				p.pos++
+			case '}':
+			default:
+				return Selector{}, p.errorf("expected , or }")
+			}
+		}
+	}
+	p.skipSpace()
+	if p.pos != len(p.input) {
+		return Selector{}, p.errorf("only instant vector selectors such as awsx_ec2_cpu_utilization{elementId=\"900000\"} are supported")
+	}
+	if len(selector.Matchers) == 0 {
+		return Selector{}, fmt.Errorf("empty selector")
+	}
+	return selector, nil
+}
+
+type selectorParser struct {
+	input string
+	pos   int
+}
+
+func (p *selectorParser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *selectorParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *selectorParser) identifier() string {
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := rune(p.input[p.pos])
+		if c == '_' || c == ':' || unicode.IsLetter(c) || (p.pos > start && unicode.IsDigit(c)) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *selectorParser) matcher() (Matcher, error) {
+	name := p.identifier()
+	if name == "" {
+		return Matcher{}, p.errorf("expected label name")
+	}
+	p.skipSpace()
+	var matchType string
+	for _, op := range []string{matchRegexp, matchNotRegexp, matchNotEqual, matchEqual} {
+		if strings.HasPrefix(p.input[p.pos:], op) {
+			matchType = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if matchType == "" {
+		return Matcher{}, p.errorf("expected =, !=, =~ or !~")
+	}
+	p.skipSpace()
+	value, err := p.quoted()
+	if err != nil {
+		return Matcher{}, err
+	}
+	matcher := Matcher{Name: name, Type: matchType, Value: value}
+	if matchType == matchRegexp || matchType == matchNotRegexp {
+		// Prometheus regexps are fully anchored
+		if matcher.re, err = regexp.Compile("^(?:" + value + ")$"); err != nil {
+			return Matcher{}, fmt.Errorf("invalid regexp of label %s: %v", name, err)
+		}
+	}
+	return matcher, nil
+}
+
+func (p *selectorParser) quoted() (string, error) {
+	quote := p.peek()
+	if quote != '"' && quote != '\'' && quote != '`' {
+		return "", p.errorf("expected quoted label value")
+	}
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.input) && p.input[p.pos] != quote {
+		if p.input[p.pos] == '\\' && quote != '`' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.input) {
+		return "", p.errorf("unterminated label value")
+	}
+	p.pos++
+	raw := p.input[start:p.pos]
+	if quote == '\'' {
+		raw = `"` + strings.ReplaceAll(strings.ReplaceAll(raw[1:len(raw)-1], `\'`, `'`), `"`, `\"`) + `"`
+	}
+	value, err := strconv.Unquote(raw)
+	if err != nil {
+		return "", p.errorf("invalid label value %s", raw)
+	}
+	return value, nil
+}
+
+func (p *selectorParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("parse error at char %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
@@ -1,9 +1,24 @@
 package routing
 
 import (
+	"awsx-api/alerting"
+	"awsx-api/anomaly"
+	"awsx-api/config"
+	"awsx-api/cost"
+	"awsx-api/forecast"
+	"awsx-api/grafana"
 	"awsx-api/handlers"
+	"awsx-api/internalmetrics"
+	"awsx-api/log"
+	"awsx-api/notifier"
+	"awsx-api/panel"
+	"awsx-api/promapi"
+	"awsx-api/sla"
 	"github.com/gorilla/mux"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 )
 
 // Route describes a single route
@@ -118,11 +133,188 @@ func NewRoutes() (r *Routes) {
 			handlers.Liveness,
 			false,
 		},
+		{
+			"health",
+			"GET",
+			"/app-health/awsx-api/health",
+			handlers.Health,
+			false,
+		},
 		{
 			"AwsxCloudWatchQueryApi",
 			"GET",
 			"/awsx-api/getQueryOutput",
-			handlers.ExecuteQuery,
+			panel.TimeRangeHandler(panel.FrameHandler(panel.EnvelopeHandler(panel.ExportHandler(handlers.ExecuteQuery)))),
+			true,
+		},
+		{
+			"AwsxAnomalies",
+			"GET",
+			"/awsx-api/anomalies",
+			anomaly.GetAnomalies,
+			true,
+		},
+		{
+			"AwsxForecast",
+			"GET",
+			"/awsx-api/forecast",
+			forecast.GetForecast,
+			true,
+		},
+		{
+			"AwsxAlerts",
+			"GET",
+			"/awsx-api/alerts",
+			alerting.GetAlerts,
+			true,
+		},
+		{
+			"AwsxAlertRules",
+			"GET",
+			"/awsx-api/alerts/rules",
+			alerting.GetRules,
+			true,
+		},
+		{
+			"AwsxSlaDetails",
+			"GET",
+			"/awsx-api/getSlaDetails",
+			sla.GetSlaDetails,
+			true,
+		},
+		{
+			"AwsxSlaReport",
+			"GET",
+			"/awsx-api/getSlaDetails/report",
+			sla.GetSlaReport,
+			true,
+		},
+		{
+			"AwsxCostDetails",
+			"GET",
+			"/awsx-api/getCostDetails",
+			cost.GetCostDetails,
+			true,
+		},
+		{
+			"AwsxSilences",
+			"GET",
+			"/awsx-api/silences",
+			notifier.GetSilences,
+			true,
+		},
+		{
+			"AwsxCreateSilence",
+			"POST",
+			"/awsx-api/silences",
+			notifier.CreateSilence,
+			true,
+		},
+		{
+			"AwsxExpireSilence",
+			"DELETE",
+			"/awsx-api/silences/{id}",
+			notifier.ExpireSilence,
+			true,
+		},
+		// Grafana JSON datasource protocol, see specs/grafana/API-SPEC.md
+		{
+			"GrafanaTestConnection",
+			"GET",
+			"/grafana",
+			grafana.TestConnection,
+			true,
+		},
+		{
+			"GrafanaSearch",
+			"POST",
+			"/grafana/search",
+			grafana.Search,
+			true,
+		},
+		{
+			"GrafanaQuery",
+			"POST",
+			"/grafana/query",
+			grafana.Query,
+			true,
+		},
+		{
+			"GrafanaAnnotations",
+			"POST",
+			"/grafana/annotations",
+			grafana.Annotations,
+			true,
+		},
+		{
+			"GrafanaVariable",
+			"POST",
+			"/grafana/variable",
+			grafana.Variable,
+			true,
+		},
+		// read-only subset of the Prometheus HTTP API, see specs/prometheus/API-SPEC.md
+		{
+			"PrometheusQueryRange",
+			"GET",
+			"/api/v1/query_range",
+			promapi.QueryRange,
+			true,
+		},
+		{
+			"PrometheusQueryRange",
+			"POST",
+			"/api/v1/query_range",
+			promapi.QueryRange,
+			true,
+		},
+		{
+			"PrometheusQuery",
+			"GET",
+			"/api/v1/query",
+			promapi.Query,
+			true,
+		},
+		{
+			"PrometheusQuery",
+			"POST",
+			"/api/v1/query",
+			promapi.Query,
+			true,
+		},
+		{
+			"PrometheusSeries",
+			"GET",
+			"/api/v1/series",
+			promapi.Series,
+			true,
+		},
+		{
+			"PrometheusSeries",
+			"POST",
+			"/api/v1/series",
+			promapi.Series,
+			true,
+		},
+		{
+			"PrometheusLabels",
+			"GET",
+			"/api/v1/labels",
+			promapi.Labels,
+			true,
+		},
+		{
+			"PrometheusLabels",
+			"POST",
+			"/api/v1/labels",
+			promapi.Labels,
+			true,
+		},
+		{
+			"PrometheusLabelValues",
+			"GET",
+			"/api/v1/label/{name}/values",
+			promapi.LabelValues,
 			true,
 		},
 		// {
@@ -300,72 +492,51 @@ func NewRoutes() (r *Routes) {
 	return
 }
 
+// NewRouter creates the router of all API routes and, when a static content root directory is
+// present, of the web console. Everything is mounted under the configured web_root.
 func NewRouter() *mux.Router {
 
-	// conf := config.Get()
-	// webRoot := "" //conf.Server.WebRoot
-	// webRootWithSlash := webRoot + "/"
-
-	appRouter := mux.NewRouter().StrictSlash(true)
-	// appRouter := rootRouter
-
-	// staticFileServer := http.FileServer(http.Dir(conf.Server.StaticContentRootDirectory))s
-
-	// if webRoot != "/" {
-	// 	// help the user out - if a request comes in for "/", redirect to our true webroot
-	// 	rootRouter.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-	// 		http.Redirect(w, r, webRootWithSlash, http.StatusFound)
-	// 	})
-
-	// 	appRouter = rootRouter.PathPrefix(conf.Server.WebRoot).Subrouter()
-	// 	staticFileServer = http.StripPrefix(webRootWithSlash, staticFileServer)
+	conf := config.Get()
+	// a trailing slash would mount the routes at /awsx//
+	webRoot := strings.TrimRight(conf.Server.WebRoot, "/")
+	if webRoot == "" {
+		webRoot = "/"
+	}
+	webRootWithSlash := webRoot + "/"
 
-	// 	// Because of OIDC, when we receive a request for the webroot without
-	// 	// the trailing slash, we can not redirect the user to the correct
-	// 	// webroot as the hash params are lost (and they are not sent to the
-	// 	// server).
-	// 	//
-	// 	// See https://github.com/kiali/kiali/issues/3103
-	// 	rootRouter.HandleFunc(webRoot, func(w http.ResponseWriter, r *http.Request) {
-	// 		r.URL.Path = webRootWithSlash
-	// 		rootRouter.ServeHTTP(w, r)
-	// 	})
-	// } else {
-	// webRootWithSlash = "/"
-	// }
+	rootRouter := mux.NewRouter().StrictSlash(true)
+	appRouter := rootRouter
 
-	// fileServerHandler := func(w http.ResponseWriter, r *http.Request) {
-	// 	urlPath := r.RequestURI
-	// 	if r.URL != nil {
-	// 		urlPath = r.URL.Path
-	// 	}
+	if webRoot != "/" {
+		// help the user out - if a request comes in for "/", redirect to our true webroot
+		rootRouter.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, webRootWithSlash, http.StatusFound)
+		})
 
-	// 	if urlPath == webRootWithSlash || urlPath == webRoot || urlPath == webRootWithSlash+"index.html" {
-	// 		serveIndexFile(w)
-	// 	} else if urlPath == webRootWithSlash+"env.js" {
-	// 		serveEnvJsFile(w)
-	// 	} else {
-	// 		staticFileServer.ServeHTTP(w, r)
-	// 	}
-	// }
-
-	// appRouter = appRouter.StrictSlash(true)
+		appRouter = rootRouter.PathPrefix(webRoot).Subrouter()
+	} else {
+		webRootWithSlash = "/"
+	}
 
 	// Build our API server routes and install them.
 	apiRoutes := NewRoutes()
+	auditEnabled := conf.Server.AuditLog
 	// authenticationHandler, _ := handlers.NewAuthenticationHandler()
 	for _, route := range apiRoutes.Routes {
-		// handlerFunction := metricHandler(route.HandlerFunc, route)
+		handlerFunction := metricHandler(route.HandlerFunc, route)
 		// if route.Authenticated {
 		// 	handlerFunction = authenticationHandler.Handle(handlerFunction)
 		// } else {
 		// 	handlerFunction = authenticationHandler.HandleUnauthenticated(handlerFunction)
 		// }
+		if auditEnabled && isWriteMethod(route.Method) {
+			handlerFunction = auditHandler(handlerFunction, route)
+		}
 		appRouter.
 			Methods(route.Method).
 			Path(route.Pattern).
 			Name(route.Name).
-			Handler(route.HandlerFunc)
+			Handler(handlerFunction)
 	}
 
 	// if authController := authentication.GetAuthController(); authController != nil {
@@ -374,22 +545,22 @@ func NewRouter() *mux.Router {
 	// 	}
 	// }
 
-	// All client-side routes are prefixed with /console.
-	// They are forwarded to index.html and will be handled by react-router.
-	// appRouter.PathPrefix("/console").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-	// 	serveIndexFile(w)
-	// })
-
-	// if authController := authentication.GetAuthController(); authController != nil {
-	// 	if ac, ok := authController.(*authentication.OpenIdAuthController); ok {
-	// 		authCallback := ac.GetAuthCallbackHandler(http.HandlerFunc(fileServerHandler))
-	// 		rootRouter.Methods("GET").Path(webRootWithSlash).Handler(authCallback)
-	// 	}
-	// }
-
-	// rootRouter.PathPrefix(webRootWithSlash).HandlerFunc(fileServerHandler)
+	// The console is optional, the API is served without it
+	if info, err := os.Stat(conf.Server.StaticContentRootDirectory); err == nil && info.IsDir() {
+		log.Infof("Server endpoint will serve static content from [%v] at [%v]", conf.Server.StaticContentRootDirectory, webRootWithSlash)
+		console := consoleHandler(conf.Server, webRoot)
+		// A request for the webroot without the trailing slash is served as the webroot,
+		// a redirect would lose the hash params of the console. Matched exactly, as
+		// StrictSlash would redirect between both forms.
+		rootRouter.MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+			return r.URL.Path == webRoot || r.URL.Path == webRootWithSlash
+		}).Handler(console)
+		rootRouter.PathPrefix(webRootWithSlash).Handler(console)
+	} else {
+		log.Warningf("Static content root directory [%v] not found, the web console is not served", conf.Server.StaticContentRootDirectory)
+	}
 
-	return appRouter
+	return rootRouter
 }
 
 // statusResponseWriter contains a ResponseWriter and a StatusCode to read in the metrics middleware
@@ -404,73 +575,54 @@ func (srw *statusResponseWriter) WriteHeader(code int) {
 	srw.StatusCode = code
 }
 
-// updateMetric evaluates the StatusCode, if there is an error, increase the API failure counter, otherwise save the duration
-// func updateMetric(route string, srw *statusResponseWriter, timer *prometheus.Timer) {
-// 	// Always measure the duration even if the API call ended in an error
-// 	timer.ObserveDuration()
-// 	// Increase the error counter on 500 and 503 errors
-// 	if srw.StatusCode == http.StatusInternalServerError || srw.StatusCode == http.StatusServiceUnavailable {
-// 		internalmetrics.GetAPIFailureMetric(route).Inc()
-// 	}
-// }
-
-// func metricHandler(next http.Handler, route Route) http.Handler {
-// 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-// 		// By default, if there is no call to WriteHeader, an 200 will be
-// 		srw := &statusResponseWriter{
-// 			ResponseWriter: w,
-// 			StatusCode:     http.StatusOK,
-// 		}
-// 		promtimer := internalmetrics.GetAPIProcessingTimePrometheusTimer(route.Name)
-// 		defer updateMetric(route.Name, srw, promtimer)
-// 		next.ServeHTTP(srw, r)
-// 	})
-// }
-
-// serveEnvJsFile generates the env.js file needed by the UI from Kiali configs. The
-// generated file is sent to the HTTP response.
-// func serveEnvJsFile(w http.ResponseWriter) {
-// 	conf := config.Get()
-// 	var body string
-// 	if len(conf.Server.WebHistoryMode) > 0 {
-// 		body += fmt.Sprintf("window.HISTORY_MODE='%s';", conf.Server.WebHistoryMode)
-// 	}
-
-// 	body += "window.WEB_ROOT = document.getElementsByTagName('base')[0].getAttribute('href').replace(/^https?:\\/\\/[^#?\\/]+/g, '').replace(/\\/+$/g, '')"
-
-// 	w.Header().Set("content-type", "text/javascript")
-// 	_, err := io.WriteString(w, body)
-// 	if err != nil {
-// 		log.Errorf("HTTP I/O error [%v]", err.Error())
-// 	}
-// }
-
-// serveIndexFile takes UI's index.html as a template to generate a modified index file that takes
-// into account the web_root path configured in the Kiali CR. The result is sent to the HTTP response.
-// func serveIndexFile(w http.ResponseWriter) {
-// 	webRootPath := config.Get().Server.WebRoot
-// 	webRootPath = strings.TrimSuffix(webRootPath, "/")
-
-// 	path, _ := filepath.Abs("./console/index.html")
-// 	b, err := ioutil.ReadFile(path)
-// 	if err != nil {
-// 		log.Errorf("File I/O error [%v]", err.Error())
-// 		handlers.RespondWithDetailedError(w, http.StatusInternalServerError, "Unable to read index.html template file", err.Error())
-// 		return
-// 	}
+// Flush is forwarded so that streaming handlers keep working behind the metrics middleware
+func (srw *statusResponseWriter) Flush() {
+	if flusher, ok := srw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
 
-// 	html := string(b)
-// 	newHTML := html
+func isWriteMethod(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
 
-// 	if len(webRootPath) != 0 {
-// 		searchStr := `<base href="/"`
-// 		newStr := `<base href="` + webRootPath + `/"`
-// 		newHTML = strings.Replace(html, searchStr, newStr, -1)
-// 	}
+// auditHandler writes an audit record for every call of a route that changes state.
+func auditHandler(next http.Handler, route Route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srw := &statusResponseWriter{
+			ResponseWriter: w,
+			StatusCode:     http.StatusOK,
+		}
+		start := time.Now()
+		next.ServeHTTP(srw, r)
+		log.Audit().
+			Str("requestId", log.RequestIdFromContext(r.Context())).
+			Str("route", route.Name).
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Int("status", srw.StatusCode).
+			Str("remoteAddr", r.RemoteAddr).
+			Str("userAgent", r.UserAgent()).
+			Dur("duration", time.Since(start)).
+			Send()
+	})
+}
 
-// 	w.Header().Set("content-type", "text/html")
-// 	_, err = io.WriteString(w, newHTML)
-// 	if err != nil {
-// 		log.Errorf("HTTP I/O error [%v]", err.Error())
-// 	}
-// }
+// metricHandler records the request count and duration of a route, labeled with the
+// elementType and query parameters and the response status.
+func metricHandler(next http.Handler, route Route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// By default, if there is no call to WriteHeader, an 200 will be
+		srw := &statusResponseWriter{
+			ResponseWriter: w,
+			StatusCode:     http.StatusOK,
+		}
+		start := time.Now()
+		// Always measure the duration even if the API call ended in an error
+		defer func() {
+			query := r.URL.Query()
+			internalmetrics.ObserveAPIRequest(route.Name, query.Get("elementType"), query.Get("query"), srw.StatusCode, time.Since(start))
+		}()
+		next.ServeHTTP(srw, r)
+	})
+}
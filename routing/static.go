@@ -0,0 +1,137 @@
+package routing
+
+import (
+	"awsx-api/config"
+	"awsx-api/log"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// hashedAssetPattern matches build outputs with a content hash in the file name, e.g.
+// main.3f2a1b9c.js (webpack) or index-BqU3nN_x.css (vite).
+var hashedAssetPattern = regexp.MustCompile(`[.-]([A-Za-z0-9_]{8,})\.(js|mjs|css|map|woff2?|ttf|eot|svg|png|jpe?g|gif|webp|ico)$`)
+
+// apiPathPrefixes are never answered with index.html, so an unknown API path gets a 404
+// instead of the console.
+var apiPathPrefixes = []string{"/awsx-api/", "/app-health/", "/management/", "/grafana/", "/api/v1/"}
+
+// consoleHandler serves the web console from the static content root directory. index.html
+// and env.js are generated for the configured web root. With the "browser" history mode,
+// client-side routes (paths without a file behind them) are answered with index.html.
+func consoleHandler(conf config.Server, webRoot string) http.Handler {
+	root := conf.StaticContentRootDirectory
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		urlPath := path.Clean("/" + strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(webRoot, "/")))
+		switch urlPath {
+		case "/", "/index.html":
+			serveIndexFile(w, root, webRoot)
+			return
+		case "/env.js":
+			serveEnvJsFile(w, conf.WebHistoryMode)
+			return
+		}
+
+		file := filepath.Join(root, filepath.FromSlash(urlPath))
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			if isHashedAsset(urlPath) {
+				w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			} else {
+				w.Header().Set("Cache-Control", "no-cache")
+			}
+			serveFile(w, r, file, info)
+			return
+		}
+
+		if conf.WebHistoryMode == "browser" && path.Ext(urlPath) == "" && !isApiPath(urlPath) {
+			serveIndexFile(w, root, webRoot)
+			return
+		}
+		http.NotFound(w, r)
+	})
+}
+
+func serveFile(w http.ResponseWriter, r *http.Request, file string, info os.FileInfo) {
+	f, err := os.Open(file)
+	if err != nil {
+		log.FromContext(r.Context()).Errorf("File I/O error [%v]", err.Error())
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+func isHashedAsset(urlPath string) bool {
+	match := hashedAssetPattern.FindStringSubmatch(path.Base(urlPath))
+	// a hash has at least one digit, this keeps names like app-settings.js out
+	return match != nil && strings.ContainsAny(match[1], "0123456789")
+}
+
+func isApiPath(urlPath string) bool {
+	for _, prefix := range apiPathPrefixes {
+		if strings.HasPrefix(urlPath+"/", prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveEnvJsFile generates the env.js file needed by the UI from the awsx-api config. The
+// generated file is sent to the HTTP response.
+func serveEnvJsFile(w http.ResponseWriter, historyMode string) {
+	var body string
+	if len(historyMode) > 0 {
+		body += fmt.Sprintf("window.HISTORY_MODE='%s';", historyMode)
+	}
+
+	body += "window.WEB_ROOT = document.getElementsByTagName('base')[0].getAttribute('href').replace(/^https?:\\/\\/[^#?\\/]+/g, '').replace(/\\/+$/g, '')"
+
+	w.Header().Set("content-type", "text/javascript")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, err := io.WriteString(w, body)
+	if err != nil {
+		log.Errorf("HTTP I/O error [%v]", err.Error())
+	}
+}
+
+// serveIndexFile takes UI's index.html as a template to generate a modified index file that takes
+// into account the configured web_root path. The result is sent to the HTTP response.
+func serveIndexFile(w http.ResponseWriter, root, webRoot string) {
+	webRootPath := strings.TrimSuffix(webRoot, "/")
+
+	b, err := os.ReadFile(filepath.Join(root, "index.html"))
+	if err != nil {
+		log.Errorf("File I/O error [%v]", err.Error())
+		http.Error(w, "Unable to read index.html template file", http.StatusInternalServerError)
+		return
+	}
+
+	html := string(b)
+	newHTML := html
+
+	if len(webRootPath) != 0 {
+		searchStr := `<base href="/"`
+		newStr := `<base href="` + webRootPath + `/"`
+		newHTML = strings.Replace(html, searchStr, newStr, -1)
+	}
+
+	w.Header().Set("content-type", "text/html")
+	// index.html references the hashed assets of the current build, it must always be revalidated
+	w.Header().Set("Cache-Control", "no-cache")
+	_, err = io.WriteString(w, newHTML)
+	if err != nil {
+		log.Errorf("HTTP I/O error [%v]", err.Error())
+	}
+}
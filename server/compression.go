@@ -0,0 +1,252 @@
+package server
+
+import (
+	"awsx-api/config"
+	"awsx-api/internalmetrics"
+	"bufio"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	encodingGzip   = "gzip"
+	encodingBrotli = "br"
+)
+
+// compressibleContentTypes lists the media types worth compressing. Everything else
+// (images, already compressed archives) is passed through untouched.
+var compressibleContentTypes = map[string]bool{
+	"application/javascript": true,
+	"application/json":       true,
+	"application/x-ndjson":   true,
+	"image/svg+xml":          true,
+	"text/css":               true,
+	"text/csv":               true,
+	"text/html":              true,
+	"text/javascript":        true,
+	"text/plain":             true,
+}
+
+// configureGzipHandler wraps the handler with content negotiated response compression.
+// Responses are buffered up to compression.min_size bytes so that small bodies are
+// sent as they are, the encoder overhead is not worth it for them.
+func configureGzipHandler(handler http.Handler) http.Handler {
+	conf := config.Get().Server.Compression
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), conf.BrotliEnabled)
+		if encoding == "" || r.Method == http.MethodHead {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressResponseWriter{
+			ResponseWriter: w,
+			encoding:       encoding,
+			level:          conf.Level,
+			minSize:        conf.MinSize,
+			status:         http.StatusOK,
+		}
+		defer cw.Close()
+		handler.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks the preferred encoding from an Accept-Encoding header, honoring
+// q-values. Brotli wins a tie with gzip when it is enabled.
+func negotiateEncoding(acceptEncoding string, brotliEnabled bool) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, q := parseEncodingQuality(part)
+		switch name {
+		case encodingBrotli:
+			if !brotliEnabled {
+				continue
+			}
+		case encodingGzip, "*":
+			name = encodingGzip
+		default:
+			continue
+		}
+		if q > bestQ || (q == bestQ && q > 0 && name == encodingBrotli) {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+func parseEncodingQuality(part string) (string, float64) {
+	fields := strings.Split(part, ";")
+	name := strings.ToLower(strings.TrimSpace(fields[0]))
+	q := 1.0
+	for _, param := range fields[1:] {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "q=") {
+			if v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+				q = v
+			}
+		}
+	}
+	return name, q
+}
+
+// compressResponseWriter holds back the response until it knows whether the body is worth
+// compressing: either min_size bytes were written, the handler flushed, or it finished.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding string
+	level    int
+	minSize  int
+	status   int
+
+	buf      []byte
+	decided  bool
+	encoder  io.WriteCloser
+	counter  *countingWriter
+	rawBytes int64
+}
+
+func (cw *compressResponseWriter) WriteHeader(code int) {
+	if cw.decided {
+		return
+	}
+	cw.status = code
+}
+
+func (cw *compressResponseWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.minSize {
+			return len(p), nil
+		}
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.encoder != nil {
+		cw.rawBytes += int64(len(p))
+		return cw.encoder.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush lets streaming handlers push partial output through the encoder.
+func (cw *compressResponseWriter) Flush() {
+	if !cw.decided {
+		// A handler that flushes is streaming, the body size is unknown, so only the
+		// content type decides.
+		cw.minSize = 0
+		if err := cw.decide(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack is forwarded so websocket upgrades keep working behind the compression handler.
+func (cw *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+func (cw *compressResponseWriter) Close() {
+	if !cw.decided {
+		_ = cw.decide()
+	}
+	if cw.encoder != nil {
+		_ = cw.encoder.Close()
+		internalmetrics.ObserveResponseCompression(cw.encoding, cw.rawBytes, cw.counter.n)
+	}
+}
+
+func (cw *compressResponseWriter) decide() error {
+	cw.decided = true
+	header := cw.ResponseWriter.Header()
+	if header.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	if cw.shouldCompress() {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", cw.encoding)
+		cw.counter = &countingWriter{w: cw.ResponseWriter}
+		cw.encoder = cw.newEncoder(cw.counter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	buf := cw.buf
+	cw.buf = nil
+	if cw.encoder != nil {
+		cw.rawBytes += int64(len(buf))
+		_, err := cw.encoder.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+func (cw *compressResponseWriter) shouldCompress() bool {
+	if len(cw.buf) < cw.minSize {
+		return false
+	}
+	if cw.status < http.StatusOK || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+	header := cw.ResponseWriter.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return compressibleContentTypes[mediaType]
+}
+
+func (cw *compressResponseWriter) newEncoder(w io.Writer) io.WriteCloser {
+	if cw.encoding == encodingBrotli {
+		level := brotli.DefaultCompression
+		if cw.level > 0 && cw.level <= brotli.BestCompression {
+			level = cw.level
+		}
+		return brotli.NewWriterLevel(w, level)
+	}
+	level := gzip.DefaultCompression
+	if cw.level > 0 && cw.level <= gzip.BestCompression {
+		level = cw.level
+	}
+	gw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		gw = gzip.NewWriter(w)
+	}
+	return gw
+}
+
+// countingWriter counts the compressed bytes that reach the client.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"awsx-api/config"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// corsPolicy is the resolved form of the CORS configuration. Origins are kept lower-cased
+// and split into exact matches and wildcard subdomain patterns.
+type corsPolicy struct {
+	allowAll         bool
+	allowCredentials bool
+	exactOrigins     map[string]bool
+	wildcardOrigins  []wildcardOrigin
+	allowedHeaders   string
+	allowedMethods   string
+	exposedHeaders   string
+	maxAge           string
+}
+
+// wildcardOrigin matches origins such as https://*.example.com. The wildcard covers one or
+// more subdomain labels but never the scheme or the parent domain itself.
+type wildcardOrigin struct {
+	prefix string
+	suffix string
+}
+
+func (o wildcardOrigin) match(origin string) bool {
+	if len(origin) <= len(o.prefix)+len(o.suffix) {
+		return false
+	}
+	if !strings.HasPrefix(origin, o.prefix) || !strings.HasSuffix(origin, o.suffix) {
+		return false
+	}
+	subdomain := origin[len(o.prefix) : len(origin)-len(o.suffix)]
+	return !strings.ContainsAny(subdomain, "/:@")
+}
+
+// corsEnabled reports whether the configuration asks for CORS headers at all.
+func corsEnabled(conf config.Server) bool {
+	return conf.CORSAllowAll || len(corsOrigins(conf)) > 0
+}
+
+// corsOrigins merges the legacy comma separated white_list_urls with cors.allowed_origins.
+func corsOrigins(conf config.Server) []string {
+	var origins []string
+	for _, origin := range strings.Split(conf.WhiteListUrls, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	for _, origin := range conf.CORS.AllowedOrigins {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// ValidateCORS rejects credentials together with allowing every origin, which would let any
+// site make credentialed requests. Origins are allowed all when none are configured or one is "*".
+func ValidateCORS(conf config.Server) error {
+	if conf.CORS.AllowCredentials && newCorsPolicy(conf).allowAll {
+		return fmt.Errorf("cors allow_credentials needs explicit cors.allowed_origins or white_list_urls, not all origins")
+	}
+	return nil
+}
+
+func newCorsPolicy(conf config.Server) *corsPolicy {
+	origins := corsOrigins(conf)
+	p := &corsPolicy{
+		allowAll:         len(origins) == 0,
+		allowCredentials: conf.CORS.AllowCredentials,
+		exactOrigins:     map[string]bool{},
+		allowedHeaders:   strings.Join(conf.CORS.AllowedHeaders, ", "),
+		allowedMethods:   strings.Join(conf.CORS.AllowedMethods, ", "),
+		exposedHeaders:   strings.Join(conf.CORS.ExposedHeaders, ", "),
+	}
+	if conf.CORS.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(conf.CORS.MaxAge)
+	}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		if origin == "*" {
+			p.allowAll = true
+		} else if i := strings.Index(origin, "*."); i >= 0 {
+			p.wildcardOrigins = append(p.wildcardOrigins, wildcardOrigin{prefix: origin[:i], suffix: origin[i+1:]})
+		} else {
+			p.exactOrigins[origin] = true
+		}
+	}
+	return p
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if p.exactOrigins[origin] {
+		return true
+	}
+	for _, wildcard := range p.wildcardOrigins {
+		if wildcard.match(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsAllowed answers CORS preflight requests and echoes the matched origin on every other
+// response. It wraps the whole router rather than being installed as a mux middleware so that
+// OPTIONS requests are handled even though the API routes are registered for GET only.
+func corsAllowed(next http.Handler) http.Handler {
+	policy := newCorsPolicy(config.Get().Server)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		w.Header().Add("Vary", "Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !policy.allowOrigin(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Any origin gets a literal "*", which browsers never combine with credentials. Only
+		// configured origins are echoed back, and only they may send credentials.
+		if policy.allowAll {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if policy.allowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if policy.allowedMethods != "" {
+				w.Header().Set("Access-Control-Allow-Methods", policy.allowedMethods)
+			}
+			if policy.allowedHeaders != "" {
+				w.Header().Set("Access-Control-Allow-Headers", policy.allowedHeaders)
+			} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				w.Header().Set("Access-Control-Allow-Headers", requested)
+			}
+			if policy.maxAge != "" {
+				w.Header().Set("Access-Control-Max-Age", policy.maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if policy.exposedHeaders != "" {
+			w.Header().Set("Access-Control-Expose-Headers", policy.exposedHeaders)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
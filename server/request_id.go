@@ -0,0 +1,58 @@
+package server
+
+import (
+	"awsx-api/appstate"
+	"awsx-api/log"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const requestIdHeader = "X-Request-ID"
+
+// requestIdPattern limits accepted ids to printable tokens, so a caller can not inject log
+// lines or oversized values through the header.
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._:\-]{1,128}$`)
+
+// requestIdHandler accepts the X-Request-ID of the caller or generates one, echoes it on the
+// response and puts a logger carrying it, the elementType, query and landingZoneId of the
+// request into the request context (see log.FromContext). Requests that name a cloud element
+// get its landing zone once the cache has looked it up. The request is listed as in flight
+// until it has been served.
+func requestIdHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(requestIdHeader)
+		if !requestIdPattern.MatchString(requestId) {
+			requestId = newRequestId()
+		}
+		w.Header().Set(requestIdHeader, requestId)
+
+		query := r.URL.Query()
+		ctx := log.NewContext(r.Context(), requestId)
+		ctx = log.WithField(ctx, log.ElementTypeField, query.Get("elementType"))
+		ctx = log.WithField(ctx, log.QueryField, query.Get("query"))
+		log.SetLandingZone(ctx, query.Get("landingZoneId"))
+
+		done := appstate.TrackRequest(appstate.InFlightRequest{
+			RequestId:   requestId,
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			ElementType: query.Get("elementType"),
+			Query:       query.Get("query"),
+			RemoteAddr:  r.RemoteAddr,
+			StartedAt:   time.Now(),
+		})
+		defer done()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Warningf("Failed to generate a request id: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -1,16 +1,23 @@
 package server
 
 import (
+	"awsx-api/cache"
 	"awsx-api/config"
 	"awsx-api/log"
+	"awsx-api/observability"
 	"awsx-api/routing"
 	"awsx-api/util"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"path"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // func HandleRequests() {
@@ -31,9 +38,10 @@ import (
 // }
 
 type Server struct {
-	httpServer *http.Server
-	router     *mux.Router
-	// tracer     *sdktrace.TracerProvider
+	httpServer   *http.Server
+	router       *mux.Router
+	certReloader *certReloader
+	tracer       *sdktrace.TracerProvider
 }
 
 func NewServer() *Server {
@@ -41,26 +49,32 @@ func NewServer() *Server {
 
 	// create a router that will route all incoming API server requests to different handlers
 	router := routing.NewRouter()
-	// var tracingProvider *sdktrace.TracerProvider
-	// if conf.Server.Observability.Tracing.Enabled {
-	// 	// log.Infof("Tracing Enabled. Initializing tracer with collector url: %s", conf.Server.Observability.Tracing.CollectorURL)
-	// 	tracingProvider = observability.InitTracer(conf.Server.Observability.Tracing.CollectorURL)
-	// }
+	var tracingProvider *sdktrace.TracerProvider
+	if conf.Server.Observability.Tracing.Enabled {
+		log.Infof("Tracing Enabled. Initializing tracer with %s exporter, collector url: %s", conf.Server.Observability.Tracing.Exporter, conf.Server.Observability.Tracing.CollectorURL)
+		var err error
+		tracingProvider, err = observability.InitTracer(conf.Server.Observability.Tracing)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cache.RegisterClientDecorator(observability.TraceAwsClient)
+	}
 
 	middlewares := []mux.MiddlewareFunc{}
-	if conf.Server.CORSAllowAll {
-		middlewares = append(middlewares, corsAllowed)
+	if conf.Server.Observability.Tracing.Enabled {
+		middlewares = append(middlewares, otelmux.Middleware(observability.TracingService))
 	}
-	// if conf.Server.Observability.Tracing.Enabled {
-	// 	middlewares = append(middlewares, otelmux.Middleware(observability.TracingService))
-	// }
 
 	router.Use(middlewares...)
 
 	handler := http.Handler(router)
-	// if conf.Server.GzipEnabled {
-	// 	handler = configureGzipHandler(router)
-	// }
+	if conf.Server.GzipEnabled {
+		handler = configureGzipHandler(handler)
+	}
+	if corsEnabled(conf.Server) {
+		handler = corsAllowed(handler)
+	}
+	handler = requestIdHandler(handler)
 
 	// The Kiali server has only a single http server ever during its lifetime. But to support
 	// testing that wants to start multiple servers over the lifetime of the process,
@@ -68,18 +82,11 @@ func NewServer() *Server {
 	mux := http.NewServeMux()
 	http.DefaultServeMux = mux
 	http.Handle("/", handler)
-	http.Handle("/management/prometheus", promhttp.Handler())
-
-	// Clients must use TLS 1.2 or higher
-	// tlsConfig := &tls.Config{
-	// 	MinVersion: tls.VersionTLS12,
-	// }
+	http.Handle(path.Join(conf.Server.WebRoot, "/management/prometheus"), promhttp.Handler())
 
 	// create the server definition that will handle both console and api server traffic
 	httpServer := &http.Server{
-		// Addr:         fmt.Sprintf("%v:%v", conf.Server.Address, conf.Server.Port),
-		Addr: fmt.Sprintf("%v:%v", conf.Server.Address, conf.Server.Port),
-		// TLSConfig:    tlsConfig,
+		Addr:         fmt.Sprintf("%v:%v", conf.Server.Address, conf.Server.Port),
 		ReadTimeout:  120 * time.Second,
 		WriteTimeout: 120 * time.Second,
 	}
@@ -89,9 +96,22 @@ func NewServer() *Server {
 		httpServer: httpServer,
 		router:     router,
 	}
-	// if conf.Server.Observability.Tracing.Enabled && tracingProvider != nil {
-	// 	s.tracer = tracingProvider
-	// }
+
+	if conf.Identity.CertFile != "" && conf.Identity.PrivateKeyFile != "" {
+		reloader, err := newCertReloader(conf.Identity.CertFile, conf.Identity.PrivateKeyFile, conf.Server.TLS.ClientCAFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tlsConfig, err := newTLSConfig(conf.Server.TLS, reloader)
+		if err != nil {
+			log.Fatal(err)
+		}
+		httpServer.TLSConfig = tlsConfig
+		s.certReloader = reloader
+	}
+	if conf.Server.Observability.Tracing.Enabled && tracingProvider != nil {
+		s.tracer = tracingProvider
+	}
 	return s
 }
 
@@ -102,23 +122,30 @@ func (s *Server) Start() {
 	// that the cache is ready before it's used by one of the server handlers.
 	// business.Start()
 
-	// conf := config.Get()
-	//log.Infof("Server endpoint will start at [%v%v]", s.httpServer.Addr, conf.Server.WebRoot)
-	log.Infof("Server endpoint will start at [%v%v]", s.httpServer.Addr, "/")
-	// log.Infof("Server endpoint will serve static content from [%v]", conf.Server.StaticContentRootDirectory)
-	// secure := conf.Identity.CertFile != "" && conf.Identity.PrivateKeyFile != ""
+	conf := config.Get()
+	log.Infof("Server endpoint will start at [%v%v]", s.httpServer.Addr, conf.Server.WebRoot)
+	secure := s.certReloader != nil
+	if secure {
+		log.Infof("Server endpoint will require https")
+		s.router.Use(secureHttpsMiddleware)
+		interval := time.Duration(conf.Server.TLS.ReloadInterval) * time.Second
+		if interval > 0 {
+			s.certReloader.watch(interval)
+		}
+	} else {
+		s.router.Use(plainHttpMiddleware)
+	}
 	go func() {
 		var err error
-		// if secure {
-		// 	// log.Infof("Server endpoint will require https")
-		// 	s.router.Use(secureHttpsMiddleware)
-		// 	err = s.httpServer.ListenAndServeTLS(conf.Identity.CertFile, conf.Identity.PrivateKeyFile)
-		// } else {
-		s.router.Use(plainHttpMiddleware)
-		err = s.httpServer.ListenAndServe()
-		util.CommonError(err)
-		// }
-		// log.Warning(err)
+		if secure {
+			// The certificate is served from memory by the reloader, not read from these files.
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = s.httpServer.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			util.CommonError(err)
+		}
 	}()
 
 	// Start the Metrics Server
@@ -127,56 +154,34 @@ func (s *Server) Start() {
 	// }
 }
 
-// Stop the HTTP server
-func (s *Server) Stop() {
+// Stop the HTTP server. In-flight requests get until ctx is done to complete, after that the
+// remaining connections are closed.
+func (s *Server) Stop(ctx context.Context) {
 	// StopMetricsServer()
 	// business.Stop()
-	// log.Infof("Server endpoint will stop at [%v]", s.httpServer.Addr)
-	s.httpServer.Close()
-	// observability.StopTracer(s.tracer)
+	log.Infof("Server endpoint will stop at [%v]", s.httpServer.Addr)
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		log.Warningf("Not all in-flight requests completed in time: %v", err)
+		s.httpServer.Close()
+	}
+	if s.certReloader != nil {
+		s.certReloader.stop()
+	}
+	if err := observability.StopTracer(ctx, s.tracer); err != nil {
+		log.Warningf("Failed to stop the tracer: %v", err)
+	}
 }
 
-func corsAllowed(next http.Handler) http.Handler {
-	conf := config.Get()
+func plainHttpMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if conf.Server.WhiteListUrls != "" {
-			w.Header().Set("Access-Control-Allow-Origin", conf.Server.WhiteListUrls)
-		} else {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-		}
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept")
+		r.URL.Scheme = "http"
 		next.ServeHTTP(w, r)
-
 	})
 }
 
-// func configureGzipHandler(handler http.Handler) http.Handler {
-// 	contentTypeOption := gziphandler.ContentTypes([]string{
-// 		"application/javascript",
-// 		"application/json",
-// 		"image/svg+xml",
-// 		"text/css",
-// 		"text/html",
-// 	})
-// 	if handlerFunc, err := gziphandler.GzipHandlerWithOpts(contentTypeOption); err == nil {
-// 		return handlerFunc(handler)
-// 	} else {
-// 		// This could happen by a wrong configuration being sent to GzipHandlerWithOpts
-// 		panic(err)
-// 	}
-// }
-
-func plainHttpMiddleware(next http.Handler) http.Handler {
+func secureHttpsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		r.URL.Scheme = "http"
+		r.URL.Scheme = "https"
 		next.ServeHTTP(w, r)
 	})
 }
-
-// func secureHttpsMiddleware(next http.Handler) http.Handler {
-// 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-// 		r.URL.Scheme = "https"
-// 		next.ServeHTTP(w, r)
-// 	})
-// }
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"awsx-api/config"
+	"awsx-api/log"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// certReloader keeps the serving certificate and the client CA pool in memory and swaps
+// them when the files on disk change, so rotated certificates are picked up without a restart.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	lock      sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+
+	stopChan chan struct{}
+}
+
+func newCertReloader(certFile, keyFile, caFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		modTimes: map[string]time.Time{},
+		stopChan: make(chan struct{}),
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload reads the certificate, key and CA bundle. On failure the previously loaded
+// material stays in use.
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server certificate [%v] and key [%v]: %v", r.certFile, r.keyFile, err)
+	}
+	var clientCAs *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA bundle [%v]: %v", r.caFile, err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("client CA bundle [%v] does not contain any PEM certificate", r.caFile)
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	for _, file := range r.files() {
+		if info, err := os.Stat(file); err == nil {
+			r.modTimes[file] = info.ModTime()
+		}
+	}
+	return nil
+}
+
+func (r *certReloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+// changed reports whether any watched file has a different modification time than at the last
+// load. os.Stat follows symlinks, so the atomic symlink swap done by Kubernetes secret volumes
+// is detected as well.
+func (r *certReloader) changed() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch polls the files every interval until stop is called.
+func (r *certReloader) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stopChan:
+				return
+			case <-ticker.C:
+				if !r.changed() {
+					continue
+				}
+				if err := r.reload(); err != nil {
+					log.Errorf("Certificate reload failed, keeping the current certificate: %v", err)
+				} else {
+					log.Infof("Reloaded server certificate from [%v]", r.certFile)
+				}
+			}
+		}
+	}()
+}
+
+func (r *certReloader) stop() {
+	close(r.stopChan)
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.cert, nil
+}
+
+// newTLSConfig builds the listener TLS configuration. Every handshake gets a copy of base
+// carrying the current client CA pool, so a rotated CA bundle applies to new connections.
+func newTLSConfig(conf config.TLS, reloader *certReloader) (*tls.Config, error) {
+	minVersion, err := parseTLSVersion(conf.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	cipherSuites, err := parseCipherSuites(conf.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	clientAuth, err := parseClientAuth(conf.ClientAuth, conf.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// The config of GetConfigForClient replaces the whole server config, so it has to offer
+	// HTTP/2 itself.
+	base := &tls.Config{
+		MinVersion:     minVersion,
+		CipherSuites:   cipherSuites,
+		ClientAuth:     clientAuth,
+		GetCertificate: reloader.getCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	return &tls.Config{
+		MinVersion: minVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c := base.Clone()
+			reloader.lock.RLock()
+			c.ClientCAs = reloader.clientCAs
+			reloader.lock.RUnlock()
+			return c, nil
+		},
+	}, nil
+}
+
+func parseTLSVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(version), "tls") {
+	case "", "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS min_version [%v], use 1.2 or 1.3", version)
+	}
+}
+
+// parseCipherSuites resolves IANA cipher suite names. Suites that Go considers insecure are rejected.
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure TLS cipher suite [%v]", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func parseClientAuth(mode string, caFile string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(mode) {
+	case "":
+		if caFile != "" {
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.NoClientCert, nil
+	case "none":
+		return tls.NoClientCert, nil
+	case "request":
+		if caFile == "" {
+			return 0, fmt.Errorf("tls client_auth [request] needs a client_ca_file")
+		}
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		if caFile == "" {
+			return 0, fmt.Errorf("tls client_auth [require] needs a client_ca_file")
+		}
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("unsupported tls client_auth [%v], use none, request or require", mode)
+	}
+}
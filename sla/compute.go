@@ -0,0 +1,258 @@
+package sla
+
+import (
+	"awsx-api/alerting"
+	"awsx-api/handlers"
+	"awsx-api/panel"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// burnAlert fires when both windows burn the error budget faster than consuming the given
+// share of it within the long window would. For a 30 day objective these are the 14.4, 6 and
+// 1 times burn rates of the multiwindow, multi-burn-rate alerts of the SRE workbook.
+type burnAlert struct {
+	severity    string
+	long, short time.Duration
+	consumed    float64
+}
+
+var burnAlerts = []burnAlert{
+	{alerting.SeverityCritical, time.Hour, 5 * time.Minute, 0.02},
+	{alerting.SeverityCritical, 6 * time.Hour, 30 * time.Minute, 0.05},
+	{alerting.SeverityWarning, 3 * 24 * time.Hour, 6 * time.Hour, 0.10},
+}
+
+// Status is how an objective does over its window up to now.
+type Status struct {
+	SLO       SLO             `json:"slo"`
+	TimeRange panel.TimeRange `json:"timeRange"`
+	// Attainment is the percentage of the window the objective was met.
+	Attainment  *float64     `json:"attainment,omitempty"`
+	ErrorBudget *ErrorBudget `json:"errorBudget,omitempty"`
+	BurnRates   []BurnRate   `json:"burnRates"`
+	Alerts      []BurnAlert  `json:"alerts"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// ErrorBudget is the time of the window the objective may be missed. RemainingPercent is the
+// share of it left, negative once it is exceeded.
+type ErrorBudget struct {
+	TotalMinutes     float64 `json:"totalMinutes"`
+	ConsumedMinutes  float64 `json:"consumedMinutes"`
+	RemainingMinutes float64 `json:"remainingMinutes"`
+	RemainingPercent float64 `json:"remainingPercent"`
+}
+
+// BurnRate is how many times faster than the window allows the error budget was consumed
+// over the last Window; 1 uses up the budget exactly at the end of the window.
+type BurnRate struct {
+	Window     string   `json:"window"`
+	Attainment *float64 `json:"attainment,omitempty"`
+	BurnRate   *float64 `json:"burnRate,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// BurnAlert is a multiwindow burn rate alert and whether it fires.
+type BurnAlert struct {
+	Severity    string  `json:"severity"`
+	LongWindow  string  `json:"longWindow"`
+	ShortWindow string  `json:"shortWindow"`
+	Threshold   float64 `json:"threshold"`
+	Firing      bool    `json:"firing"`
+}
+
+// measurement is the indicator of an objective over a time range.
+type measurement struct {
+	points []panel.Point
+	err    error
+}
+
+// Evaluate measures an objective over its window and the windows of the burn rate alerts that
+// fit in it, all ending at now.
+func Evaluate(r *http.Request, slo SLO, now time.Time) Status {
+	window := time.Duration(slo.Window)
+	status := Status{
+		SLO:       slo,
+		TimeRange: panel.TimeRange{From: now.Add(-window), To: now},
+		BurnRates: []BurnRate{},
+		Alerts:    []BurnAlert{},
+	}
+	windows := map[time.Duration]bool{window: true}
+	for _, alert := range burnAlerts {
+		if alert.long <= window {
+			windows[alert.long], windows[alert.short] = true, true
+		}
+	}
+	measurements := map[time.Duration]measurement{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := range windows {
+		wg.Add(1)
+		go func(w time.Duration) {
+			defer wg.Done()
+			points, err := measure(r, slo, slo.Panel, slo.Series, now.Add(-w), now)
+			mu.Lock()
+			measurements[w] = measurement{points, err}
+			mu.Unlock()
+		}(w)
+	}
+	wg.Wait()
+
+	if m := measurements[window]; m.err != nil {
+		status.Error = m.err.Error()
+	} else {
+		attainment := slo.attainment(m.points)
+		status.Attainment = round(attainment*100, 4)
+		total := slo.budget() * window.Minutes()
+		consumed := (1 - attainment) * window.Minutes()
+		status.ErrorBudget = &ErrorBudget{
+			TotalMinutes:     *round(total, 2),
+			ConsumedMinutes:  *round(consumed, 2),
+			RemainingMinutes: *round(total-consumed, 2),
+			RemainingPercent: *round((1-consumed/total)*100, 2),
+		}
+	}
+
+	burnRates := map[time.Duration]float64{}
+	sorted := make([]time.Duration, 0, len(windows))
+	for w := range windows {
+		sorted = append(sorted, w)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, w := range sorted {
+		burnRate := BurnRate{Window: formatWindow(w)}
+		if m := measurements[w]; m.err != nil {
+			burnRate.Error = m.err.Error()
+		} else {
+			attainment := slo.attainment(m.points)
+			burnRates[w] = (1 - attainment) / slo.budget()
+			burnRate.Attainment = round(attainment*100, 4)
+			burnRate.BurnRate = round(burnRates[w], 2)
+		}
+		status.BurnRates = append(status.BurnRates, burnRate)
+	}
+	for _, alert := range burnAlerts {
+		if alert.long > window {
+			continue
+		}
+		threshold := alert.consumed * float64(window) / float64(alert.long)
+		long, okLong := burnRates[alert.long]
+		short, okShort := burnRates[alert.short]
+		status.Alerts = append(status.Alerts, BurnAlert{
+			Severity:    alert.severity,
+			LongWindow:  formatWindow(alert.long),
+			ShortWindow: formatWindow(alert.short),
+			Threshold:   *round(threshold, 2),
+			Firing:      okLong && okShort && long > threshold && short > threshold,
+		})
+	}
+	return status
+}
+
+// good is the share of a point the objective was met: the availability relative to full
+// availability, or whether the latency was at most the threshold.
+func (slo SLO) good(value float64) float64 {
+	if slo.Objective == ObjectiveLatency {
+		if value <= slo.Threshold {
+			return 1
+		}
+		return 0
+	}
+	return math.Min(math.Max(value/slo.full(), 0), 1)
+}
+
+// attainment is the share of the points the objective was met.
+func (slo SLO) attainment(points []panel.Point) float64 {
+	sum := 0.0
+	for _, p := range points {
+		sum += slo.good(p.Value)
+	}
+	return sum / float64(len(points))
+}
+
+// measure runs a panel of the element of an objective and returns the points of a series,
+// the named one or else the first.
+func measure(r *http.Request, slo SLO, query, series string, from, to time.Time) ([]panel.Point, error) {
+	payload, err := run(r, slo, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range panel.Normalize(prepare(payload), to) {
+		if len(s.Points) > 0 && (series == "" || s.Name == series) {
+			return s.Points, nil
+		}
+	}
+	return nil, fmt.Errorf("panel [%s] has no data from %s to %s", query, from.Format(time.RFC3339), to.Format(time.RFC3339))
+}
+
+func run(r *http.Request, slo SLO, query string, from, to time.Time) (interface{}, error) {
+	definition, ok := handlers.Panels().Lookup(slo.ElementType, query)
+	if !ok {
+		return nil, fmt.Errorf("unknown panel [%s] of elementType [%s]", query, slo.ElementType)
+	}
+	params := url.Values{}
+	for k, v := range slo.Element {
+		params.Set(k, v)
+	}
+	return panel.Run(r, definition, params, from, to)
+}
+
+// prepare rewrites the panel answers panel.Normalize does not read: percentages in strings,
+// e.g. {"uptimePercentage": "99.50%"} of RDS, and lists of a timestamp and a single named
+// value, e.g. [{"Timestamp": "...", "Availability": 99.5}] of EKS.
+func prepare(v interface{}) interface{} {
+	switch value := v.(type) {
+	case string:
+		if trimmed := strings.TrimSpace(value); strings.HasSuffix(trimmed, "%") {
+			if f, err := strconv.ParseFloat(strings.TrimSuffix(trimmed, "%"), 64); err == nil && finite(f) {
+				return f
+			}
+		}
+		return value
+	case map[string]interface{}:
+		prepared := make(map[string]interface{}, len(value))
+		for k, child := range value {
+			prepared[k] = prepare(child)
+		}
+		return prepared
+	case []interface{}:
+		prepared := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			if m, ok := item.(map[string]interface{}); ok && len(m) == 2 {
+				if name, ok := namedValue(m); ok {
+					item = map[string]interface{}{"Timestamp": m["Timestamp"], "Value": m[name]}
+				}
+			}
+			prepared = append(prepared, prepare(item))
+		}
+		return prepared
+	}
+	return v
+}
+
+// namedValue is the key of the number next to the Timestamp of a list item.
+func namedValue(m map[string]interface{}) (string, bool) {
+	if _, ok := m["Timestamp"]; !ok {
+		return "", false
+	}
+	for k, v := range m {
+		if _, isNumber := v.(float64); isNumber && k != "Timestamp" && k != "Value" {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+func round(v float64, decimals int) *float64 {
+	scale := math.Pow(10, float64(decimals))
+	rounded := math.Round(v*scale) / scale
+	return &rounded
+}
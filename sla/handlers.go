@@ -0,0 +1,138 @@
+package sla
+
+import (
+	"awsx-api/handlers"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ownParams are the parameters of the sla endpoints, all others select the element of an
+// objective given by parameters.
+var ownParams = map[string]bool{
+	"slo": true, "elementType": true, "objective": true, "target": true, "window": true, "panel": true,
+	"series": true, "threshold": true, "month": true, "tz": true,
+}
+
+// GetSlaDetails reports the attainment, error budget and burn rates of the objectives of the
+// slo files, or only the one named by slo, or of an objective given by the parameters.
+func GetSlaDetails(w http.ResponseWriter, r *http.Request) {
+	slos, code, err := selectSLOs(r.URL.Query())
+	if err != nil {
+		respondWithError(w, code, err.Error())
+		return
+	}
+	now := time.Now().UTC()
+	statuses := make([]Status, 0, len(slos))
+	for _, slo := range slos {
+		statuses = append(statuses, Evaluate(r, slo, now))
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"slos": statuses})
+}
+
+// GetSlaReport reports how the objectives did over a calendar month, with their incidents.
+func GetSlaReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	location := time.UTC
+	if tz := query.Get("tz"); tz != "" {
+		var err error
+		if location, err = time.LoadLocation(tz); err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid tz [%s]", tz))
+			return
+		}
+	}
+	month, err := Month(query.Get("month"), location, time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	slos, code, err := selectSLOs(query)
+	if err != nil {
+		respondWithError(w, code, err.Error())
+		return
+	}
+	reports := make([]MonthlySLA, 0, len(slos))
+	for _, slo := range slos {
+		reports = append(reports, Report(r, slo, month))
+	}
+	handlers.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"month":     month.From.In(location).Format("2006-01"),
+		"timeRange": month,
+		"slos":      reports,
+	})
+}
+
+// selectSLOs is the objective given by the parameters when there is an elementType, else the
+// objectives of the slo files, or the one named by slo.
+func selectSLOs(query url.Values) ([]SLO, int, error) {
+	if query.Get("elementType") != "" {
+		slo, err := sloOf(query)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return []SLO{slo}, 0, nil
+	}
+	name := query.Get("slo")
+	if name == "" {
+		return SLOs(), 0, nil
+	}
+	for _, slo := range SLOs() {
+		if slo.Name == name {
+			return []SLO{slo}, 0, nil
+		}
+	}
+	return nil, http.StatusNotFound, fmt.Errorf("unknown slo [%s]", name)
+}
+
+// sloOf reads an objective from the parameters.
+func sloOf(query url.Values) (SLO, error) {
+	slo := SLO{
+		ElementType: query.Get("elementType"),
+		Element:     map[string]string{},
+		Objective:   query.Get("objective"),
+		Panel:       query.Get("panel"),
+		Series:      query.Get("series"),
+	}
+	for k := range query {
+		if !ownParams[k] {
+			slo.Element[k] = query.Get(k)
+		}
+	}
+	if value := query.Get("target"); value != "" {
+		var err error
+		if slo.Target, err = strconv.ParseFloat(value, 64); err != nil {
+			return slo, fmt.Errorf("invalid target [%s]", value)
+		}
+	}
+	if value := query.Get("threshold"); value != "" {
+		var err error
+		if slo.Threshold, err = strconv.ParseFloat(value, 64); err != nil {
+			return slo, fmt.Errorf("invalid threshold [%s]", value)
+		}
+	}
+	if value := query.Get("window"); value != "" {
+		window, err := parseWindow(value)
+		if err != nil {
+			return slo, err
+		}
+		slo.Window = Window(window)
+	}
+	slo.Name = slo.ElementType + "/" + elementOf(slo.Element)
+	if err := slo.validate(); err != nil {
+		return slo, err
+	}
+	return slo, nil
+}
+
+func elementOf(element map[string]string) string {
+	if element["elementId"] != "" {
+		return element["elementId"]
+	}
+	return element["instanceId"]
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	handlers.RespondWithJSON(w, code, map[string]string{"error": message})
+}
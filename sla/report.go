@@ -0,0 +1,213 @@
+package sla
+
+import (
+	"awsx-api/panel"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sourceIndicator = "indicator"
+	sourceLogs      = "logs"
+)
+
+// incidentPanels are the panels that list the downtime incidents of an element type.
+var incidentPanels = map[string]string{
+	"APIGATEWAY": "downtime_incident_panel",
+}
+
+// serverErrorPanels are the panels that count the server errors of an element type.
+var serverErrorPanels = map[string]string{
+	"APIGATEWAY": "5xx_errors_panel",
+}
+
+// MonthlySLA is how an objective did over a calendar month.
+type MonthlySLA struct {
+	SLO       SLO             `json:"slo"`
+	TimeRange panel.TimeRange `json:"timeRange"`
+	// Attainment is the percentage of the month, up to now for the current month, the
+	// objective was met.
+	Attainment             *float64   `json:"attainment,omitempty"`
+	Met                    *bool      `json:"met,omitempty"`
+	AllowedDowntimeMinutes float64    `json:"allowedDowntimeMinutes"`
+	DowntimeMinutes        *float64   `json:"downtimeMinutes,omitempty"`
+	ServerErrors           *float64   `json:"serverErrors,omitempty"`
+	Incidents              []Incident `json:"incidents"`
+	Errors                 []string   `json:"errors,omitempty"`
+}
+
+// Incident is a time the objective was missed. Incidents of the indicator are the runs of
+// points below the target; incidents of the logs are the events of the incident panel of the
+// element type, which have no end.
+type Incident struct {
+	Source          string     `json:"source"`
+	Start           time.Time  `json:"start"`
+	End             *time.Time `json:"end,omitempty"`
+	DurationMinutes *float64   `json:"durationMinutes,omitempty"`
+	// Worst is the lowest availability, or the highest latency, of the incident.
+	Worst     *float64 `json:"worst,omitempty"`
+	EventType string   `json:"eventType,omitempty"`
+	Message   string   `json:"message,omitempty"`
+}
+
+// Month is the time range of a calendar month, YYYY-MM, in a location, cut off at now. The
+// current month is the default.
+func Month(month string, location *time.Location, now time.Time) (panel.TimeRange, error) {
+	start := time.Date(now.In(location).Year(), now.In(location).Month(), 1, 0, 0, 0, 0, location)
+	if month != "" {
+		parsed, err := time.ParseInLocation("2006-01", month, location)
+		if err != nil {
+			return panel.TimeRange{}, fmt.Errorf("invalid month [%s], expected e.g. 2024-03", month)
+		}
+		start = parsed
+	}
+	if start.After(now) {
+		return panel.TimeRange{}, fmt.Errorf("month [%s] has not started yet", month)
+	}
+	end := start.AddDate(0, 1, 0)
+	if end.After(now) {
+		end = now
+	}
+	return panel.TimeRange{From: start.UTC(), To: end.UTC()}, nil
+}
+
+// Report reports how an objective did over a month, with its incidents and, for API Gateway,
+// the 5xx errors.
+func Report(r *http.Request, slo SLO, month panel.TimeRange) MonthlySLA {
+	report := MonthlySLA{SLO: slo, TimeRange: month, Incidents: []Incident{}}
+	// the allowed downtime is that of the whole month, even while it is not over
+	from := month.From
+	monthMinutes := from.AddDate(0, 1, 0).Sub(from).Minutes()
+	report.AllowedDowntimeMinutes = *round(slo.budget()*monthMinutes, 2)
+
+	var points []panel.Point
+	var logs interface{}
+	var serverErrors []panel.Point
+	errs := make([]error, 3)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		points, errs[0] = measure(r, slo, slo.Panel, slo.Series, month.From, month.To)
+	}()
+	if query, ok := incidentPanels[slo.ElementType]; ok && slo.Objective == ObjectiveAvailability {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logs, errs[1] = run(r, slo, query, month.From, month.To)
+		}()
+	}
+	if query, ok := serverErrorPanels[slo.ElementType]; ok {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serverErrors, errs[2] = measure(r, slo, query, "", month.From, month.To)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		}
+	}
+
+	if errs[0] == nil {
+		attainment := slo.attainment(points)
+		met := attainment*100 >= slo.Target
+		report.Attainment = round(attainment*100, 4)
+		report.Met = &met
+		report.DowntimeMinutes = round((1-attainment)*month.To.Sub(month.From).Minutes(), 2)
+		report.Incidents = append(report.Incidents, slo.incidents(points, month.To)...)
+	}
+	if errs[1] == nil && logs != nil {
+		report.Incidents = append(report.Incidents, logIncidents(logs)...)
+	}
+	if errs[2] == nil && serverErrors != nil {
+		total := 0.0
+		for _, p := range serverErrors {
+			total += p.Value
+		}
+		report.ServerErrors = &total
+	}
+	sort.SliceStable(report.Incidents, func(i, j int) bool { return report.Incidents[i].Start.Before(report.Incidents[j].Start) })
+	return report
+}
+
+// incidents are the runs of points below the target. An incident ends at the next good point,
+// or one step after its last point when it lasts until the end.
+func (slo SLO) incidents(points []panel.Point, end time.Time) []Incident {
+	var incidents []Incident
+	var current *Incident
+	finish := func(at time.Time) {
+		if current == nil {
+			return
+		}
+		if at.After(end) {
+			at = end
+		}
+		current.End = &at
+		current.DurationMinutes = round(at.Sub(current.Start).Minutes(), 2)
+		incidents = append(incidents, *current)
+		current = nil
+	}
+	for i, p := range points {
+		bad := slo.good(p.Value)*100 < slo.Target
+		switch {
+		case bad && current == nil:
+			worst := p.Value
+			current = &Incident{Source: sourceIndicator, Start: p.Time, Worst: &worst}
+		case bad:
+			if (slo.Objective == ObjectiveLatency && p.Value > *current.Worst) || (slo.Objective == ObjectiveAvailability && p.Value < *current.Worst) {
+				*current.Worst = p.Value
+			}
+		case current != nil:
+			finish(p.Time)
+		}
+		if current != nil && i == len(points)-1 {
+			step := time.Minute
+			if i > 0 {
+				step = p.Time.Sub(points[i-1].Time)
+			}
+			finish(p.Time.Add(step))
+		}
+	}
+	return incidents
+}
+
+// logIncidents reads the Logs Insights results of an incident panel, rows of @timestamp,
+// eventType and errorMessage fields.
+func logIncidents(v interface{}) []Incident {
+	var incidents []Incident
+	outputs, _ := v.([]interface{})
+	for _, output := range outputs {
+		m, _ := output.(map[string]interface{})
+		rows, _ := m["Results"].([]interface{})
+		for _, row := range rows {
+			fields, _ := row.([]interface{})
+			incident := Incident{Source: sourceLogs}
+			for _, field := range fields {
+				f, _ := field.(map[string]interface{})
+				name, _ := f["Field"].(string)
+				value, _ := f["Value"].(string)
+				switch name {
+				case "@timestamp":
+					if t, err := time.Parse("2006-01-02 15:04:05.000", value); err == nil {
+						incident.Start = t
+					}
+				case "eventType":
+					incident.EventType = value
+				case "errorMessage":
+					incident.Message = strings.TrimSpace(value)
+				}
+			}
+			if !incident.Start.IsZero() {
+				incidents = append(incidents, incident)
+			}
+		}
+	}
+	return incidents
+}
@@ -0,0 +1,225 @@
+package sla
+
+import (
+	"awsx-api/handlers"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ObjectiveAvailability = "availability"
+	ObjectiveLatency      = "latency"
+
+	defaultTarget = 99.9
+	defaultWindow = 30 * 24 * time.Hour
+)
+
+// availabilityPanels are the default panels of availability objectives by element type.
+var availabilityPanels = map[string]string{
+	"RDS":        "uptime_percentage_panel",
+	"APIGATEWAY": "uptime_percentage_panel",
+	"EKS":        "service_availability_panel",
+}
+
+// fullValues are the panels that are not percentages, by elementType/query. node_uptime_panel
+// is 1 for every minute a node was up and 0 otherwise.
+var fullValues = map[string]float64{
+	"EKS/node_uptime_panel": 1,
+}
+
+// Window is the window of an objective: minutes, hours, days or weeks, e.g. 30d or 4w.
+type Window time.Duration
+
+func (w *Window) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := parseWindow(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %v", node.Line, err)
+	}
+	*w = Window(parsed)
+	return nil
+}
+
+func (w Window) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + formatWindow(time.Duration(w)) + `"`), nil
+}
+
+var windowUnits = map[byte]time.Duration{'m': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+
+func parseWindow(value string) (time.Duration, error) {
+	if value != "" {
+		if unit, ok := windowUnits[value[len(value)-1]]; ok {
+			if n, err := strconv.Atoi(value[:len(value)-1]); err == nil && n > 0 {
+				return time.Duration(n) * unit, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid window [%s], expected e.g. 30m, 6h, 28d or 4w", value)
+}
+
+// formatWindow writes a window in the largest unit it is a whole number of.
+func formatWindow(d time.Duration) string {
+	for _, unit := range []byte{'w', 'd', 'h'} {
+		if d >= windowUnits[unit] && d%windowUnits[unit] == 0 {
+			return strconv.FormatInt(int64(d/windowUnits[unit]), 10) + string(unit)
+		}
+	}
+	return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+}
+
+// SLO is a service level objective of an element: the share of the window an element is
+// available, or the share of the window its latency is at most the threshold. The element is
+// given by the panel parameters that select it, elementId or instanceId with zone,
+// crossAccountRoleArn and externalId.
+type SLO struct {
+	Name        string            `yaml:"name" json:"name"`
+	ElementType string            `yaml:"elementType" json:"elementType"`
+	Element     map[string]string `yaml:"element" json:"element"`
+	Objective   string            `yaml:"objective,omitempty" json:"objective"`
+	// Target is the percentage of the window the objective is met, e.g. 99.9.
+	Target float64 `yaml:"target,omitempty" json:"target"`
+	Window Window  `yaml:"window,omitempty" json:"window"`
+	// Panel is the query of the panel the objective is measured with. Availability objectives
+	// of RDS, APIGATEWAY and EKS have a default, latency objectives need one.
+	Panel  string `yaml:"panel,omitempty" json:"panel"`
+	Series string `yaml:"series,omitempty" json:"series,omitempty"`
+	// Threshold is the latency, in the unit of the panel, that is still good.
+	Threshold float64 `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+}
+
+type sloFile struct {
+	SLOs []SLO `yaml:"slos"`
+}
+
+var (
+	definitions     []SLO
+	definitionsLock sync.RWMutex
+)
+
+// Load reads the objectives of the files matching the glob patterns, which /awsx-api/getSlaDetails
+// then reports on. Names must be unique across all files.
+func Load(patterns []string) error {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid slo file pattern [%s]: %v", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	var slos []SLO
+	names := map[string]string{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read slo file [%s]: %v", file, err)
+		}
+		fileSLOs, err := ParseSLOs(content)
+		if err != nil {
+			return fmt.Errorf("slo file [%s]: %v", file, err)
+		}
+		for _, slo := range fileSLOs {
+			if other, ok := names[slo.Name]; ok {
+				return fmt.Errorf("slo file [%s]: slo [%s] is already defined in [%s]", file, slo.Name, other)
+			}
+			names[slo.Name] = file
+		}
+		slos = append(slos, fileSLOs...)
+	}
+	definitionsLock.Lock()
+	definitions = slos
+	definitionsLock.Unlock()
+	return nil
+}
+
+// SLOs are the objectives Load read.
+func SLOs() []SLO {
+	definitionsLock.RLock()
+	defer definitionsLock.RUnlock()
+	return definitions
+}
+
+// ParseSLOs reads and validates the objectives of an slo file and fills in their defaults.
+func ParseSLOs(content []byte) ([]SLO, error) {
+	var file sloFile
+	decoder := yaml.NewDecoder(strings.NewReader(string(content)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse yaml: %v", err)
+	}
+	for i := range file.SLOs {
+		if err := file.SLOs[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	return file.SLOs, nil
+}
+
+func (slo *SLO) validate() error {
+	if slo.Name == "" {
+		return fmt.Errorf("slo without name")
+	}
+	if slo.Element["elementId"] == "" && slo.Element["instanceId"] == "" {
+		return fmt.Errorf("slo [%s]: the element needs an elementId or instanceId", slo.Name)
+	}
+	switch slo.Objective {
+	case "":
+		slo.Objective = ObjectiveAvailability
+	case ObjectiveAvailability, ObjectiveLatency:
+	default:
+		return fmt.Errorf("slo [%s]: invalid objective [%s], expected availability or latency", slo.Name, slo.Objective)
+	}
+	if slo.Panel == "" {
+		query, ok := availabilityPanels[slo.ElementType]
+		if !ok || slo.Objective != ObjectiveAvailability {
+			return fmt.Errorf("slo [%s]: no default %s panel for elementType [%s], set panel", slo.Name, slo.Objective, slo.ElementType)
+		}
+		slo.Panel = query
+	}
+	if _, ok := handlers.Panels().Lookup(slo.ElementType, slo.Panel); !ok {
+		return fmt.Errorf("slo [%s]: unknown panel [%s] of elementType [%s]", slo.Name, slo.Panel, slo.ElementType)
+	}
+	if !finite(slo.Threshold) {
+		return fmt.Errorf("slo [%s]: invalid threshold %g, expected a number", slo.Name, slo.Threshold)
+	}
+	if slo.Objective == ObjectiveLatency && slo.Threshold <= 0 {
+		return fmt.Errorf("slo [%s]: a latency objective needs a threshold", slo.Name)
+	}
+	if slo.Target == 0 {
+		slo.Target = defaultTarget
+	}
+	if !finite(slo.Target) || slo.Target <= 0 || slo.Target >= 100 {
+		return fmt.Errorf("slo [%s]: invalid target %g, expected a percentage below 100, e.g. 99.9", slo.Name, slo.Target)
+	}
+	if slo.Window == 0 {
+		slo.Window = Window(defaultWindow)
+	}
+	return nil
+}
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// full is the value of the panel of an availability objective that means fully available.
+func (slo SLO) full() float64 {
+	if full, ok := fullValues[slo.ElementType+"/"+slo.Panel]; ok {
+		return full
+	}
+	return 100
+}
+
+// budget is the share of the window the objective may be missed, e.g. 0.001 for 99.9.
+func (slo SLO) budget() float64 {
+	return 1 - slo.Target/100
+}
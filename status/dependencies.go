@@ -0,0 +1,186 @@
+package status
+
+import (
+	"awsx-api/config"
+	"awsx-api/log"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	commonconfig "github.com/Appkube-awsx/awsx-common/config"
+)
+
+const (
+	DependencyCmdb  = "cmdb"
+	DependencyVault = "vault"
+
+	StatusUp      = "up"
+	StatusDown    = "down"
+	StatusUnknown = "unknown"
+
+	defaultCheckTimeout = 3 * time.Second
+)
+
+// Dependency is the last known state of an external service awsx-api needs to answer queries.
+// The url and the error as the http client reported it stay out of the public health report,
+// the admin listener shows them.
+type Dependency struct {
+	Name      string `json:"name"`
+	Url       string `json:"-"`
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+	// LastError is the generic reason of the last failed check, e.g. timeout.
+	LastError   string     `json:"lastError,omitempty"`
+	Error       string     `json:"-"`
+	LastCheck   *time.Time `json:"lastCheck,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+}
+
+var (
+	dependencies     = map[string]*Dependency{}
+	dependenciesLock sync.RWMutex
+	checksStarted    sync.Once
+)
+
+// StartDependencyChecks checks CMDB and vault right away and then every check_interval seconds
+// until ctx is done. Readiness and the health report read the results, so probes never wait
+// for a dependency.
+func StartDependencyChecks(ctx context.Context) {
+	checksStarted.Do(func() {
+		conf := config.Get().Server.Health
+		interval := time.Duration(conf.CheckInterval) * time.Second
+		timeout := time.Duration(conf.CheckTimeout) * time.Second
+		if timeout <= 0 {
+			timeout = defaultCheckTimeout
+		}
+		client := &http.Client{Timeout: timeout}
+		urls := dependencyUrls()
+
+		dependenciesLock.Lock()
+		for name, url := range urls {
+			dependencies[name] = &Dependency{Name: name, Url: url, Status: StatusUnknown}
+		}
+		dependenciesLock.Unlock()
+
+		checkAll(ctx, client, urls)
+		if interval <= 0 {
+			return
+		}
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					checkAll(ctx, client, urls)
+				}
+			}
+		}()
+	})
+}
+
+// dependencyUrls prefers the urls of the awsx-api config over the defaults of awsx-common.
+func dependencyUrls() map[string]string {
+	conf := config.Get()
+	urls := map[string]string{
+		DependencyCmdb:  commonconfig.CmdbUrl,
+		DependencyVault: commonconfig.VaultUrl,
+	}
+	if conf.CloudElement.Url != "" {
+		urls[DependencyCmdb] = conf.CloudElement.Url
+	}
+	if conf.Vault.Url != "" {
+		urls[DependencyVault] = conf.Vault.Url
+	}
+	return urls
+}
+
+func checkAll(ctx context.Context, client *http.Client, urls map[string]string) {
+	var wg sync.WaitGroup
+	for name, url := range urls {
+		wg.Add(1)
+		go func(name, url string) {
+			defer wg.Done()
+			start := time.Now()
+			err := check(ctx, client, url)
+			record(name, err, time.Since(start))
+		}(name, url)
+	}
+	wg.Wait()
+}
+
+// check succeeds when the service answers at all; client errors such as 404 on the base url
+// still prove it is reachable, only 5xx and transport errors count as down.
+func check(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func record(name string, err error, latency time.Duration) {
+	now := time.Now()
+	dependenciesLock.Lock()
+	defer dependenciesLock.Unlock()
+	dep := dependencies[name]
+	wasDown := dep.Status == StatusDown
+	dep.LatencyMs = latency.Milliseconds()
+	dep.LastCheck = &now
+	if err != nil {
+		if !wasDown {
+			log.Warningf("Dependency [%s] at [%s] is not reachable: %v", name, dep.Url, err)
+		}
+		dep.Status = StatusDown
+		dep.LastError = reasonOf(err)
+		dep.Error = err.Error()
+		return
+	}
+	if wasDown {
+		log.Infof("Dependency [%s] at [%s] is reachable again", name, dep.Url)
+	}
+	dep.Status = StatusUp
+	dep.LastSuccess = &now
+}
+
+// reasonOf tells why a check failed without the addresses the http client puts into
+// transport errors, the reasons and the health report are public.
+func reasonOf(err error) string {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return "timeout"
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return "unreachable"
+	}
+	return err.Error()
+}
+
+// Dependencies returns the state of all checked dependencies, sorted by name.
+func Dependencies() []Dependency {
+	dependenciesLock.RLock()
+	defer dependenciesLock.RUnlock()
+	deps := make([]Dependency, 0, len(dependencies))
+	for _, dep := range dependencies {
+		deps = append(deps, *dep)
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps
+}
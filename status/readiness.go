@@ -0,0 +1,27 @@
+package status
+
+import (
+	"awsx-api/appstate"
+	"fmt"
+)
+
+// NotReadyReasons explains why the server should not receive traffic; it is empty when the
+// server is ready.
+func NotReadyReasons() []string {
+	reasons := []string{}
+	if !IsConfigLoaded() {
+		reasons = append(reasons, "configuration is not loaded")
+	}
+	if appstate.IsDraining() {
+		reasons = append(reasons, "server is shutting down")
+	}
+	for _, dep := range Dependencies() {
+		switch dep.Status {
+		case StatusDown:
+			reasons = append(reasons, fmt.Sprintf("%s is down: %s", dep.Name, dep.LastError))
+		case StatusUnknown:
+			reasons = append(reasons, fmt.Sprintf("%s has not been checked yet", dep.Name))
+		}
+	}
+	return reasons
+}
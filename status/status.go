@@ -0,0 +1,64 @@
+package status
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	CoreVersion    = "awsx-api core version"
+	CoreCommitHash = "awsx-api core commit hash"
+	GoVersion      = "Go version"
+)
+
+var (
+	info      = map[string]string{GoVersion: runtime.Version()}
+	infoLock  sync.RWMutex
+	startedAt = time.Now()
+
+	configLoaded     bool
+	configLoadedLock sync.RWMutex
+)
+
+// Put records a piece of build or runtime information reported by the health endpoint.
+func Put(name, value string) {
+	infoLock.Lock()
+	defer infoLock.Unlock()
+	info[name] = value
+}
+
+// Info returns a copy of the recorded build and runtime information.
+func Info() map[string]string {
+	infoLock.RLock()
+	defer infoLock.RUnlock()
+	copy := make(map[string]string, len(info))
+	for k, v := range info {
+		copy[k] = v
+	}
+	return copy
+}
+
+// StartedAt is the time the process started.
+func StartedAt() time.Time {
+	return startedAt
+}
+
+// Uptime is the time passed since the process started.
+func Uptime() time.Duration {
+	return time.Since(startedAt)
+}
+
+// SetConfigLoaded marks the configuration as loaded and validated.
+func SetConfigLoaded() {
+	configLoadedLock.Lock()
+	defer configLoadedLock.Unlock()
+	configLoaded = true
+}
+
+// IsConfigLoaded reports whether SetConfigLoaded was called.
+func IsConfigLoaded() bool {
+	configLoadedLock.RLock()
+	defer configLoadedLock.RUnlock()
+	return configLoaded
+}
@@ -37,7 +37,6 @@ func HandleHttpRequest(httpMethod string, url string, apiKey string, payload io.
 		return nil, http.StatusInternalServerError, err
 	}
 	if httpResponse.StatusCode != http.StatusOK && httpResponse.StatusCode != http.StatusCreated {
-		fmt.Errorf("Unable to get data from URL: %s due to status code: %d", url, httpResponse.StatusCode)
 		return nil, httpResponse.StatusCode, fmt.Errorf("unable to fetch data from url: %s", url)
 	}
 	return data, httpResponse.StatusCode, nil